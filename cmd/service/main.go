@@ -7,16 +7,23 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 	"w2learn/internal/config"
 	"w2learn/internal/controller"
+	"w2learn/internal/mailer"
 	"w2learn/internal/model"
+	"w2learn/internal/notifier"
+	"w2learn/internal/oidc"
 	"w2learn/internal/repository"
 	"w2learn/internal/router"
+	"w2learn/internal/scheduler"
 	"w2learn/internal/service"
 	"w2learn/internal/utils"
+	"w2learn/internal/validation"
 	"w2learn/pkg/database"
+	"w2learn/pkg/def"
 	"w2learn/pkg/logger"
 
 	"go.uber.org/zap"
@@ -114,7 +121,22 @@ func main() {
 
 	if cfg.Database.AutoMigrate {
 		logger.Info("AutoMigrate Start")
-		err := db.AutoMigrate(&model.User{}, &model.Habit{})
+
+		// 唯一索引 idx_habits_user_name 建立之前先处理历史数据中重名的未归档习惯
+		if db.Migrator().HasTable(&model.Habit{}) {
+			renamed, err := repository.NewHabitRepository(db).RenameDuplicateNames(context.Background())
+
+			if err != nil {
+				logger.Fatal("Rename Duplicate Habits Fail", zap.Error(err))
+				return
+			}
+
+			if renamed > 0 {
+				logger.Warn("Renamed duplicate habit names", zap.Int64("count", renamed))
+			}
+		}
+
+		err := db.AutoMigrate(&model.User{}, &model.Category{}, &model.Tag{}, &model.Habit{}, &model.HabitCheckIn{}, &model.HabitStreak{}, &model.HabitPause{}, &model.HabitReminder{}, &model.Notification{}, &model.UserSession{}, &model.Role{}, &model.UserTwoFactor{}, &model.UserRecoveryCode{}, &model.PersonalAccessToken{}, &model.UserIdentity{}, &model.AuditLog{})
 
 		if err != nil {
 			logger.Fatal("AutoMigrate Fail", zap.Error(err))
@@ -124,8 +146,68 @@ func main() {
 	}
 	logger.Info("Init Database End")
 
+	// 开发环境缺少的密钥在启动时生成，密钥目录不纳入版本库
+	if serviceType == config.ServiceTypeDev {
+		logger.Info("Prepare Dev Keys Start")
+
+		for _, keyConfig := range cfg.JWT.Keys {
+			if keyConfig.PrivateKeyFile == "" {
+				continue
+			}
+
+			created, err := utils.GenerateJWTKeyFile(keyConfig.PrivateKeyFile)
+
+			if err != nil {
+				logger.Fatal("Generate JWT Key Fail", zap.Error(err), zap.String("kid", keyConfig.ID))
+				return
+			}
+
+			if created {
+				logger.Info("Generated dev JWT key", zap.String("kid", keyConfig.ID), zap.String("file", keyConfig.PrivateKeyFile))
+			}
+		}
+
+		if cfg.TwoFactor.EncryptionKey == "" && cfg.TwoFactor.EncryptionKeyFile != "" {
+			created, err := utils.GenerateSecretFile(cfg.TwoFactor.EncryptionKeyFile)
+
+			if err != nil {
+				logger.Fatal("Generate Encryption Key Fail", zap.Error(err))
+				return
+			}
+
+			if created {
+				logger.Info("Generated dev encryption key", zap.String("file", cfg.TwoFactor.EncryptionKeyFile))
+			}
+		}
+
+		logger.Info("Prepare Dev Keys End")
+	}
+
 	logger.Info("Init Utils Start")
-	utils.InitJwt(cfg.Session.Secret)
+	jwtKeys := make([]*utils.JWTKey, 0, len(cfg.JWT.Keys))
+
+	for _, keyConfig := range cfg.JWT.Keys {
+		key, err := utils.LoadJWTKey(keyConfig.ID, keyConfig.PrivateKeyFile, keyConfig.PublicKeyFile)
+
+		if err != nil {
+			logger.Fatal("Load JWT Key Fail", zap.Error(err), zap.String("kid", keyConfig.ID))
+			return
+		}
+
+		jwtKeys = append(jwtKeys, key)
+	}
+
+	err = utils.InitJwt(&utils.JWTConfig{
+		Secret:             cfg.Session.Secret,
+		Keys:               jwtKeys,
+		SigningKeyID:       cfg.JWT.SigningKey,
+		AcceptLegacyTokens: cfg.JWT.AcceptLegacyTokens,
+	})
+
+	if err != nil {
+		logger.Fatal("Init JWT Fail", zap.Error(err))
+		return
+	}
 	logger.Info("Init Utils End")
 
 	logger.Info("Init Redis Start")
@@ -160,13 +242,192 @@ func main() {
 	healthRepo := repository.NewHealthRepository()
 	userRepo := repository.NewUserRepository(db)
 	habitRepo := repository.NewHabitRepository(db)
+	habitCheckInRepo := repository.NewHabitCheckInRepository(db)
+	habitStreakRepo := repository.NewHabitStreakRepository(db)
+	habitPauseRepo := repository.NewHabitPauseRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	habitReminderRepo := repository.NewHabitReminderRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(redis)
+	userSessionRepo := repository.NewUserSessionRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	twoFactorChallengeRepo := repository.NewTwoFactorChallengeRepository(redis)
+	passwordResetRepo := repository.NewPasswordResetRepository(redis)
+	emailVerificationRepo := repository.NewEmailVerificationRepository(redis)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(redis)
+	personalAccessTokenRepo := repository.NewPersonalAccessTokenRepository(db)
+	userIdentityRepo := repository.NewUserIdentityRepository(db)
+	oidcStateRepo := repository.NewOIDCStateRepository(redis)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	tokenRevocationRepo := repository.NewTokenRevocationRepository(redis, time.Duration(cfg.Session.AccessTokenTTL)*time.Second)
 	logger.Info("Init Repo End")
 
+	logger.Info("Init Mailer Start")
+	var mail mailer.Mailer
+
+	switch cfg.Mail.Driver {
+	case def.MailDriverSMTP:
+		mail = mailer.NewSMTPMailer(&mailer.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			Timeout:  time.Duration(cfg.SMTP.Timeout) * time.Second,
+		})
+	case def.MailDriverFile:
+		fileDir := cfg.Mail.FileDir
+
+		if fileDir == "" {
+			fileDir = def.MailDefaultFileDir
+		}
+
+		mail = mailer.NewFileMailer(fileDir, cfg.SMTP.From)
+	default:
+		mail = mailer.NewLogMailer()
+	}
+	logger.Info("Init Mailer End")
+
 	logger.Info("Init Service Start")
+	passwordHasher := utils.NewArgon2idHasher(&utils.Argon2Params{
+		Memory:      cfg.Password.Memory,
+		Iterations:  cfg.Password.Iterations,
+		Parallelism: cfg.Password.Parallelism,
+		SaltLength:  cfg.Password.SaltLength,
+		KeyLength:   cfg.Password.KeyLength,
+	})
+
+	encryptionKey := cfg.TwoFactor.EncryptionKey
+
+	if encryptionKey == "" && cfg.TwoFactor.EncryptionKeyFile != "" {
+		encryptionKey, err = utils.ReadSecretFile(cfg.TwoFactor.EncryptionKeyFile)
+
+		if err != nil {
+			logger.Fatal("Read Encryption Key Fail", zap.Error(err))
+			return
+		}
+	}
+
+	secretCipher, err := utils.NewSecretCipher(encryptionKey)
+
+	if err != nil {
+		logger.Fatal("Init Secret Cipher Fail", zap.Error(err))
+		return
+	}
+	emailConfig := &service.EmailConfig{
+		RequireVerification: cfg.Account.RequireEmailVerification,
+		VerifyTokenTTL:      time.Duration(cfg.Account.VerifyTokenTTL) * time.Second,
+		VerifyURL:           cfg.Account.VerifyURL,
+	}
 	healthService := service.NewHealthService(healthRepo)
-	userService := service.NewUserService(userRepo, habitRepo)
-	habitService := service.NewHabitService(habitRepo, userRepo)
-	authService := service.NewAuthService(userRepo, redis)
+	userService := service.NewUserService(userRepo, habitRepo, userSessionRepo, refreshTokenRepo, loginAttemptRepo, auditLogRepo, tokenRevocationRepo, personalAccessTokenRepo, passwordHasher)
+	habitService := service.NewHabitService(habitRepo, userRepo, habitCheckInRepo, habitStreakRepo, habitPauseRepo, tagRepo, categoryRepo)
+	authService := service.NewAuthService(
+		&service.AuthConfig{
+			AccessTokenTTL:        time.Duration(cfg.Session.AccessTokenTTL) * time.Second,
+			RefreshTokenTTL:       time.Duration(cfg.Session.RefreshTokenTTL) * time.Second,
+			MaxSessions:           cfg.Session.MaxSessions,
+			TwoFactorChallengeTTL: time.Duration(cfg.TwoFactor.ChallengeTTL) * time.Second,
+			LoginThrottle: service.LoginThrottleConfig{
+				Window:             time.Duration(cfg.Login.Window) * time.Second,
+				BackoffThreshold:   int64(cfg.Login.BackoffThreshold),
+				BackoffBase:        time.Duration(cfg.Login.BackoffBase) * time.Second,
+				BackoffMax:         time.Duration(cfg.Login.BackoffMax) * time.Second,
+				LockThreshold:      int64(cfg.Login.LockThreshold),
+				LockDuration:       time.Duration(cfg.Login.LockDuration) * time.Second,
+				IPBackoffThreshold: int64(cfg.Login.IPBackoffThreshold),
+			},
+		},
+		userRepo,
+		refreshTokenRepo,
+		userSessionRepo,
+		twoFactorRepo,
+		twoFactorChallengeRepo,
+		emailVerificationRepo,
+		loginAttemptRepo,
+		auditLogRepo,
+		emailConfig,
+		passwordHasher,
+		secretCipher,
+		mail,
+		tokenRevocationRepo,
+		personalAccessTokenRepo,
+	)
+	twoFactorService := service.NewTwoFactorService(
+		&service.TwoFactorConfig{
+			Issuer: cfg.TwoFactor.Issuer,
+		},
+		userRepo,
+		twoFactorRepo,
+		auditLogRepo,
+		passwordHasher,
+		secretCipher,
+	)
+	tagService := service.NewTagService(tagRepo, habitRepo, userRepo, habitCheckInRepo)
+	categoryService := service.NewCategoryService(categoryRepo)
+	reminderService := service.NewReminderService(habitRepo, habitReminderRepo, userRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	statsService := service.NewStatsService(habitRepo, userRepo, habitCheckInRepo)
+	sessionService := service.NewSessionService(userSessionRepo, refreshTokenRepo, redis)
+	passwordService := service.NewPasswordService(
+		&service.PasswordConfig{
+			ResetTokenTTL: time.Duration(cfg.Password.ResetTokenTTL) * time.Second,
+			ResetURL:      cfg.Password.ResetURL,
+		},
+		userRepo,
+		userSessionRepo,
+		refreshTokenRepo,
+		passwordResetRepo,
+		auditLogRepo,
+		tokenRevocationRepo,
+		personalAccessTokenRepo,
+		passwordHasher,
+		mail,
+	)
+	var oidcProvider *oidc.Provider
+
+	if cfg.OIDC.Enabled {
+		oidcProvider = oidc.NewProvider(&oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+			Timeout:      time.Duration(cfg.OIDC.Timeout) * time.Second,
+		})
+	}
+
+	oidcService := service.NewOIDCService(
+		&service.OIDCConfig{
+			StateTTL: time.Duration(cfg.OIDC.StateTTL) * time.Second,
+		},
+		oidcProvider,
+		authService,
+		userRepo,
+		userIdentityRepo,
+		oidcStateRepo,
+		passwordHasher,
+	)
+	accessTokenService := service.NewAccessTokenService(userRepo, personalAccessTokenRepo, auditLogRepo)
+	emailService := service.NewEmailService(emailConfig, userRepo, emailVerificationRepo, mail)
+	auditService := service.NewAuditService(auditLogRepo)
+	roleService := service.NewRoleService(roleRepo, userRepo, userSessionRepo, refreshTokenRepo, auditLogRepo, tokenRevocationRepo, personalAccessTokenRepo)
+
+	err = roleService.EnsureBuiltinRoles(context.Background())
+
+	if err != nil {
+		logger.Fatal("Init Roles Fail", zap.Error(err))
+		return
+	}
+
+	err = roleService.GrantAdmins(context.Background(), cfg.RBAC.Admins)
+
+	if err != nil {
+		logger.Fatal("Grant Admins Fail", zap.Error(err))
+		return
+	}
 	logger.Info("Init Service End")
 
 	logger.Info("Init Controller Start")
@@ -174,10 +435,33 @@ func main() {
 	userController := controller.NewUserController(userService)
 	habitController := controller.NewHabitsController(habitService)
 	authController := controller.NewAuthController(authService)
+	tagController := controller.NewTagController(tagService)
+	categoryController := controller.NewCategoryController(categoryService)
+	reminderController := controller.NewReminderController(reminderService)
+	notificationController := controller.NewNotificationController(notificationService)
+	statsController := controller.NewStatsController(statsService)
+	sessionController := controller.NewSessionController(sessionService)
+	roleController := controller.NewRoleController(roleService)
+	twoFactorController := controller.NewTwoFactorController(twoFactorService)
+	passwordController := controller.NewPasswordController(passwordService)
+	emailController := controller.NewEmailController(emailService)
+	jwksController := controller.NewJWKSController()
+	accessTokenController := controller.NewAccessTokenController(accessTokenService)
+	oidcController := controller.NewOIDCController(oidcService, strings.HasPrefix(cfg.OIDC.RedirectURL, "https://"))
+	auditController := controller.NewAuditController(auditService)
 	logger.Info("Init Controller End")
 
+	logger.Info("Setup Validator Start")
+	err = validation.Setup(habitRepo.GetByUserAndName)
+
+	if err != nil {
+		logger.Fatal("Setup Validator Fail", zap.Error(err))
+		return
+	}
+	logger.Info("Setup Validator End")
+
 	logger.Info("Setup Router Start")
-	r := router.SetupRouter(cfg, redis, healthController, userController, habitController, authController)
+	r := router.SetupRouter(cfg, redis, healthController, userController, habitController, authController, tagController, categoryController, reminderController, notificationController, statsController, sessionController, roleController, twoFactorController, passwordController, emailController, jwksController, accessTokenController, oidcController, auditController, roleService, accessTokenService)
 
 	if r == nil {
 		logger.Fatal("New router err")
@@ -188,6 +472,36 @@ func main() {
 
 	logger.Info("Init Router End")
 
+	logger.Info("Init Scheduler Start")
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+
+	if cfg.Reminder.Enabled {
+		notifiers := []notifier.Notifier{
+			notifier.NewInboxNotifier(notificationRepo),
+			notifier.NewWebhookNotifier(&notifier.WebhookConfig{
+				Secret:  cfg.Webhook.Secret,
+				Timeout: time.Duration(cfg.Webhook.Timeout) * time.Second,
+			}),
+			notifier.NewEmailNotifier(mail),
+		}
+
+		reminderScheduler := scheduler.NewReminderScheduler(
+			&scheduler.ReminderConfig{
+				Interval: time.Duration(cfg.Reminder.Interval) * time.Second,
+				LockTTL:  time.Duration(cfg.Reminder.LockTTL) * time.Second,
+			},
+			habitReminderRepo,
+			habitStreakRepo,
+			habitCheckInRepo,
+			redis,
+			notifier.NewDispatcher(notifiers...),
+		)
+
+		go reminderScheduler.Run(schedulerCtx)
+	}
+	logger.Info("Init Scheduler End")
+
 	logger.Info("Prepare Http Server Start")
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
@@ -218,6 +532,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Shutdown Server ...")
+	stopScheduler()
 
 	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Duration(cfg.Server.CloseTimeout)*time.Second)
 	defer cancelFunc()
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -25,11 +26,22 @@ const (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"service"`
-	Log      LogConfig      `mapstructure:"log"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Session  SessionConfig  `mapstructure:"session"`
+	Server    ServerConfig    `mapstructure:"service"`
+	Log       LogConfig       `mapstructure:"log"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Session   SessionConfig   `mapstructure:"session"`
+	Reminder  ReminderConfig  `mapstructure:"reminder"`
+	Mail      MailConfig      `mapstructure:"mail"`
+	SMTP      SMTPConfig      `mapstructure:"smtp"`
+	Webhook   WebhookConfig   `mapstructure:"webhook"`
+	Password  PasswordConfig  `mapstructure:"password"`
+	RBAC      RBACConfig      `mapstructure:"rbac"`
+	TwoFactor TwoFactorConfig `mapstructure:"two_factor"`
+	Account   AccountConfig   `mapstructure:"account"`
+	Login     LoginConfig     `mapstructure:"login"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	OIDC      OIDCConfig      `mapstructure:"oidc"`
 }
 
 type ServerConfig struct {
@@ -43,8 +55,15 @@ type ServerConfig struct {
 
 	//TODO: 待添加Gin中间件
 	LimitNumber int `mapstructure:"limit_number"`
+
+	// TrustedProxies 为可信反向代理的地址或网段，只有来自这些地址的请求才读取 X-Forwarded-For 中的客户端地址
+	// 未配置时不信任任何代理，TrustedPlatform 为平台写入客户端地址的请求头，如 CF-Connecting-IP，配置后总是信任
+	TrustedProxies  []string `mapstructure:"trusted_proxies"`
+	TrustedPlatform string   `mapstructure:"trusted_platform"`
 }
 
+// DatabaseConfig 中 AutoMigrate 为 true 时启动时迁移表结构，并先为重名的未归档习惯追加 ID 后缀以便建立唯一索引
+// 关闭 AutoMigrate 自行迁移时，需要在创建 idx_habits_user_name 之前手动处理同一用户重名的未归档习惯
 type DatabaseConfig struct {
 	Host         string `mapstructure:"host"`
 	Port         int    `mapstructure:"port"`
@@ -71,8 +90,118 @@ type RedisConfig struct {
 	WriteTimeout int    `mapstructure:"write_timeout"`
 }
 
+// SessionConfig 中 AccessTokenTTL 与 RefreshTokenTTL 单位为秒，未配置时使用默认值
+// MaxSessions 为用户未单独设置时的并发会话数上限
 type SessionConfig struct {
-	Secret string `mapstructure:"secret"`
+	Secret          string `mapstructure:"secret"`
+	AccessTokenTTL  int    `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL int    `mapstructure:"refresh_token_ttl"`
+	MaxSessions     int    `mapstructure:"max_sessions"`
+}
+
+// JWTConfig 中 SigningKey 为签发令牌使用的密钥 ID，Keys 中的其余密钥只用于验证
+// 轮换时先加入新密钥并切换 SigningKey，旧密钥改为只配置公钥，待访问令牌全部过期后再移除
+// 未配置 Keys 时使用 session.secret 以 HS256 签名，配置后只在 AcceptLegacyTokens 为 true 时接受不带 kid 的 HS256 旧令牌
+type JWTConfig struct {
+	SigningKey         string         `mapstructure:"signing_key"`
+	Keys               []JWTKeyConfig `mapstructure:"keys"`
+	AcceptLegacyTokens bool           `mapstructure:"accept_legacy_tokens"`
+}
+
+// JWTKeyConfig 中 PrivateKeyFile 与 PublicKeyFile 为 PEM 文件路径，只用于验证的密钥只需配置公钥
+// 开发环境下私钥文件不存在时启动时自动生成 Ed25519 私钥
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"kid"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+// OIDCConfig 为外部身份提供方的配置，RedirectURL 必须与在身份提供方登记的回调地址一致
+// ClientSecret 为空时作为公共客户端只使用 PKCE，StateTTL 与 Timeout 单位为秒
+type OIDCConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+	StateTTL     int      `mapstructure:"state_ttl"`
+	Timeout      int      `mapstructure:"timeout"`
+}
+
+// ReminderConfig 中 Interval 为调度器扫描间隔，LockTTL 为发送锁的过期时间，单位均为秒
+type ReminderConfig struct {
+	Enabled  bool `mapstructure:"enabled"`
+	Interval int  `mapstructure:"interval"`
+	LockTTL  int  `mapstructure:"lock_ttl"`
+}
+
+// MailConfig 中 Driver 为 smtp/file/log，file 驱动将邮件写入 FileDir，log 驱动只写日志
+type MailConfig struct {
+	Driver  string `mapstructure:"driver"`
+	FileDir string `mapstructure:"file_dir"`
+}
+
+// SMTPConfig 为 smtp 邮件驱动的服务器配置
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	Timeout  int    `mapstructure:"timeout"`
+}
+
+// WebhookConfig 中 Secret 用于对请求体签名
+type WebhookConfig struct {
+	Secret  string `mapstructure:"secret"`
+	Timeout int    `mapstructure:"timeout"`
+}
+
+// PasswordConfig 为 argon2id 的成本参数，Memory 单位为 KiB，未配置时使用默认值
+// ResetTokenTTL 为重置口令令牌的有效期，单位为秒，ResetURL 为重置口令页面地址
+type PasswordConfig struct {
+	Memory        uint32 `mapstructure:"memory"`
+	Iterations    uint32 `mapstructure:"iterations"`
+	Parallelism   uint8  `mapstructure:"parallelism"`
+	SaltLength    uint32 `mapstructure:"salt_length"`
+	KeyLength     uint32 `mapstructure:"key_length"`
+	ResetTokenTTL int    `mapstructure:"reset_token_ttl"`
+	ResetURL      string `mapstructure:"reset_url"`
+}
+
+// RBACConfig 中 Admins 为启动时授予管理员角色的用户名
+type RBACConfig struct {
+	Admins []string `mapstructure:"admins"`
+}
+
+// TwoFactorConfig 中 EncryptionKey 用于加密落库的 TOTP 密钥，未配置时从 EncryptionKeyFile 读取，ChallengeTTL 单位为秒
+// 开发环境下 EncryptionKeyFile 不存在时启动时自动生成
+type TwoFactorConfig struct {
+	Issuer            string `mapstructure:"issuer"`
+	EncryptionKey     string `mapstructure:"encryption_key"`
+	EncryptionKeyFile string `mapstructure:"encryption_key_file"`
+	ChallengeTTL      int    `mapstructure:"challenge_ttl"`
+}
+
+// AccountConfig 中 RequireEmailVerification 为 true 时新注册账户需要验证邮箱后才能正常使用
+// VerifyTokenTTL 为邮箱验证令牌的有效期，单位为秒，VerifyURL 为验证邮箱页面地址
+type AccountConfig struct {
+	RequireEmailVerification bool   `mapstructure:"require_email_verification"`
+	VerifyTokenTTL           int    `mapstructure:"verify_token_ttl"`
+	VerifyURL                string `mapstructure:"verify_url"`
+}
+
+// LoginConfig 为登录失败的限流参数，时间单位为秒，未配置时使用默认值
+// 用户名失败 BackoffThreshold 次后开始指数退避，失败 LockThreshold 次后锁定 LockDuration 秒
+type LoginConfig struct {
+	Window             int `mapstructure:"window"`
+	BackoffThreshold   int `mapstructure:"backoff_threshold"`
+	BackoffBase        int `mapstructure:"backoff_base"`
+	BackoffMax         int `mapstructure:"backoff_max"`
+	LockThreshold      int `mapstructure:"lock_threshold"`
+	LockDuration       int `mapstructure:"lock_duration"`
+	IPBackoffThreshold int `mapstructure:"ip_backoff_threshold"`
 }
 
 type LogConfig struct {
@@ -88,7 +217,8 @@ func Load(serviceType string, configPath string, configType string) (*Config, er
 	viper.SetConfigType(configType)
 	viper.AddConfigPath(configPath)
 
-	// 自动扫描环境
+	// 自动扫描环境，嵌套配置项以下划线连接，如 SESSION_SECRET 覆盖 session.secret
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
 	// 将配置文件读取到环境
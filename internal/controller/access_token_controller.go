@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"strconv"
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ AccessTokenController = (*accessTokenController)(nil)
+
+type AccessTokenController interface {
+	CreateToken(c *gin.Context)
+	ListTokens(c *gin.Context)
+	RevokeToken(c *gin.Context)
+}
+
+type accessTokenController struct {
+	accessTokenService service.AccessTokenService
+}
+
+func NewAccessTokenController(accessTokenService service.AccessTokenService) AccessTokenController {
+	return &accessTokenController{
+		accessTokenService: accessTokenService,
+	}
+}
+
+func (ctrl *accessTokenController) CreateToken(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.CreateAccessTokenRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	token, err := ctrl.accessTokenService.CreateToken(c.Request.Context(), requestActor(c), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, token)
+}
+
+func (ctrl *accessTokenController) ListTokens(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	tokens, err := ctrl.accessTokenService.ListTokens(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, tokens)
+}
+
+func (ctrl *accessTokenController) RevokeToken(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, response.Validation("invalid token id"))
+		return
+	}
+
+	err = ctrl.accessTokenService.RevokeToken(c.Request.Context(), requestActor(c), id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"w2learn/internal/dto"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ AuditController = (*auditController)(nil)
+
+type AuditController interface {
+	ListAuditLogs(c *gin.Context)
+}
+
+type auditController struct {
+	auditService service.AuditService
+}
+
+func NewAuditController(auditService service.AuditService) AuditController {
+	return &auditController{
+		auditService: auditService,
+	}
+}
+
+func (ctrl *auditController) ListAuditLogs(c *gin.Context) {
+	var req dto.ListAuditLogsRequest
+
+	err := c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	logs, err := ctrl.auditService.ListAuditLogs(c.Request.Context(), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, logs)
+}
@@ -1,8 +1,12 @@
 package controller
 
 import (
+	"errors"
+	"strconv"
 	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
 	"w2learn/internal/service"
+	"w2learn/internal/validation"
 	"w2learn/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +18,9 @@ type AuthController interface {
 	Register(c *gin.Context)
 	Login(c *gin.Context)
 	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
+	Refresh(c *gin.Context)
+	VerifyTwoFactor(c *gin.Context)
 }
 
 type authController struct {
@@ -32,14 +39,14 @@ func (ctrl *authController) Register(c *gin.Context) {
 	err := c.ShouldBindJSON(&req)
 
 	if err != nil {
-		response.Error(c, "Parameter binding failed: "+err.Error())
+		response.Error(c, bindError(c, err))
 		return
 	}
 
-	err = ctrl.authService.Register(c, &req)
+	err = ctrl.authService.Register(c, &req, clientInfo(c))
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -47,20 +54,66 @@ func (ctrl *authController) Register(c *gin.Context) {
 }
 
 func (ctrl *authController) Login(c *gin.Context) {
-	// TODO: 当前登录接口仅支持用户名密码登录，并且会产生大量可登录的token，后续需要在产生新的Token时失效掉旧的token
 	var req dto.LoginRequest
 
 	err := c.ShouldBindJSON(&req)
 
 	if err != nil {
-		response.Error(c, "Parameter binding failed: "+err.Error())
+		response.Error(c, bindError(c, err))
 		return
 	}
 
-	token, err := ctrl.authService.Login(c, &req)
+	token, err := ctrl.authService.Login(c, &req, clientInfo(c))
 
 	if err != nil {
-		response.Error(c, err.Error())
+		var throttled *service.LoginThrottledError
+
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			err = response.Wrap(response.CodeTooManyRequests, throttled.Error(), err)
+		}
+
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, token)
+}
+
+func (ctrl *authController) Refresh(c *gin.Context) {
+	var req dto.RefreshRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	token, err := ctrl.authService.Refresh(c, &req, clientInfo(c))
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, token)
+}
+
+func (ctrl *authController) VerifyTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorVerifyRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	token, err := ctrl.authService.VerifyTwoFactor(c, &req, clientInfo(c))
+
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
 
@@ -68,18 +121,68 @@ func (ctrl *authController) Login(c *gin.Context) {
 }
 
 func (ctrl *authController) Logout(c *gin.Context) {
-	id := c.GetString("id")
+	token, ok := middleware.GetUserToken(c)
 
-	if id == "" {
-		response.Error(c, "Invalid id")
+	if !ok || token.ID == "" {
+		response.Error(c, response.ErrUnauthorized)
+		return
 	}
 
-	err := ctrl.authService.Logout(c, id)
+	// 请求体可选，携带刷新令牌时一并作废其所在的令牌族
+	var req dto.RefreshRequest
+
+	_ = c.ShouldBindJSON(&req)
+
+	err := ctrl.authService.Logout(c, requestActor(c), token, req.RefreshToken)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
 	response.Success(c, "Logout successfully")
 }
+
+func (ctrl *authController) LogoutAll(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	err := ctrl.authService.LogoutAll(c, requestActor(c))
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, "Logout from all devices successfully")
+}
+
+// bindError 将参数绑定或校验错误转换为按字段列出的校验错误，错误信息按 Accept-Language 翻译
+func bindError(c *gin.Context, err error) error {
+	return validation.Translate(err, c.GetHeader("Accept-Language"))
+}
+
+// clientInfo 提取发起请求的客户端信息，用于记录设备会话
+func clientInfo(c *gin.Context) *dto.ClientInfo {
+	return &dto.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// requestActor 提取已认证用户及其客户端信息，用于记录审计日志
+func requestActor(c *gin.Context) *dto.Actor {
+	actor := &dto.Actor{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	if token, ok := middleware.GetUserToken(c); ok {
+		actor.UserID = token.UID
+		actor.Username = token.Username
+	}
+
+	return actor
+}
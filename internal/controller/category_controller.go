@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"strconv"
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ CategoryController = (*categoryController)(nil)
+
+type CategoryController interface {
+	CreateCategory(c *gin.Context)
+	UpdateCategory(c *gin.Context)
+	DeleteCategory(c *gin.Context)
+	ListCategories(c *gin.Context)
+}
+
+type categoryController struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryController(categoryService service.CategoryService) CategoryController {
+	return &categoryController{
+		categoryService: categoryService,
+	}
+}
+
+func (ctrl *categoryController) CreateCategory(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.CreateCategoryRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	category, err := ctrl.categoryService.CreateCategory(c.Request.Context(), uid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, category)
+}
+
+func (ctrl *categoryController) UpdateCategory(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.UpdateCategoryRequest
+
+	err = c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	category, err := ctrl.categoryService.UpdateCategory(c.Request.Context(), uid, id, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, category)
+}
+
+func (ctrl *categoryController) DeleteCategory(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	err = ctrl.categoryService.DeleteCategory(c.Request.Context(), uid, id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *categoryController) ListCategories(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	categories, err := ctrl.categoryService.ListCategories(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, categories)
+}
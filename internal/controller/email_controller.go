@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ EmailController = (*emailController)(nil)
+
+type EmailController interface {
+	VerifyEmail(c *gin.Context)
+	ResendVerification(c *gin.Context)
+}
+
+type emailController struct {
+	emailService service.EmailService
+}
+
+func NewEmailController(emailService service.EmailService) EmailController {
+	return &emailController{
+		emailService: emailService,
+	}
+}
+
+func (ctrl *emailController) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	err = ctrl.emailService.VerifyEmail(c.Request.Context(), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, "Email verified successfully")
+}
+
+func (ctrl *emailController) ResendVerification(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	err := ctrl.emailService.ResendVerification(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, "Verification email sent")
+}
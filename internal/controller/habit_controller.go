@@ -1,9 +1,15 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"io"
 	"strconv"
 	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/model"
 	"w2learn/internal/service"
+	"w2learn/internal/validation"
 	"w2learn/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +23,15 @@ type HabitController interface {
 	UpdateHabit(c *gin.Context)
 	DeleteHabit(c *gin.Context)
 	ListHabits(c *gin.Context)
+	CreateCheckIn(c *gin.Context)
+	ListCheckIns(c *gin.Context)
+	DeleteCheckIn(c *gin.Context)
+	RebuildStreak(c *gin.Context)
+	ListDueHabits(c *gin.Context)
+	PauseHabit(c *gin.Context)
+	ResumeHabit(c *gin.Context)
+	ArchiveHabit(c *gin.Context)
+	UnarchiveHabit(c *gin.Context)
 }
 
 type habitController struct {
@@ -30,19 +45,30 @@ func NewHabitsController(habitsService service.HabitService) HabitController {
 }
 
 func (ctrl *habitController) CreateHabit(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
 	var req dto.CreateHabitRequest
 
 	err := c.ShouldBindJSON(&req)
 
+	if err == nil {
+		err = validation.StructCtx(validation.WithHabitScope(c.Request.Context(), uid, 0), &req)
+	}
+
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, bindError(c, err))
 		return
 	}
 
-	habit, err := ctrl.habitService.CreateHabit(c.Request.Context(), &req)
+	habit, err := ctrl.habitService.CreateHabit(c.Request.Context(), uid, &req)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -50,19 +76,26 @@ func (ctrl *habitController) CreateHabit(c *gin.Context) {
 }
 
 func (ctrl *habitController) GetHabit(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
 	idStr := c.Param("id")
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
-	h, err := ctrl.habitService.GetHabitByID(c.Request.Context(), id)
+	h, err := ctrl.habitService.GetHabitByID(c.Request.Context(), uid, id)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -70,12 +103,19 @@ func (ctrl *habitController) GetHabit(c *gin.Context) {
 }
 
 func (ctrl *habitController) UpdateHabit(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
 	idStr := c.Param("id")
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -83,15 +123,19 @@ func (ctrl *habitController) UpdateHabit(c *gin.Context) {
 
 	err = c.ShouldBindJSON(&req)
 
+	if err == nil {
+		err = validation.StructCtx(validation.WithHabitScope(c.Request.Context(), uid, id), &req)
+	}
+
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, bindError(c, err))
 		return
 	}
 
-	h, err := ctrl.habitService.UpdateHabit(c.Request.Context(), id, &req)
+	h, err := ctrl.habitService.UpdateHabit(c.Request.Context(), uid, id, &req)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -99,19 +143,42 @@ func (ctrl *habitController) UpdateHabit(c *gin.Context) {
 }
 
 func (ctrl *habitController) DeleteHabit(c *gin.Context) {
-	var req dto.DeleteHabitRequest
-
-	err := c.ShouldBindJSON(&req)
+	uid, ok := middleware.GetUserID(c)
 
-	if err != nil {
-		response.Error(c, err.Error())
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
 		return
 	}
 
-	err = ctrl.habitService.DeleteHabit(c.Request.Context(), &req)
+	var hid uint64
+
+	// /me/habits/:id 从路径读取习惯 ID，兼容旧的 DELETE /habit 从请求体读取
+	if idStr := c.Param("id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+
+		hid = id
+	} else {
+		var req dto.DeleteHabitRequest
+
+		err := c.ShouldBindJSON(&req)
+
+		if err != nil {
+			response.Error(c, bindError(c, err))
+			return
+		}
+
+		hid = req.HabitID
+	}
+
+	err := ctrl.habitService.DeleteHabit(c.Request.Context(), uid, hid)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -119,30 +186,251 @@ func (ctrl *habitController) DeleteHabit(c *gin.Context) {
 }
 
 func (ctrl *habitController) ListHabits(c *gin.Context) {
-	pageStr := c.DefaultQuery("page", "1")
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.ListHabitsRequest
+
+	err := c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	habits, err := ctrl.habitService.ListHabits(c.Request.Context(), uid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, habits)
+}
+
+func (ctrl *habitController) CreateCheckIn(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
 
-	page, err := strconv.Atoi(pageStr)
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
-	pageSizeStr := c.DefaultQuery("pageSize", "10")
+	var req dto.CheckInRequest
 
-	pageSize, err := strconv.Atoi(pageSizeStr)
+	err = c.ShouldBindJSON(&req)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, bindError(c, err))
 		return
 	}
 
-	habits, err := ctrl.habitService.ListHabits(c.Request.Context(), page, pageSize)
+	checkIn, err := ctrl.habitService.CheckIn(c.Request.Context(), uid, id, &req)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, checkIn)
+}
+
+func (ctrl *habitController) ListCheckIns(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.ListCheckInsRequest
+
+	err = c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	checkIns, err := ctrl.habitService.ListCheckIns(c.Request.Context(), uid, id, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, checkIns)
+}
+
+func (ctrl *habitController) DeleteCheckIn(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	err = ctrl.habitService.UndoCheckIn(c.Request.Context(), uid, id, c.Param("day"))
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *habitController) RebuildStreak(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	streak, err := ctrl.habitService.RebuildStreak(c.Request.Context(), uid, id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, streak)
+}
+
+func (ctrl *habitController) ListDueHabits(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	habits, err := ctrl.habitService.ListDueHabits(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
 
 	response.Success(c, habits)
 }
+
+func (ctrl *habitController) PauseHabit(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.PauseHabitRequest
+
+	// 请求体可选，未指定恢复日期时需要手动恢复
+	err = c.ShouldBindJSON(&req)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	h, err := ctrl.habitService.PauseHabit(c.Request.Context(), uid, id, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, h)
+}
+
+func (ctrl *habitController) ResumeHabit(c *gin.Context) {
+	ctrl.changeStatus(c, ctrl.habitService.ResumeHabit)
+}
+
+func (ctrl *habitController) ArchiveHabit(c *gin.Context) {
+	ctrl.changeStatus(c, ctrl.habitService.ArchiveHabit)
+}
+
+func (ctrl *habitController) UnarchiveHabit(c *gin.Context) {
+	ctrl.changeStatus(c, ctrl.habitService.UnarchiveHabit)
+}
+
+func (ctrl *habitController) changeStatus(
+	c *gin.Context,
+	change func(ctx context.Context, uid uint64, hid uint64) (*model.Habit, error),
+) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	h, err := change(c.Request.Context(), uid, id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, h)
+}
@@ -32,7 +32,8 @@ func (ctrl *healthController) HealthCheckWithFlag(c *gin.Context) {
 	flagInt, err := strconv.Atoi(flag)
 
 	if err != nil {
-		response.Error(c, "convert flag fail")
+		response.Error(c, response.Validation("invalid health flag"))
+		return
 	}
 
 	health, err := ctrl.healthService.GetHealth(c, flagInt)
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"net/http"
+	"w2learn/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ JWKSController = (*jwksController)(nil)
+
+type JWKSController interface {
+	GetJWKS(c *gin.Context)
+}
+
+type jwksController struct{}
+
+func NewJWKSController() JWKSController {
+	return &jwksController{}
+}
+
+// GetJWKS 按 RFC 7517 的格式直接返回公钥集合，不使用统一响应结构，供其他服务验证令牌
+func (ctrl *jwksController) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.JWKS())
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"strconv"
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ NotificationController = (*notificationController)(nil)
+
+type NotificationController interface {
+	ListNotifications(c *gin.Context)
+	ReadNotification(c *gin.Context)
+	ReadAllNotifications(c *gin.Context)
+}
+
+type notificationController struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationController(notificationService service.NotificationService) NotificationController {
+	return &notificationController{
+		notificationService: notificationService,
+	}
+}
+
+func (ctrl *notificationController) ListNotifications(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.ListNotificationsRequest
+
+	err := c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	notifications, err := ctrl.notificationService.ListNotifications(c.Request.Context(), uid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, notifications)
+}
+
+func (ctrl *notificationController) ReadNotification(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	err = ctrl.notificationService.ReadNotification(c.Request.Context(), uid, id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *notificationController) ReadAllNotifications(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	err := ctrl.notificationService.ReadAllNotifications(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
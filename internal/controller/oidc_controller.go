@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"net/http"
+	"w2learn/internal/dto"
+	"w2learn/internal/service"
+	"w2learn/pkg/def"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ OIDCController = (*oidcController)(nil)
+
+type OIDCController interface {
+	Login(c *gin.Context)
+	Callback(c *gin.Context)
+}
+
+type oidcController struct {
+	oidcService  service.OIDCService
+	secureCookie bool
+}
+
+// NewOIDCController 中 secureCookie 表示 state Cookie 只通过 HTTPS 发送
+func NewOIDCController(oidcService service.OIDCService, secureCookie bool) OIDCController {
+	return &oidcController{
+		oidcService:  oidcService,
+		secureCookie: secureCookie,
+	}
+}
+
+func (ctrl *oidcController) Login(c *gin.Context) {
+	var req dto.OIDCLoginRequest
+
+	err := c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	result, err := ctrl.oidcService.StartLogin(c.Request.Context(), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	// 身份提供方以跨站的顶层导航重定向回来，SameSite=Lax 时 Cookie 仍会随回调请求发送
+	ctrl.setStateCookie(c, result.StateHash, int(result.ExpiresIn))
+
+	response.Success(c, result)
+}
+
+func (ctrl *oidcController) Callback(c *gin.Context) {
+	var req dto.OIDCCallbackRequest
+
+	err := c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	req.StateHash, _ = c.Cookie(def.OIDCStateCookieName)
+
+	// state 只能使用一次，回调后立即清除 Cookie
+	ctrl.setStateCookie(c, "", -1)
+
+	token, err := ctrl.oidcService.Callback(c.Request.Context(), &req, clientInfo(c))
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, token)
+}
+
+func (ctrl *oidcController) setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(def.OIDCStateCookieName, value, maxAge, def.OIDCStateCookiePath, "", ctrl.secureCookie, true)
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ PasswordController = (*passwordController)(nil)
+
+type PasswordController interface {
+	ChangePassword(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+}
+
+type passwordController struct {
+	passwordService service.PasswordService
+}
+
+func NewPasswordController(passwordService service.PasswordService) PasswordController {
+	return &passwordController{
+		passwordService: passwordService,
+	}
+}
+
+func (ctrl *passwordController) ChangePassword(c *gin.Context) {
+	token, ok := middleware.GetUserToken(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	err = ctrl.passwordService.ChangePassword(c.Request.Context(), requestActor(c), token.SessionID, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, "Password changed successfully")
+}
+
+func (ctrl *passwordController) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	err = ctrl.passwordService.ForgotPassword(c.Request.Context(), &req, clientInfo(c))
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, "If the account exists, a password reset email has been sent")
+}
+
+func (ctrl *passwordController) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	err = ctrl.passwordService.ResetPassword(c.Request.Context(), &req, clientInfo(c))
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, "Password reset successfully")
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ ReminderController = (*reminderController)(nil)
+
+type ReminderController interface {
+	CreateReminder(c *gin.Context)
+	UpdateReminder(c *gin.Context)
+	DeleteReminder(c *gin.Context)
+	ListReminders(c *gin.Context)
+	SnoozeReminder(c *gin.Context)
+}
+
+type reminderController struct {
+	reminderService service.ReminderService
+}
+
+func NewReminderController(reminderService service.ReminderService) ReminderController {
+	return &reminderController{
+		reminderService: reminderService,
+	}
+}
+
+func (ctrl *reminderController) CreateReminder(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	hid, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.CreateReminderRequest
+
+	err = c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	reminder, err := ctrl.reminderService.CreateReminder(c.Request.Context(), uid, hid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, reminder)
+}
+
+func (ctrl *reminderController) UpdateReminder(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	hid, rid, err := reminderParams(c)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.UpdateReminderRequest
+
+	err = c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	reminder, err := ctrl.reminderService.UpdateReminder(c.Request.Context(), uid, hid, rid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, reminder)
+}
+
+func (ctrl *reminderController) DeleteReminder(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	hid, rid, err := reminderParams(c)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	err = ctrl.reminderService.DeleteReminder(c.Request.Context(), uid, hid, rid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *reminderController) ListReminders(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	hid, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	reminders, err := ctrl.reminderService.ListReminders(c.Request.Context(), uid, hid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, reminders)
+}
+
+func (ctrl *reminderController) SnoozeReminder(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	hid, rid, err := reminderParams(c)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.SnoozeReminderRequest
+
+	// 请求体可选，未指定时长时使用默认值
+	err = c.ShouldBindJSON(&req)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	reminder, err := ctrl.reminderService.SnoozeReminder(c.Request.Context(), uid, hid, rid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, reminder)
+}
+
+func reminderParams(c *gin.Context) (uint64, uint64, error) {
+	hid, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rid, err := strconv.ParseUint(c.Param("rid"), 10, 64)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return hid, rid, nil
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"strconv"
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ RoleController = (*roleController)(nil)
+
+type RoleController interface {
+	ListRoles(c *gin.Context)
+	CreateRole(c *gin.Context)
+	UpdateRole(c *gin.Context)
+	DeleteRole(c *gin.Context)
+	SetUserRoles(c *gin.Context)
+}
+
+type roleController struct {
+	roleService service.RoleService
+}
+
+func NewRoleController(roleService service.RoleService) RoleController {
+	return &roleController{
+		roleService: roleService,
+	}
+}
+
+func (ctrl *roleController) ListRoles(c *gin.Context) {
+	roles, err := ctrl.roleService.ListRoles(c.Request.Context())
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, roles)
+}
+
+func (ctrl *roleController) CreateRole(c *gin.Context) {
+	var req dto.CreateRoleRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	role, err := ctrl.roleService.CreateRole(c.Request.Context(), requestActor(c), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, role)
+}
+
+func (ctrl *roleController) UpdateRole(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+
+	err = c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	role, err := ctrl.roleService.UpdateRole(c.Request.Context(), requestActor(c), id, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, role)
+}
+
+func (ctrl *roleController) DeleteRole(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	err = ctrl.roleService.DeleteRole(c.Request.Context(), requestActor(c), id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *roleController) SetUserRoles(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, response.Validation("invalid user id"))
+		return
+	}
+
+	var req dto.SetUserRolesRequest
+
+	err = c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	user, err := ctrl.roleService.SetUserRoles(c.Request.Context(), requestActor(c), id, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, user)
+}
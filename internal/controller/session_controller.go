@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ SessionController = (*sessionController)(nil)
+
+type SessionController interface {
+	ListSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+	RevokeOtherSessions(c *gin.Context)
+}
+
+type sessionController struct {
+	sessionService service.SessionService
+}
+
+func NewSessionController(sessionService service.SessionService) SessionController {
+	return &sessionController{
+		sessionService: sessionService,
+	}
+}
+
+func (ctrl *sessionController) ListSessions(c *gin.Context) {
+	token, ok := middleware.GetUserToken(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	sessions, err := ctrl.sessionService.ListSessions(c.Request.Context(), token.UID, token.SessionID)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+func (ctrl *sessionController) RevokeSession(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	err := ctrl.sessionService.RevokeSession(c.Request.Context(), uid, c.Param("id"))
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *sessionController) RevokeOtherSessions(c *gin.Context) {
+	token, ok := middleware.GetUserToken(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	if token.SessionID == "" {
+		response.Error(c, response.NotFound("current token has no session"))
+		return
+	}
+
+	err := ctrl.sessionService.RevokeOtherSessions(c.Request.Context(), token.UID, token.SessionID)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"strconv"
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ StatsController = (*statsController)(nil)
+
+type StatsController interface {
+	GetHabitStats(c *gin.Context)
+	GetUserStats(c *gin.Context)
+}
+
+type statsController struct {
+	statsService service.StatsService
+}
+
+func NewStatsController(statsService service.StatsService) StatsController {
+	return &statsController{
+		statsService: statsService,
+	}
+}
+
+func (ctrl *statsController) GetHabitStats(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req dto.StatsRequest
+
+	err = c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	stats, err := ctrl.statsService.GetHabitStats(c.Request.Context(), uid, id, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+func (ctrl *statsController) GetUserStats(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.StatsRequest
+
+	err := c.ShouldBindQuery(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	stats, err := ctrl.statsService.GetUserStats(c.Request.Context(), uid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, stats)
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/service"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ TwoFactorController = (*twoFactorController)(nil)
+
+type TwoFactorController interface {
+	GetStatus(c *gin.Context)
+	Enroll(c *gin.Context)
+	Confirm(c *gin.Context)
+	Disable(c *gin.Context)
+	RegenerateRecoveryCodes(c *gin.Context)
+}
+
+type twoFactorController struct {
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorController(twoFactorService service.TwoFactorService) TwoFactorController {
+	return &twoFactorController{
+		twoFactorService: twoFactorService,
+	}
+}
+
+func (ctrl *twoFactorController) GetStatus(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	status, err := ctrl.twoFactorService.GetStatus(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (ctrl *twoFactorController) Enroll(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	enrollment, err := ctrl.twoFactorService.Enroll(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+func (ctrl *twoFactorController) Confirm(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.TwoFactorCodeRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	codes, err := ctrl.twoFactorService.Confirm(c.Request.Context(), requestActor(c), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, codes)
+}
+
+func (ctrl *twoFactorController) Disable(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.TwoFactorDisableRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	err = ctrl.twoFactorService.Disable(c.Request.Context(), requestActor(c), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *twoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.TwoFactorCodeRequest
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	codes, err := ctrl.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), requestActor(c), &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, codes)
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"strconv"
 	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
 	"w2learn/internal/service"
 	"w2learn/pkg/response"
 
@@ -16,6 +17,11 @@ type UserController interface {
 	UpdateUser(c *gin.Context)
 	DeleteUser(c *gin.Context)
 	ListUsers(c *gin.Context)
+	GetCurrentUser(c *gin.Context)
+	UpdateCurrentUser(c *gin.Context)
+	SuspendUser(c *gin.Context)
+	ReinstateUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
 }
 
 type userController struct {
@@ -32,14 +38,14 @@ func (ctrl *userController) CreateUser(c *gin.Context) {
 	var req dto.CreateUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, "Parameter binding failed: "+err.Error())
+		response.Error(c, bindError(c, err))
 		return
 	}
 
-	user, err := ctrl.userService.CreateUser(c.Request.Context(), &req)
+	user, err := ctrl.userService.CreateUser(c.Request.Context(), requestActor(c), &req)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -52,14 +58,14 @@ func (ctrl *userController) GetUser(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 64)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
 	user, err := ctrl.userService.GetUserByID(c.Request.Context(), id)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -70,14 +76,14 @@ func (ctrl *userController) GetUserByUsername(c *gin.Context) {
 	username := c.Param("username")
 
 	if username == "" {
-		response.Error(c, "Username can't be empty")
+		response.Error(c, response.Validation("username is empty"))
 		return
 	}
 
 	user, err := ctrl.userService.GetUserByUsername(c.Request.Context(), username)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -89,21 +95,21 @@ func (ctrl *userController) UpdateUser(c *gin.Context) {
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		response.Error(c, "Invalid user ID")
+		response.Error(c, response.Validation("invalid user id"))
 		return
 	}
 
 	var req dto.UpdateUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, "Parameter binding failed: "+err.Error())
+		response.Error(c, bindError(c, err))
 		return
 	}
 
-	user, err := ctrl.userService.UpdateUser(c.Request.Context(), id, &req)
+	user, err := ctrl.userService.UpdateUser(c.Request.Context(), requestActor(c), id, &req)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -116,14 +122,14 @@ func (ctrl *userController) DeleteUser(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 64)
 
 	if err != nil {
-		response.Error(c, "Invalid user ID")
+		response.Error(c, response.Validation("invalid user id"))
 		return
 	}
 
-	err = ctrl.userService.DeleteUser(c.Request.Context(), id)
+	err = ctrl.userService.DeleteUser(c.Request.Context(), requestActor(c), id)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -136,7 +142,7 @@ func (ctrl *userController) ListUsers(c *gin.Context) {
 	page, err := strconv.Atoi(pageStr)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
@@ -145,16 +151,124 @@ func (ctrl *userController) ListUsers(c *gin.Context) {
 	pageSize, err := strconv.Atoi(pageSizeStr)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
 	users, err := ctrl.userService.ListUsers(c, page, pageSize)
 
 	if err != nil {
-		response.Error(c, err.Error())
+		response.Error(c, err)
 		return
 	}
 
 	response.Success(c, users)
 }
+
+func (ctrl *userController) GetCurrentUser(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	user, err := ctrl.userService.GetUserByID(c.Request.Context(), uid)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, user)
+}
+
+func (ctrl *userController) UpdateCurrentUser(c *gin.Context) {
+	uid, ok := middleware.GetUserID(c)
+
+	if !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	var req dto.UpdateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, bindError(c, err))
+		return
+	}
+
+	user, err := ctrl.userService.UpdateUser(c.Request.Context(), requestActor(c), uid, &req)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, user)
+}
+
+func (ctrl *userController) SuspendUser(c *gin.Context) {
+	if _, ok := middleware.GetUserID(c); !ok {
+		response.Error(c, response.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, response.Validation("invalid user id"))
+		return
+	}
+
+	err = ctrl.userService.SuspendUser(c.Request.Context(), requestActor(c), id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *userController) ReinstateUser(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, response.Validation("invalid user id"))
+		return
+	}
+
+	err = ctrl.userService.ReinstateUser(c.Request.Context(), requestActor(c), id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (ctrl *userController) UnlockUser(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+
+	if err != nil {
+		response.Error(c, response.Validation("invalid user id"))
+		return
+	}
+
+	err = ctrl.userService.UnlockUser(c.Request.Context(), requestActor(c), id)
+
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
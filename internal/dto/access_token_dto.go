@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=64"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+// AccessTokenResponse 为个人访问令牌信息，Prefix 为令牌开头，用于在列表中识别令牌
+type AccessTokenResponse struct {
+	ID         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// AccessTokenCreatedResponse 中 Token 为完整令牌，只在创建时返回一次
+type AccessTokenCreatedResponse struct {
+	*AccessTokenResponse
+	Token string `json:"token"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+	"w2learn/internal/model"
+)
+
+// Actor 为发起操作的用户及其客户端信息，UserID 为 0 表示未登录的请求
+type Actor struct {
+	UserID    uint64
+	Username  string
+	IP        string
+	UserAgent string
+}
+
+// ListAuditLogsRequest 中 From 与 To 为 RFC 3339 格式的时间，包含 From 不包含 To
+type ListAuditLogsRequest struct {
+	ActorID  uint64    `form:"actor_id"`
+	TargetID uint64    `form:"target_id"`
+	Action   string    `form:"action" binding:"omitempty,max=32"`
+	Result   string    `form:"result" binding:"omitempty,oneof=success failure"`
+	IP       string    `form:"ip" binding:"omitempty,max=64"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int       `form:"page"`
+	PageSize int       `form:"page_size"`
+}
+
+type AuditLogListResponse struct {
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	Items    []*model.AuditLog `json:"items"`
+}
@@ -5,19 +5,72 @@ import "github.com/golang-jwt/jwt/v5"
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=32"`
 	Password string `json:"password" binding:"required"`
+	Email    string `json:"email" binding:"omitempty,email,max=255"`
+	Timezone string `json:"timezone" binding:"omitempty,timezone"`
 }
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=32"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" binding:"omitempty,max=64"`
+}
+
+// ClientInfo 为发起请求的客户端信息，由控制器从请求中提取
+type ClientInfo struct {
+	IP        string
+	UserAgent string
 }
 
 type LogoutRequest struct {
 	UID uint64 `json:"uid" binding:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse 为登录与刷新返回的令牌对，ExpiresIn 与 RefreshExpiresIn 单位为秒
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// LoginResponse 在用户开启二次验证时只返回 ChallengeToken，需调用 /auth/2fa/verify 换取令牌对
+type LoginResponse struct {
+	*TokenResponse
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	ChallengeToken     string `json:"challenge_token,omitempty"`
+	ChallengeExpiresIn int64  `json:"challenge_expires_in,omitempty"`
+}
+
+// UserToken 中 SessionID 为访问令牌所属的设备会话，Roles 与 Status 为签发时用户的角色与状态
 type UserToken struct {
-	UID      uint64 `json:"uid"`
-	Username string `json:"username"`
+	UID       uint64   `json:"uid"`
+	Username  string   `json:"username"`
+	SessionID string   `json:"sid,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Status    int8     `json:"status,omitempty"`
 	jwt.RegisteredClaims
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=128"`
+}
+
+// ForgotPasswordRequest 中 Account 可以是用户名或邮箱
+type ForgotPasswordRequest struct {
+	Account string `json:"account" binding:"required,max=255"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=128"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+type CreateCategoryRequest struct {
+	Name string `json:"name" binding:"required,max=32"`
+	Info string `json:"info" binding:"max=255"`
+}
+
+type UpdateCategoryRequest struct {
+	Name string `json:"name" binding:"required,max=32"`
+	Info string `json:"info" binding:"max=255"`
+}
@@ -1,17 +1,100 @@
 package dto
 
+import (
+	"time"
+	"w2learn/internal/model"
+)
+
 type CreateHabitRequest struct {
-	UserID uint64 `json:"user_id" binding:"required"`
-	Name   string `json:"name" binding:"required"`
-	Info   string `json:"info" binding:"required"`
+	Name       string                `json:"name" binding:"required,max=64,habit_name_unique"`
+	Info       string                `json:"info" binding:"required"`
+	Schedule   *HabitScheduleRequest `json:"schedule"`
+	Target     *HabitTargetRequest   `json:"target"`
+	TagIDs     []uint64              `json:"tag_ids" binding:"omitempty,max=20,dive,gt=0"`
+	CategoryID *uint64               `json:"category_id"`
 }
 
+// UpdateHabitRequest 中 TagIDs 为 null 表示不修改标签，为空数组表示清空标签；
+// CategoryID 为 null 表示不修改分类，为 0 表示取消分类
 type UpdateHabitRequest struct {
-	Name string `json:"name" binding:"required"`
-	Info string `json:"info" binding:"required"`
+	Name       string                `json:"name" binding:"required,max=64,habit_name_unique"`
+	Info       string                `json:"info" binding:"required"`
+	Schedule   *HabitScheduleRequest `json:"schedule"`
+	Target     *HabitTargetRequest   `json:"target"`
+	TagIDs     []uint64              `json:"tag_ids" binding:"omitempty,max=20,dive,gt=0"`
+	CategoryID *uint64               `json:"category_id"`
+}
+
+// ListHabitsRequest 为习惯列表的查询参数，Status 为空时返回未归档的习惯，为 all 时不限状态
+type ListHabitsRequest struct {
+	Status     string `form:"status"`
+	TagID      uint64 `form:"tag"`
+	CategoryID uint64 `form:"category"`
+	Page       int    `form:"page"`
+	PageSize   int    `form:"pageSize"`
+}
+
+// HabitScheduleRequest 描述习惯的重复规则，Weekdays 取值 0-6 对应周日至周六
+type HabitScheduleRequest struct {
+	Type     string `json:"type" binding:"required,oneof=daily weekdays interval weekly monthly rrule"`
+	Weekdays []int  `json:"weekdays" binding:"required_if=Type weekdays,max=7,dive,min=0,max=6"`
+	Interval int    `json:"interval" binding:"required_if=Type interval,min=0,max=365"`
+	Times    int    `json:"times" binding:"min=0,max=28"`
+	RRule    string `json:"rrule" binding:"required_if=Type rrule,max=255"`
+	StartDay string `json:"start_day" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// HabitTargetRequest 描述可量化习惯的每日目标，Value 为 0 表示取消目标
+type HabitTargetRequest struct {
+	Value float64 `json:"value" binding:"gte=0"`
+	Unit  string  `json:"unit" binding:"max=32"`
+	Mode  string  `json:"mode" binding:"omitempty,oneof=at_least at_most exactly"`
+}
+
+type PauseHabitRequest struct {
+	ResumeDay string `json:"resume_day" binding:"omitempty,datetime=2006-01-02"`
 }
 
 type DeleteHabitRequest struct {
-	UserID  uint64 `json:"user_id" binding:"required"`
 	HabitID uint64 `json:"habit_id" binding:"required"`
 }
+
+// CheckInRequest 记录一次打卡，可量化习惯的 Amount 会累加到当日总量
+type CheckInRequest struct {
+	Day    string  `json:"day" binding:"omitempty,datetime=2006-01-02"`
+	Amount float64 `json:"amount" binding:"gte=0"`
+	Note   string  `json:"note" binding:"max=255"`
+}
+
+type ListCheckInsRequest struct {
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type HabitStreakResponse struct {
+	CurrentStreak int        `json:"current_streak"`
+	LongestStreak int        `json:"longest_streak"`
+	AtRisk        bool       `json:"at_risk"`
+	LastPeriod    *time.Time `json:"last_period"`
+}
+
+type HabitProgressResponse struct {
+	Day       time.Time `json:"day"`
+	Amount    float64   `json:"amount"`
+	Target    float64   `json:"target"`
+	Unit      string    `json:"unit"`
+	Percent   float64   `json:"percent"`
+	Completed bool      `json:"completed"`
+}
+
+type HabitResponse struct {
+	*model.Habit
+	DueToday bool                   `json:"due_today"`
+	Today    *HabitProgressResponse `json:"today"`
+	Streak   *HabitStreakResponse   `json:"streak"`
+}
+
+type CheckInResponse struct {
+	*model.HabitCheckIn
+	Percent float64 `json:"percent"`
+}
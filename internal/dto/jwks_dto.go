@@ -0,0 +1,17 @@
+package dto
+
+// JWK 为 RFC 7517 格式的公钥，RSA 密钥使用 N 与 E，Ed25519 密钥使用 Crv 与 X
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
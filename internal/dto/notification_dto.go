@@ -0,0 +1,14 @@
+package dto
+
+import "w2learn/internal/model"
+
+type ListNotificationsRequest struct {
+	Unread   bool `form:"unread"`
+	Page     int  `form:"page"`
+	PageSize int  `form:"pageSize"`
+}
+
+type NotificationListResponse struct {
+	Unread int64                 `json:"unread"`
+	Items  []*model.Notification `json:"items"`
+}
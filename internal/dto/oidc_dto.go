@@ -0,0 +1,23 @@
+package dto
+
+type OIDCLoginRequest struct {
+	Device string `form:"device" binding:"omitempty,max=64"`
+}
+
+// OIDCLoginResponse 中 AuthorizationURL 为身份提供方的授权地址，客户端需要在 ExpiresIn 秒内完成登录
+// StateHash 由控制器写入 Cookie，不出现在响应体中
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	ExpiresIn        int64  `json:"expires_in"`
+	StateHash        string `json:"-"`
+}
+
+// OIDCCallbackRequest 为身份提供方重定向回来时携带的查询参数，授权失败时只有 Error
+// StateHash 取自发起登录时写入的 Cookie
+type OIDCCallbackRequest struct {
+	Code             string `form:"code"`
+	State            string `form:"state" binding:"required"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+	StateHash        string `form:"-"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+// CreateReminderRequest 中 Times 为用户本地时间 "HH:MM"，Weekdays 取值 0-6 对应周日至周六，
+// 为空表示只在习惯当天需要打卡时提醒；Target 为 webhook 地址，邮件提醒只能发送到用户已验证的邮箱，可以省略，站内信不需要
+type CreateReminderRequest struct {
+	Times         []string `json:"times" binding:"required,min=1,max=10,dive,datetime=15:04"`
+	Weekdays      []int    `json:"weekdays" binding:"max=7,dive,min=0,max=6"`
+	Channel       string   `json:"channel" binding:"omitempty,oneof=inbox email webhook"`
+	Target        string   `json:"target" binding:"max=255"`
+	SnoozeMinutes int      `json:"snooze_minutes" binding:"min=0,max=720"`
+}
+
+type UpdateReminderRequest struct {
+	Times         []string `json:"times" binding:"required,min=1,max=10,dive,datetime=15:04"`
+	Weekdays      []int    `json:"weekdays" binding:"max=7,dive,min=0,max=6"`
+	Channel       string   `json:"channel" binding:"omitempty,oneof=inbox email webhook"`
+	Target        string   `json:"target" binding:"max=255"`
+	SnoozeMinutes int      `json:"snooze_minutes" binding:"min=0,max=720"`
+}
+
+// SnoozeReminderRequest 中 Minutes 为 0 时使用提醒规则的默认稍后提醒时长
+type SnoozeReminderRequest struct {
+	Minutes int `json:"minutes" binding:"min=0,max=720"`
+}
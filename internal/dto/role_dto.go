@@ -0,0 +1,16 @@
+package dto
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=32,alphanum"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"dive,required"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"dive,required"`
+}
+
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required,min=1,dive,required,max=32"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+// StatsRequest 中 Days 为热力图与星期分布覆盖的天数，截至今天
+type StatsRequest struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=366"`
+}
+
+// StatsResponse 为单个习惯或用户全部习惯的统计，Rate 为完成天数与计划要求天数之比，最大为 1
+type StatsResponse struct {
+	From       time.Time                 `json:"from"`
+	To         time.Time                 `json:"to"`
+	HabitCount int                       `json:"habit_count"`
+	Rates      []*CompletionRateResponse `json:"rates"`
+	Heatmap    []*HeatmapCellResponse    `json:"heatmap"`
+	Weekdays   []*WeekdayStatsResponse   `json:"weekdays"`
+	Trend      []*WeekTrendResponse      `json:"trend"`
+}
+
+// CompletionRateResponse 为截至今天最近 Days 天的完成率
+type CompletionRateResponse struct {
+	Days      int     `json:"days"`
+	Completed int64   `json:"completed"`
+	Expected  int     `json:"expected"`
+	Rate      float64 `json:"rate"`
+}
+
+type HeatmapCellResponse struct {
+	Day       time.Time `json:"day"`
+	Completed int64     `json:"completed"`
+	Amount    float64   `json:"amount"`
+}
+
+// WeekdayStatsResponse 中 Weekday 取值 0-6 对应周日至周六，Percent 为该星期占全部完成次数的比例
+type WeekdayStatsResponse struct {
+	Weekday   int     `json:"weekday"`
+	Completed int64   `json:"completed"`
+	Percent   float64 `json:"percent"`
+}
+
+// WeekTrendResponse 为一周（周一开始）的完成情况，Delta 字段为与上一周相比的变化
+type WeekTrendResponse struct {
+	WeekStart      time.Time `json:"week_start"`
+	Completed      int64     `json:"completed"`
+	Expected       int       `json:"expected"`
+	Rate           float64   `json:"rate"`
+	CompletedDelta int64     `json:"completed_delta"`
+	RateDelta      float64   `json:"rate_delta"`
+}
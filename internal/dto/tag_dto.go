@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+	"w2learn/internal/model"
+)
+
+type CreateTagRequest struct {
+	Name  string `json:"name" binding:"required,max=32"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+type UpdateTagRequest struct {
+	Name  string `json:"name" binding:"required,max=32"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+type TagStatsRequest struct {
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// TagStatsResponse 汇总标签下所有习惯在 [From, To] 内的完成情况
+// Expected 为计划要求完成的天数，Rate 为 Completed/Expected，最大为 1
+type TagStatsResponse struct {
+	Tag        *model.Tag `json:"tag"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	HabitCount int        `json:"habit_count"`
+	Completed  int64      `json:"completed"`
+	Expected   int        `json:"expected"`
+	Rate       float64    `json:"rate"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+// TwoFactorVerifyRequest 中 Code 可以是 TOTP 验证码或恢复码
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=32"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorEnrollResponse 中 URI 为 otpauth:// 地址，可生成二维码供认证器扫描
+type TwoFactorEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodesResponse 中的恢复码只在生成时返回一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	Pending                bool  `json:"pending"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
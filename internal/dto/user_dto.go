@@ -3,8 +3,14 @@ package dto
 type CreateUserRequest struct {
 	Username string `json:"username" form:"username" binding:"required,min=3,max=32"`
 	Password string `json:"password" form:"password" binding:"required"`
+	Email    string `json:"email" form:"email" binding:"omitempty,email,max=255"`
+	Timezone string `json:"timezone" form:"timezone" binding:"omitempty,timezone"`
 }
 
 type UpdateUserRequest struct {
-	Username string `json:"username" form:"username" binding:"required,min=3,max=32"`
+	Username        string  `json:"username" form:"username" binding:"required,min=3,max=32"`
+	Email           *string `json:"email" form:"email" binding:"omitempty,max=255"`
+	Timezone        string  `json:"timezone" form:"timezone" binding:"omitempty,timezone"`
+	DayRolloverHour *int    `json:"day_rollover_hour" form:"day_rollover_hour" binding:"omitempty,min=0,max=23"`
+	MaxSessions     *int    `json:"max_sessions" form:"max_sessions" binding:"omitempty,min=0,max=100"`
 }
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var _ Mailer = (*fileMailer)(nil)
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer 将每封邮件保存为 dir 下的 .eml 文件，用于本地开发与测试
+func NewFileMailer(dir string, from string) Mailer {
+	return &fileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *fileMailer) Send(ctx context.Context, mail *Mail) error {
+	if mail == nil {
+		return errors.New("mail is nil")
+	}
+
+	err := os.MkdirAll(m.dir, 0755)
+
+	if err != nil {
+		return err
+	}
+
+	// 文件名中的收件人只保留安全字符，避免路径穿越
+	to := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, mail.To)
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), to)
+
+	return os.WriteFile(filepath.Join(m.dir, name), build(m.from, mail), 0600)
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"w2learn/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var _ Mailer = (*logMailer)(nil)
+
+type logMailer struct{}
+
+// NewLogMailer 只将邮件内容写入日志，不实际发送
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Send(ctx context.Context, mail *Mail) error {
+	if mail == nil {
+		return errors.New("mail is nil")
+	}
+
+	logger.Info("Mail sent to log sink",
+		zap.String("to", mail.To),
+		zap.String("subject", mail.Subject),
+		zap.String("body", mail.Body),
+	)
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package mailer
+
+import (
+	"context"
+	"time"
+)
+
+// Mail 为一封纯文本邮件
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+	SentAt  time.Time
+}
+
+// Mailer 为邮件的发送方式，开发与测试环境可以使用文件或日志实现代替 SMTP
+type Mailer interface {
+	Send(ctx context.Context, mail *Mail) error
+}
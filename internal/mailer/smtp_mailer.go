@@ -0,0 +1,145 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+var _ Mailer = (*smtpMailer)(nil)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+type smtpMailer struct {
+	config *SMTPConfig
+}
+
+// NewSMTPMailer 创建 SMTP 发信器，服务器支持 STARTTLS 时自动启用，未配置用户名时不进行认证
+func NewSMTPMailer(config *SMTPConfig) Mailer {
+	return &smtpMailer{
+		config: config,
+	}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail *Mail) error {
+	if mail == nil {
+		return errors.New("mail is nil")
+	}
+
+	if mail.To == "" {
+		return errors.New("email recipient is empty")
+	}
+
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := net.Dialer{Timeout: m.config.Timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else if m.config.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(m.config.Timeout))
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: m.config.Host})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.config.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(m.config.From)
+
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(mail.To)
+
+	if err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(build(m.config.From, mail))
+
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	err = w.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// build 生成 RFC 5322 格式的邮件内容
+func build(from string, mail *Mail) []byte {
+	var buf bytes.Buffer
+
+	sentAt := mail.SentAt
+
+	if sentAt.IsZero() {
+		sentAt = time.Now()
+	}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", sentAt.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(mail.Body)
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
@@ -1,21 +1,76 @@
 package middleware
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
+	"w2learn/internal/dto"
 	"w2learn/internal/utils"
+	"w2learn/pkg/def"
 	"w2learn/pkg/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-func JWTAuthMiddleware(rdb *redis.Client) gin.HandlerFunc {
+// 认证通过后写入 gin.Context 的键
+const (
+	ContextKeyTokenID   = "id"
+	ContextKeyUserID    = "uid"
+	ContextKeyUserToken = "user_token"
+	ContextKeyScopes    = "scopes"
+)
+
+// legacyWatermarkLimit 以毫秒计为 2001 年，小于该值的失效水位是旧版本以秒保存的
+const legacyWatermarkLimit = 1_000_000_000_000
+
+type authOptions struct {
+	allowPending   bool
+	accessTokens   AccessTokenAuthenticator
+	sessionSeenTTL time.Duration
+}
+
+// AuthOption 调整 JWTAuthMiddleware 的校验行为
+type AuthOption func(*authOptions)
+
+// AllowPending 允许邮箱尚未验证的待验证用户访问
+func AllowPending() AuthOption {
+	return func(o *authOptions) {
+		o.allowPending = true
+	}
+}
+
+// AcceptAccessTokens 允许使用个人访问令牌认证，组内每个路由都需要用 RequireScope 声明权限范围
+func AcceptAccessTokens(authenticator AccessTokenAuthenticator) AuthOption {
+	return func(o *authOptions) {
+		o.accessTokens = authenticator
+	}
+}
+
+// SessionSeenTTL 设置会话最近访问时间的保存时长，应与刷新令牌的有效期一致
+func SessionSeenTTL(ttl time.Duration) AuthOption {
+	return func(o *authOptions) {
+		o.sessionSeenTTL = ttl
+	}
+}
+
+func JWTAuthMiddleware(rdb *redis.Client, opts ...AuthOption) gin.HandlerFunc {
+	options := &authOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.sessionSeenTTL <= 0 {
+		options.sessionSeenTTL = def.RefreshTokenDefaultTTL * time.Second
+	}
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
 		// 检查 Authorization 头
 		if authHeader == "" {
-			response.Error(c, "Authorization header is empty")
+			response.Error(c, response.Unauthorized("Authorization header is empty"))
 			c.Abort()
 			return
 		}
@@ -23,22 +78,57 @@ func JWTAuthMiddleware(rdb *redis.Client) gin.HandlerFunc {
 		// 获取 token
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
+		// 个人访问令牌只能访问声明了权限范围的接口
+		if strings.HasPrefix(tokenStr, def.PersonalAccessTokenPrefix) {
+			if options.accessTokens == nil {
+				response.Error(c, response.Forbidden("access tokens are not accepted for this endpoint"))
+				c.Abort()
+				return
+			}
+
+			token, scopes, err := options.accessTokens.AuthenticateAccessToken(c.Request.Context(), tokenStr)
+
+			if err != nil {
+				response.Error(c, err)
+				c.Abort()
+				return
+			}
+
+			if !checkUserStatus(c, token.Status, options.allowPending) {
+				return
+			}
+
+			c.Set(ContextKeyUserID, token.UID)
+			c.Set(ContextKeyUserToken, token)
+			c.Set(ContextKeyScopes, scopes)
+
+			c.Next()
+			return
+		}
+
 		jwt, err := utils.ParseJWT(tokenStr)
 
 		if err != nil {
-			response.Error(c, "Invalid token")
+			response.Error(c, response.Unauthorized("Invalid token"))
 			c.Abort()
 			return
 		}
 		// 检查过期时间
 		if utils.IsTokenExpired(jwt) {
-			response.Error(c, "Token expired")
+			response.Error(c, response.Unauthorized("Token expired"))
 			c.Abort()
 			return
 		}
 
-		// 检查 token 是否在 Redis 黑名单 中
-		n, err := rdb.Exists(c.Request.Context(), jwt.ID).Result()
+		if !checkUserStatus(c, jwt.Status, options.allowPending) {
+			return
+		}
+
+		// 检查 token 是否在 Redis 黑名单中，以及是否早于用户的令牌失效水位签发
+		values, err := rdb.MGet(c.Request.Context(),
+			fmt.Sprintf(def.TokenBlacklistKeyLayout, jwt.ID),
+			fmt.Sprintf(def.TokenRevokedBeforeKeyLayout, jwt.UID),
+		).Result()
 
 		if err != nil {
 			response.Error(c, err)
@@ -46,14 +136,102 @@ func JWTAuthMiddleware(rdb *redis.Client) gin.HandlerFunc {
 			return
 		}
 
-		if n > 0 {
-			response.Error(c, "token is on the blacklist")
+		if values[0] != nil {
+			response.Error(c, response.Unauthorized("token is on the blacklist"))
+			c.Abort()
+			return
+		}
+
+		if issuedBefore(jwt, values[1]) {
+			response.Error(c, response.Unauthorized("token revoked"))
 			c.Abort()
 			return
 		}
 
-		c.Set("id", jwt.ID)
+		// 检查 token 所属会话是否已被撤销，未携带会话的旧 token 不做检查
+		if jwt.SessionID != "" {
+			n, err := rdb.Exists(c.Request.Context(), fmt.Sprintf(def.RefreshFamilyKeyLayout, jwt.SessionID)).Result()
+
+			if err != nil {
+				response.Error(c, err)
+				c.Abort()
+				return
+			}
+
+			if n == 0 {
+				response.Error(c, response.Unauthorized("session revoked"))
+				c.Abort()
+				return
+			}
+
+			// 记录会话最近访问时间，失败不影响本次请求
+			_ = rdb.Set(c.Request.Context(), fmt.Sprintf(def.SessionSeenKeyLayout, jwt.SessionID), time.Now().Unix(), options.sessionSeenTTL).Err()
+		}
+
+		c.Set(ContextKeyTokenID, jwt.ID)
+		c.Set(ContextKeyUserID, jwt.UID)
+		c.Set(ContextKeyUserToken, jwt)
 
 		c.Next()
 	}
 }
+
+// issuedBefore 判断 token 是否早于失效水位签发，watermark 为 Redis 中保存的 Unix 毫秒时间，不存在时为 nil
+// 旧版本以秒保存的水位按秒处理，旧 token 的签发时间只精确到秒，与水位同一秒签发时视为失效
+func issuedBefore(token *dto.UserToken, watermark any) bool {
+	value, ok := watermark.(string)
+
+	if !ok {
+		return false
+	}
+
+	revokedBefore, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		return false
+	}
+
+	if revokedBefore < legacyWatermarkLimit {
+		revokedBefore *= 1000
+	}
+
+	return token.IssuedAt == nil || token.IssuedAt.UnixMilli() < revokedBefore
+}
+
+// checkUserStatus 检查账户状态，待验证用户只能访问允许的接口，不通过时中止请求并返回 false
+func checkUserStatus(c *gin.Context, status int8, allowPending bool) bool {
+	switch status {
+	case def.UserStatusSuspended, def.UserStatusDeleted:
+		response.Error(c, response.Forbidden("account disabled"))
+		c.Abort()
+		return false
+	case def.UserStatusPending:
+		if !allowPending {
+			response.Error(c, response.Forbidden("email verification required"))
+			c.Abort()
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetUserToken 返回当前请求已认证用户的 token 信息
+func GetUserToken(c *gin.Context) (*dto.UserToken, bool) {
+	value, ok := c.Get(ContextKeyUserToken)
+
+	if !ok {
+		return nil, false
+	}
+
+	token, ok := value.(*dto.UserToken)
+
+	return token, ok && token != nil
+}
+
+// GetUserID 返回当前请求已认证用户的 ID
+func GetUserID(c *gin.Context) (uint64, bool) {
+	uid := c.GetUint64(ContextKeyUserID)
+
+	return uid, uid != 0
+}
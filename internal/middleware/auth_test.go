@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"strconv"
+	"testing"
+	"time"
+	"w2learn/internal/dto"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestIssuedBefore(t *testing.T) {
+	revokedAt := time.Date(2026, 10, 18, 9, 30, 15, 500*int(time.Millisecond), time.UTC)
+	watermark := strconv.FormatInt(revokedAt.UnixMilli(), 10)
+
+	tests := []struct {
+		name      string
+		issuedAt  *jwt.NumericDate
+		watermark any
+		want      bool
+	}{
+		{"no watermark", jwt.NewNumericDate(revokedAt.Add(-time.Hour)), nil, false},
+		{"malformed watermark", jwt.NewNumericDate(revokedAt.Add(-time.Hour)), "abc", false},
+		{"missing iat", nil, watermark, true},
+		{"issued long before", jwt.NewNumericDate(revokedAt.Add(-time.Hour)), watermark, true},
+		{"same second before watermark", &jwt.NumericDate{Time: revokedAt.Add(-200 * time.Millisecond)}, watermark, true},
+		{"same second after watermark", &jwt.NumericDate{Time: revokedAt.Add(200 * time.Millisecond)}, watermark, false},
+		{"same millisecond", &jwt.NumericDate{Time: revokedAt}, watermark, false},
+		{"issued after", jwt.NewNumericDate(revokedAt.Add(time.Minute)), watermark, false},
+		{"legacy seconds watermark", &jwt.NumericDate{Time: revokedAt.Add(-time.Second)}, strconv.FormatInt(revokedAt.Unix(), 10), true},
+		{"legacy seconds watermark same second", &jwt.NumericDate{Time: revokedAt}, strconv.FormatInt(revokedAt.Unix(), 10), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &dto.UserToken{
+				RegisteredClaims: jwt.RegisteredClaims{IssuedAt: tt.issuedAt},
+			}
+
+			if got := issuedBefore(token, tt.watermark); got != tt.want {
+				t.Fatalf("issuedBefore = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ func Logger() gin.HandlerFunc {
 			zap.String("query", query),
 			zap.Int("status", c.Writer.Status()),
 			zap.String("ip", c.ClientIP()),
+			zap.String("remote_ip", c.RemoteIP()),
 			zap.Duration("latency", time.Since(start)),
 			zap.String("user_agent", c.Request.UserAgent()),
 			zap.String("error", c.Errors.ByType(gin.ErrorTypePrivate).String()),
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionChecker 根据角色名判断是否拥有指定权限
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, roles []string, permission string) (bool, error)
+}
+
+// RequirePermission 要求当前用户的角色拥有 permission，需在 JWTAuthMiddleware 之后使用
+func RequirePermission(checker PermissionChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := GetUserToken(c)
+
+		if !ok {
+			response.Error(c, response.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		allowed, err := checker.HasPermission(c.Request.Context(), token.Roles, permission)
+
+		if err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			response.Error(c, response.ErrForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
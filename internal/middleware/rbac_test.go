@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roleChecker 按角色名查找角色并判断权限，与角色服务的判断方式一致
+type roleChecker map[string]*model.Role
+
+func (c roleChecker) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	for _, name := range roles {
+		if name == "broken" {
+			return false, errors.New("role lookup failed")
+		}
+
+		if role, ok := c[name]; ok && role.HasPermission(permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	checker := roleChecker{
+		def.RoleUser:  {Name: def.RoleUser, Permissions: model.StringList{}},
+		def.RoleAdmin: {Name: def.RoleAdmin, Permissions: model.StringList{def.PermissionAll}},
+		"auditor":     {Name: "auditor", Permissions: model.StringList{def.PermissionAuditRead, def.PermissionUserRead}},
+	}
+
+	tests := []struct {
+		name   string
+		roles  []string
+		path   string
+		status int
+	}{
+		{"anonymous", nil, "/user", http.StatusUnauthorized},
+		{"user reads users", []string{def.RoleUser}, "/user", http.StatusForbidden},
+		{"admin reads users", []string{def.RoleAdmin}, "/user", http.StatusOK},
+		{"admin writes roles", []string{def.RoleAdmin}, "/role", http.StatusOK},
+		{"auditor reads audit", []string{"auditor"}, "/audit", http.StatusOK},
+		{"auditor reads users", []string{"auditor"}, "/user", http.StatusOK},
+		{"auditor writes roles", []string{"auditor"}, "/role", http.StatusForbidden},
+		{"combined roles", []string{def.RoleUser, "auditor"}, "/audit", http.StatusOK},
+		{"unknown role", []string{"ghost"}, "/audit", http.StatusForbidden},
+		{"lookup error", []string{"broken"}, "/audit", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.roles != nil {
+					c.Set(ContextKeyUserToken, &dto.UserToken{UID: 7, Roles: tt.roles})
+				}
+			})
+
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+			r.GET("/user", RequirePermission(checker, def.PermissionUserRead), ok)
+			r.GET("/role", RequirePermission(checker, def.PermissionRoleWrite), ok)
+			r.GET("/audit", RequirePermission(checker, def.PermissionAuditRead), ok)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("GET %s with roles %v: status %d, want %d", tt.path, tt.roles, w.Code, tt.status)
+			}
+		})
+	}
+}
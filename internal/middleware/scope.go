@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"w2learn/internal/dto"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenAuthenticator 校验个人访问令牌，返回令牌所属用户的身份与令牌的权限范围
+type AccessTokenAuthenticator interface {
+	AuthenticateAccessToken(ctx context.Context, token string) (*dto.UserToken, []string, error)
+}
+
+// RequireScope 声明接口需要的权限范围，个人访问令牌必须拥有全部 scopes，使用 JWT 登录的请求不受限制
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, ok := GetScopes(c)
+
+		if !ok {
+			c.Next()
+			return
+		}
+
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				response.Error(c, response.Forbidden("insufficient scope: "+scope))
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// GetScopes 返回个人访问令牌的权限范围，使用 JWT 认证的请求返回 false
+func GetScopes(c *gin.Context) ([]string, bool) {
+	value, ok := c.Get(ContextKeyScopes)
+
+	if !ok {
+		return nil, false
+	}
+
+	scopes, ok := value.([]string)
+
+	return scopes, ok
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAuditLogImmutable = errors.New("audit logs are append-only")
+
+// AuditLog 为只追加的安全审计记录，ActorID 为空表示未登录的请求，TargetID 为被操作的用户
+// Action 与 Result 取值见 def.AuditAction* 与 def.AuditResult*，Reason 为失败原因或补充说明
+type AuditLog struct {
+	ID        uint64    `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	ActorID   *uint64   `gorm:"index" json:"actor_id"`
+	ActorName string    `gorm:"size:64" json:"actor_name"`
+	TargetID  *uint64   `gorm:"index" json:"target_id"`
+	Action    string    `gorm:"size:32;not null;index" json:"action"`
+	Result    string    `gorm:"size:16;not null" json:"result"`
+	Reason    string    `gorm:"size:255" json:"reason"`
+	IP        string    `gorm:"size:64;index" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (l *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	l.CreatedAt = time.Now().UTC()
+	return nil
+}
+
+func (l *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (l *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
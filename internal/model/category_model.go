@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Category 为习惯所属的领域，每个习惯至多属于一个分类，同一用户下名称唯一
+type Category struct {
+	ID        uint64    `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `gorm:"size:32;not null;uniqueIndex:idx_categories_user_name" json:"name"`
+	Info      string    `gorm:"size:255" json:"info,omitempty"`
+	UserID    uint64    `gorm:"not null;uniqueIndex:idx_categories_user_name" json:"-"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
+
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (c *Category) BeforeUpdate(tx *gorm.DB) error {
+	c.UpdatedAt = time.Now().UTC()
+	return nil
+}
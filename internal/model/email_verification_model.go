@@ -0,0 +1,9 @@
+package model
+
+// EmailVerification 为保存在 Redis 中的邮箱验证令牌信息
+// 验证时要求 Email 仍为用户当前的邮箱，修改邮箱后旧令牌自然失效
+type EmailVerification struct {
+	Hash   string `json:"-"`
+	UserID uint64 `json:"user_id"`
+	Email  string `json:"email"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type HabitCheckIn struct {
+	ID        uint64    `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	HabitID   uint64    `gorm:"not null;uniqueIndex:idx_habit_check_ins_habit_day" json:"habit_id"`
+	Day       time.Time `gorm:"type:date;not null;uniqueIndex:idx_habit_check_ins_habit_day" json:"day"`
+	Amount    float64   `gorm:"not null;default:0" json:"amount"`
+	Completed *bool     `gorm:"not null;default:true" json:"completed"`
+	Note      string    `gorm:"size:255" json:"note"`
+}
+
+func (HabitCheckIn) TableName() string {
+	return "habit_check_ins"
+}
+
+// IsCompleted 返回当日累计量是否已达到目标，历史数据缺省视为已完成
+func (c *HabitCheckIn) IsCompleted() bool {
+	return c.Completed == nil || *c.Completed
+}
+
+func (c *HabitCheckIn) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (c *HabitCheckIn) BeforeUpdate(tx *gorm.DB) error {
+	c.UpdatedAt = time.Now().UTC()
+	return nil
+}
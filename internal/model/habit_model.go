@@ -1,18 +1,79 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Habit 的 Status 为 active/paused/archived，PausedUntil 为暂停时指定的恢复日期
+// 每个习惯至多属于一个 Category，可以关联多个 Tag
 type Habit struct {
-	ID        uint64    `gorm:"primary_key" json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Name      string    `gorm:"size:64;not null" json:"name"`
-	Info      string    `gorm:"size:255;not null" json:"info"`
-	UserID    uint64    `json:"-"`
+	ID          uint64        `gorm:"primary_key" json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Name        string        `gorm:"size:64;not null;uniqueIndex:idx_habits_user_name,where:archived_at IS NULL" json:"name"`
+	Info        string        `gorm:"size:255;not null" json:"info"`
+	Schedule    HabitSchedule `gorm:"embedded;embeddedPrefix:schedule_" json:"schedule"`
+	Target      HabitTarget   `gorm:"embedded;embeddedPrefix:target_" json:"target"`
+	Status      string        `gorm:"size:16;not null;default:active;index" json:"status"`
+	PausedUntil *time.Time    `gorm:"type:date" json:"paused_until"`
+	ArchivedAt  *time.Time    `json:"archived_at"`
+	CategoryID  *uint64       `gorm:"index" json:"category_id"`
+	UserID      uint64        `gorm:"uniqueIndex:idx_habits_user_name,where:archived_at IS NULL" json:"-"`
+
+	Category *Category      `gorm:"constraint:OnDelete:SET NULL" json:"category,omitempty"`
+	Tags     []Tag          `gorm:"many2many:habit_tags;constraint:OnDelete:CASCADE" json:"tags"`
+	CheckIns []HabitCheckIn `gorm:"foreignKey:HabitID;constraint:OnDelete:CASCADE" json:"-"`
+	Streak   *HabitStreak   `gorm:"foreignKey:HabitID;constraint:OnDelete:CASCADE" json:"-"`
+	Pauses   []HabitPause   `gorm:"foreignKey:HabitID;constraint:OnDelete:CASCADE" json:"pauses,omitempty"`
+}
+
+// HabitSchedule 描述习惯的重复规则，各字段的含义取决于 Type
+type HabitSchedule struct {
+	Type     string     `gorm:"size:16;not null;default:daily" json:"type"`
+	Weekdays Weekdays   `gorm:"not null;default:0" json:"weekdays,omitempty"`
+	Interval int        `gorm:"not null;default:0" json:"interval,omitempty"`
+	Times    int        `gorm:"not null;default:0" json:"times,omitempty"`
+	RRule    string     `gorm:"size:255" json:"rrule,omitempty"`
+	StartDay *time.Time `gorm:"type:date" json:"start_day,omitempty"`
+}
+
+// HabitTarget 描述可量化习惯每日的目标值，Value 为 0 表示只需打卡即可完成
+type HabitTarget struct {
+	Value float64 `gorm:"not null;default:0" json:"value"`
+	Unit  string  `gorm:"size:32" json:"unit,omitempty"`
+	Mode  string  `gorm:"size:16;not null;default:at_least" json:"mode"`
+}
+
+// Weekdays 以位掩码保存星期集合，第 n 位对应 time.Weekday(n)
+type Weekdays uint8
+
+func NewWeekdays(days ...time.Weekday) Weekdays {
+	var w Weekdays
+	for _, d := range days {
+		w |= 1 << uint(d)
+	}
+	return w
+}
+
+func (w Weekdays) Has(d time.Weekday) bool {
+	return w&(1<<uint(d)) != 0
+}
+
+func (w Weekdays) List() []time.Weekday {
+	days := make([]time.Weekday, 0, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if w.Has(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func (w Weekdays) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.List())
 }
 
 func (Habit) TableName() string {
@@ -20,12 +81,12 @@ func (Habit) TableName() string {
 }
 
 func (h *Habit) BeforeCreate(tx *gorm.DB) error {
-	h.CreatedAt = time.Now()
-	h.UpdatedAt = time.Now()
+	h.CreatedAt = time.Now().UTC()
+	h.UpdatedAt = time.Now().UTC()
 	return nil
 }
 
 func (h *Habit) BeforeUpdate(tx *gorm.DB) error {
-	h.UpdatedAt = time.Now()
+	h.UpdatedAt = time.Now().UTC()
 	return nil
 }
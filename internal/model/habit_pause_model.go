@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HabitPause 记录习惯的一段暂停期 [StartDay, EndDay]，EndDay 为空表示尚未恢复
+type HabitPause struct {
+	ID        uint64     `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	HabitID   uint64     `gorm:"not null;index" json:"-"`
+	StartDay  time.Time  `gorm:"type:date;not null" json:"start_day"`
+	EndDay    *time.Time `gorm:"type:date" json:"end_day"`
+}
+
+func (HabitPause) TableName() string {
+	return "habit_pauses"
+}
+
+// Overlaps 判断暂停期是否与 [start, end] 有交集
+func (p *HabitPause) Overlaps(start time.Time, end time.Time) bool {
+	if end.Before(p.StartDay) {
+		return false
+	}
+
+	return p.EndDay == nil || !start.After(*p.EndDay)
+}
+
+func (p *HabitPause) BeforeCreate(tx *gorm.DB) error {
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (p *HabitPause) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"w2learn/pkg/def"
+
+	"gorm.io/gorm"
+)
+
+// HabitReminder 为习惯的提醒规则，Times 为用户本地时间的提醒时刻
+// Weekdays 为 0 时只在习惯当天需要打卡时提醒，否则在指定的星期提醒
+// SnoozedUntil 为稍后提醒的时刻，触发后清空
+type HabitReminder struct {
+	ID            uint64        `gorm:"primary_key" json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	HabitID       uint64        `gorm:"not null;index" json:"habit_id"`
+	UserID        uint64        `gorm:"not null;index" json:"-"`
+	Times         ReminderTimes `gorm:"type:varchar(255);not null" json:"times"`
+	Weekdays      Weekdays      `gorm:"not null;default:0" json:"weekdays"`
+	Channel       string        `gorm:"size:16;not null;default:inbox" json:"channel"`
+	Target        string        `gorm:"size:255" json:"target,omitempty"`
+	SnoozeMinutes int           `gorm:"not null;default:0" json:"snooze_minutes"`
+	SnoozedUntil  *time.Time    `json:"snoozed_until"`
+	LastFiredAt   *time.Time    `json:"last_fired_at"`
+
+	Habit *Habit `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	User  *User  `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (HabitReminder) TableName() string {
+	return "habit_reminders"
+}
+
+func (r *HabitReminder) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now().UTC()
+	r.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *HabitReminder) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// ReminderTimes 保存一天中的提醒时刻，以自零点起的分钟数表示，数据库中存为 "08:00,20:30"
+type ReminderTimes []int
+
+// ParseReminderTime 解析 "HH:MM" 格式的时刻
+func ParseReminderTime(s string) (int, error) {
+	t, err := time.Parse(def.ReminderTimeLayout, s)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid reminder time: %s", s)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (t ReminderTimes) Has(minute int) bool {
+	for _, m := range t {
+		if m == minute {
+			return true
+		}
+	}
+	return false
+}
+
+func (t ReminderTimes) Strings() []string {
+	list := make([]string, 0, len(t))
+	for _, m := range t {
+		list = append(list, fmt.Sprintf("%02d:%02d", m/60, m%60))
+	}
+	return list
+}
+
+func (t ReminderTimes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Strings())
+}
+
+func (t ReminderTimes) Value() (driver.Value, error) {
+	return strings.Join(t.Strings(), ","), nil
+}
+
+func (t *ReminderTimes) Scan(src any) error {
+	var s string
+
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+		*t = nil
+		return nil
+	default:
+		return errors.New("invalid reminder times")
+	}
+
+	times := make(ReminderTimes, 0)
+
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			continue
+		}
+
+		m, err := ParseReminderTime(part)
+
+		if err != nil {
+			return err
+		}
+
+		times = append(times, m)
+	}
+
+	*t = times
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HabitStreak 缓存每个习惯的连续打卡状态，随打卡增删增量维护
+// 连续记录以计划周期为单位：LastPeriod 是最近一个已完成周期的起始日期，
+// PeriodStart/PeriodProgress 记录最近一个有打卡的周期及其已完成天数
+type HabitStreak struct {
+	HabitID        uint64     `gorm:"primary_key;autoIncrement:false" json:"habit_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	CurrentStreak  int        `gorm:"not null;default:0" json:"current_streak"`
+	LongestStreak  int        `gorm:"not null;default:0" json:"longest_streak"`
+	LastPeriod     *time.Time `gorm:"type:date" json:"last_period"`
+	PeriodStart    *time.Time `gorm:"type:date" json:"period_start"`
+	PeriodProgress int        `gorm:"not null;default:0" json:"period_progress"`
+}
+
+func (HabitStreak) TableName() string {
+	return "habit_streaks"
+}
+
+func (s *HabitStreak) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now().UTC()
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (s *HabitStreak) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification 为站内信，ReadAt 为空表示未读
+type Notification struct {
+	ID         uint64     `gorm:"primary_key" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     uint64     `gorm:"not null;index" json:"-"`
+	HabitID    uint64     `gorm:"not null;default:0" json:"habit_id,omitempty"`
+	ReminderID uint64     `gorm:"not null;default:0" json:"reminder_id,omitempty"`
+	Title      string     `gorm:"size:128;not null" json:"title"`
+	Body       string     `gorm:"size:1024" json:"body"`
+	ReadAt     *time.Time `json:"read_at"`
+
+	User *User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	n.CreatedAt = time.Now().UTC()
+	return nil
+}
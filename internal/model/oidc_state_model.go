@@ -0,0 +1,9 @@
+package model
+
+// OIDCState 为保存在 Redis 中的授权请求，回调时用于校验 nonce 并完成 PKCE 换取令牌
+type OIDCState struct {
+	Hash         string `json:"-"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	Device       string `json:"device"`
+}
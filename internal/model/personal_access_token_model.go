@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PersonalAccessToken 为用户创建的长期令牌，只保存令牌的 SHA-256 摘要，Prefix 为令牌开头用于识别
+// Scopes 取值见 def.Scope*，RevokedAt 非空或 ExpiresAt 已过表示令牌失效
+type PersonalAccessToken struct {
+	ID         uint64     `gorm:"primary_key" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UserID     uint64     `gorm:"not null;index" json:"-"`
+	Name       string     `gorm:"size:64;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	Hash       string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Scopes     StringList `gorm:"type:varchar(255);not null" json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	User *User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+func (t *PersonalAccessToken) BeforeCreate(tx *gorm.DB) error {
+	t.CreatedAt = time.Now().UTC()
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (t *PersonalAccessToken) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
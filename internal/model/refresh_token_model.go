@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// RefreshToken 为保存在 Redis 中的刷新令牌，只保存令牌的摘要
+// 同一次登录轮换出的令牌属于同一个令牌族 FamilyID，检测到重放时整族作废
+type RefreshToken struct {
+	Hash      string    `json:"-"`
+	UserID    uint64    `json:"user_id"`
+	FamilyID  string    `json:"family_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+	"w2learn/pkg/def"
+
+	"gorm.io/gorm"
+)
+
+// Role 定义角色拥有的权限，用户通过 User.Roles 中的角色名关联
+// Builtin 为 true 的内置角色由系统在启动时创建
+type Role struct {
+	ID          uint64     `gorm:"primary_key" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Name        string     `gorm:"size:32;not null;uniqueIndex" json:"name"`
+	Description string     `gorm:"size:255" json:"description"`
+	Permissions StringList `gorm:"type:varchar(1024);not null" json:"permissions"`
+	Builtin     bool       `gorm:"not null;default:false" json:"builtin"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now().UTC()
+	r.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *Role) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func (r *Role) HasPermission(permission string) bool {
+	return r.Permissions.Has(permission) || r.Permissions.Has(def.PermissionAll)
+}
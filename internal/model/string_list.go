@@ -0,0 +1,47 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// StringList 在数据库中以逗号分隔的字符串保存，如 "user,admin"
+type StringList []string
+
+func (l StringList) Has(s string) bool {
+	return slices.Contains(l, s)
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+func (l *StringList) Scan(src any) error {
+	var s string
+
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+		*l = nil
+		return nil
+	default:
+		return errors.New("invalid string list")
+	}
+
+	list := make(StringList, 0)
+
+	for _, part := range strings.Split(s, ",") {
+		if part != "" {
+			list = append(list, part)
+		}
+	}
+
+	*l = list
+
+	return nil
+}
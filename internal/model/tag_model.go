@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tag 为用户自定义的习惯标签，与习惯为多对多关系，同一用户下名称唯一
+type Tag struct {
+	ID        uint64    `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `gorm:"size:32;not null;uniqueIndex:idx_tags_user_name" json:"name"`
+	Color     string    `gorm:"size:16" json:"color,omitempty"`
+	UserID    uint64    `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"-"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	t.CreatedAt = time.Now().UTC()
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (t *Tag) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// TwoFactorChallenge 为保存在 Redis 中的登录二次验证挑战
+// 口令校验通过后生成，携带完成登录所需的客户端信息
+type TwoFactorChallenge struct {
+	Hash      string    `json:"-"`
+	UserID    uint64    `json:"user_id"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentity 将外部身份提供方的账户关联到用户，Issuer 与 Subject 唯一确定一个外部账户
+type UserIdentity struct {
+	ID        uint64    `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint64    `gorm:"not null;index" json:"-"`
+	Issuer    string    `gorm:"size:255;not null;uniqueIndex:idx_user_identity_issuer_subject" json:"issuer"`
+	Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_user_identity_issuer_subject" json:"subject"`
+	Email     *string   `gorm:"size:255" json:"email"`
+
+	User *User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	i.CreatedAt = time.Now().UTC()
+	i.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (i *UserIdentity) BeforeUpdate(tx *gorm.DB) error {
+	i.UpdatedAt = time.Now().UTC()
+	return nil
+}
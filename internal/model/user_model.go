@@ -2,20 +2,31 @@ package model
 
 import (
 	"time"
+	"w2learn/pkg/def"
 
 	"gorm.io/gorm"
 )
 
+// User 的 Timezone 为 IANA 时区名，DayRolloverHour 表示用户本地新一天开始的小时
+// MaxSessions 为并发会话数上限，0 表示使用系统默认值，Roles 为用户拥有的角色名
+// Email 可以为空，非空时全局唯一，统一保存为小写，EmailVerifiedAt 非空表示当前邮箱已验证
+// Status 取值见 def.UserStatus*
 type User struct {
-	ID        uint64         `gorm:"primary_key" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
-	Username  string         `gorm:"size:64;uniqueIndex;not null" json:"username" binding:"required"`
-	Password  string         `gorm:"size:128;not null" json:"-"`
-	Salt      string         `gorm:"size:128;not null" json:"-"`
-	Status    int8           `gorm:"default:1;not null" json:"status"`
-	Habits    []Habit        `gorm:"foreignkey:UserID" json:"habits"`
+	ID              uint64         `gorm:"primary_key" json:"id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	Username        string         `gorm:"size:64;uniqueIndex;not null" json:"username" binding:"required"`
+	Email           *string        `gorm:"size:255;uniqueIndex" json:"email"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`
+	Password        string         `gorm:"size:128;not null" json:"-"`
+	Salt            string         `gorm:"size:128;not null" json:"-"`
+	Status          int8           `gorm:"default:1;not null" json:"status"`
+	Timezone        string         `gorm:"size:64;not null;default:UTC" json:"timezone"`
+	DayRolloverHour int            `gorm:"not null;default:0" json:"day_rollover_hour"`
+	MaxSessions     int            `gorm:"not null;default:0" json:"max_sessions"`
+	Roles           StringList     `gorm:"type:varchar(255);not null;default:user" json:"roles"`
+	Habits          []Habit        `gorm:"foreignkey:UserID" json:"habits"`
 }
 
 func (User) TableName() string {
@@ -23,15 +34,18 @@ func (User) TableName() string {
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
-	u.CreatedAt = time.Now()
-	u.UpdatedAt = time.Now()
+	u.CreatedAt = time.Now().UTC()
+	u.UpdatedAt = time.Now().UTC()
 	if u.Status == 0 {
-		u.Status = 1
+		u.Status = def.UserStatusActive
+	}
+	if len(u.Roles) == 0 {
+		u.Roles = StringList{def.RoleUser}
 	}
 	return nil
 }
 
 func (u *User) BeforeUpdate(tx *gorm.DB) error {
-	u.UpdatedAt = time.Now()
+	u.UpdatedAt = time.Now().UTC()
 	return nil
 }
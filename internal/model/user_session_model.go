@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserSession 记录一次登录产生的设备会话，ID 即刷新令牌的令牌族 ID
+// RevokedAt 非空或 ExpiresAt 已过表示会话失效
+type UserSession struct {
+	ID         string     `gorm:"primaryKey;size:36" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UserID     uint64     `gorm:"not null;index" json:"-"`
+	Device     string     `gorm:"size:64" json:"device"`
+	IP         string     `gorm:"size:64" json:"ip"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	User *User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+func (s *UserSession) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now().UTC()
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (s *UserSession) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserTwoFactor 为用户的 TOTP 二次验证配置，Secret 为加密后的密钥
+// Enabled 为 false 表示已生成密钥但尚未确认，LastCounter 为最近一次使用的时间步，用于防止验证码重放
+type UserTwoFactor struct {
+	UserID      uint64     `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Secret      string     `gorm:"size:255;not null" json:"-"`
+	Enabled     bool       `gorm:"not null;default:false" json:"enabled"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	LastCounter int64      `gorm:"not null;default:0" json:"-"`
+
+	User *User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (UserTwoFactor) TableName() string {
+	return "user_two_factors"
+}
+
+func (t *UserTwoFactor) BeforeCreate(tx *gorm.DB) error {
+	t.CreatedAt = time.Now().UTC()
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (t *UserTwoFactor) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// UserRecoveryCode 为一次性恢复码，只保存摘要，UsedAt 非空表示已使用
+type UserRecoveryCode struct {
+	ID        uint64     `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint64     `gorm:"not null;index" json:"-"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+
+	User *User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+func (c *UserRecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"w2learn/internal/mailer"
+	"w2learn/pkg/def"
+)
+
+var _ Notifier = (*emailNotifier)(nil)
+
+type emailNotifier struct {
+	mailer mailer.Mailer
+}
+
+// NewEmailNotifier 创建邮件渠道，通过 Mailer 发送提醒邮件
+func NewEmailNotifier(mailer mailer.Mailer) Notifier {
+	return &emailNotifier{
+		mailer: mailer,
+	}
+}
+
+func (n *emailNotifier) Channel() string {
+	return def.ReminderChannelEmail
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, msg *Message) error {
+	if msg == nil {
+		return errors.New("msg is nil")
+	}
+
+	if msg.To == "" {
+		return errors.New("email recipient is empty")
+	}
+
+	return n.mailer.Send(ctx, &mailer.Mail{
+		To:      msg.To,
+		Subject: msg.Title,
+		Body:    msg.Body,
+		SentAt:  msg.SentAt,
+	})
+}
@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/pkg/def"
+)
+
+var _ Notifier = (*inboxNotifier)(nil)
+
+type inboxNotifier struct {
+	notificationRepository repository.NotificationRepository
+}
+
+// NewInboxNotifier 创建站内信渠道，消息写入用户的收件箱
+func NewInboxNotifier(notificationRepository repository.NotificationRepository) Notifier {
+	return &inboxNotifier{
+		notificationRepository: notificationRepository,
+	}
+}
+
+func (n *inboxNotifier) Channel() string {
+	return def.ReminderChannelInbox
+}
+
+func (n *inboxNotifier) Notify(ctx context.Context, msg *Message) error {
+	if msg == nil {
+		return errors.New("msg is nil")
+	}
+
+	return n.notificationRepository.Create(ctx, &model.Notification{
+		UserID:     msg.UserID,
+		HabitID:    msg.HabitID,
+		ReminderID: msg.ReminderID,
+		Title:      msg.Title,
+		Body:       msg.Body,
+	})
+}
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Message 为一条待发送的提醒，To 为邮箱或 webhook 地址，站内信不需要
+type Message struct {
+	UserID     uint64    `json:"user_id"`
+	HabitID    uint64    `json:"habit_id"`
+	ReminderID uint64    `json:"reminder_id"`
+	To         string    `json:"-"`
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// Notifier 为一种提醒的投递渠道
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, msg *Message) error
+}
+
+// Dispatcher 按渠道名将消息转交给对应的 Notifier
+type Dispatcher struct {
+	notifiers map[string]Notifier
+}
+
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{
+		notifiers: make(map[string]Notifier, len(notifiers)),
+	}
+
+	for _, n := range notifiers {
+		d.notifiers[n.Channel()] = n
+	}
+
+	return d
+}
+
+func (d *Dispatcher) Notify(ctx context.Context, channel string, msg *Message) error {
+	n, ok := d.notifiers[channel]
+
+	if !ok {
+		return fmt.Errorf("notifier not configured: %s", channel)
+	}
+
+	return n.Notify(ctx, msg)
+}
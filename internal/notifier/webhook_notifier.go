@@ -0,0 +1,112 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+)
+
+var _ Notifier = (*webhookNotifier)(nil)
+
+// WebhookSignatureHeader 为请求体的 HMAC-SHA256 签名，仅在配置了 Secret 时发送
+const WebhookSignatureHeader = "X-W2learn-Signature"
+
+type WebhookConfig struct {
+	Secret  string
+	Timeout time.Duration
+}
+
+type webhookNotifier struct {
+	config *WebhookConfig
+	client *http.Client
+}
+
+func NewWebhookNotifier(config *WebhookConfig) Notifier {
+	return &webhookNotifier{
+		config: config,
+		client: newWebhookClient(config.Timeout, utils.PublicDialControl),
+	}
+}
+
+// newWebhookClient 创建投递 webhook 的客户端，control 在每次建立连接前检查目标地址
+// webhook 地址由用户填写，因此不使用环境变量中的代理，也不跟随重定向，避免借助外部地址跳转到内网
+func newWebhookClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (n *webhookNotifier) Channel() string {
+	return def.ReminderChannelWebhook
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, msg *Message) error {
+	if msg == nil {
+		return errors.New("msg is nil")
+	}
+
+	if msg.To == "" {
+		return errors.New("webhook url is empty")
+	}
+
+	body, err := json.Marshal(msg)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.To, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if n.config.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.config.Secret))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"w2learn/internal/utils"
+)
+
+func allowAll(network, address string, c syscall.RawConn) error {
+	return nil
+}
+
+func TestWebhookRejectsNonPublicAddress(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(&WebhookConfig{Timeout: time.Second})
+
+	err := n.Notify(context.Background(), &Message{To: server.URL, Title: "Read"})
+
+	if !errors.Is(err, utils.ErrNonPublicAddress) {
+		t.Fatalf("Notify to %s = %v, want %v", server.URL, err, utils.ErrNonPublicAddress)
+	}
+
+	if hits != 0 {
+		t.Fatalf("loopback server received %d requests", hits)
+	}
+}
+
+func TestWebhookDoesNotFollowRedirects(t *testing.T) {
+	internal := 0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internal++
+	}))
+	defer target.Close()
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+
+	// 测试服务器位于回环地址，这里放开连接检查以单独验证重定向处理
+	n := &webhookNotifier{
+		config: &WebhookConfig{Secret: "secret", Timeout: time.Second},
+		client: newWebhookClient(time.Second, allowAll),
+	}
+
+	err := n.Notify(context.Background(), &Message{To: redirect.URL, Title: "Read"})
+
+	if err == nil || !strings.Contains(err.Error(), "307") {
+		t.Fatalf("Notify = %v, want a 307 status error", err)
+	}
+
+	if internal != 0 {
+		t.Fatalf("redirect target received %d requests", internal)
+	}
+}
+
+func TestWebhookSignsBody(t *testing.T) {
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(WebhookSignatureHeader)
+	}))
+	defer server.Close()
+
+	n := &webhookNotifier{
+		config: &WebhookConfig{Secret: "secret", Timeout: time.Second},
+		client: newWebhookClient(time.Second, allowAll),
+	}
+
+	err := n.Notify(context.Background(), &Message{To: server.URL, Title: "Read"})
+
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if !strings.HasPrefix(signature, "sha256=") || len(signature) != len("sha256=")+64 {
+		t.Fatalf("signature header = %q", signature)
+	}
+}
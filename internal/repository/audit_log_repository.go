@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ AuditLogRepository = (*auditLogRepository)(nil)
+
+// AuditLogFilter 为审计记录的查询条件，零值表示不限制
+type AuditLogFilter struct {
+	ActorID  uint64
+	TargetID uint64
+	Action   string
+	Result   string
+	IP       string
+	From     time.Time
+	To       time.Time
+}
+
+// AuditLogRepository 只提供写入与查询，审计记录不可修改或删除
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *model.AuditLog) error
+	List(ctx context.Context, filter *AuditLogFilter, offset, limit int) ([]*model.AuditLog, int64, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{
+		db: db,
+	}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+// List 按时间倒序分页返回审计记录及符合条件的总数
+func (r *auditLogRepository) List(ctx context.Context, filter *AuditLogFilter, offset, limit int) ([]*model.AuditLog, int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.AuditLog{})
+
+	if filter.ActorID != 0 {
+		tx = tx.Where("actor_id = ?", filter.ActorID)
+	}
+
+	if filter.TargetID != 0 {
+		tx = tx.Where("target_id = ?", filter.TargetID)
+	}
+
+	if filter.Action != "" {
+		tx = tx.Where("action = ?", filter.Action)
+	}
+
+	if filter.Result != "" {
+		tx = tx.Where("result = ?", filter.Result)
+	}
+
+	if filter.IP != "" {
+		tx = tx.Where("ip = ?", filter.IP)
+	}
+
+	if !filter.From.IsZero() {
+		tx = tx.Where("created_at >= ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		tx = tx.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+
+	err := tx.Count(&total).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var logs []*model.AuditLog
+
+	err = tx.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ CategoryRepository = (*categoryRepository)(nil)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *model.Category) error
+	GetByID(ctx context.Context, id uint64) (*model.Category, error)
+	GetByUserAndName(ctx context.Context, userID uint64, name string) (*model.Category, error)
+	Update(ctx context.Context, category *model.Category) error
+	Delete(ctx context.Context, id uint64) error
+	ListByUserID(ctx context.Context, userID uint64) ([]*model.Category, error)
+}
+
+type categoryRepository struct {
+	*BaseRepository[model.Category]
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{
+		BaseRepository: NewBaseRepository[model.Category](db),
+	}
+}
+
+func (r *categoryRepository) GetByUserAndName(ctx context.Context, userID uint64, name string) (*model.Category, error) {
+	var category model.Category
+	err := r.db.WithContext(ctx).Where("user_id = ? AND name = ?", userID, name).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Delete 删除分类，原属于该分类的习惯变为未分类
+func (r *categoryRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Habit{}).Where("category_id = ?", id).Update("category_id", nil).Error
+
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Category{}, id).Error
+	})
+}
+
+func (r *categoryRepository) ListByUserID(ctx context.Context, userID uint64) ([]*model.Category, error) {
+	var categories []*model.Category
+
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("name ASC").Find(&categories).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ EmailVerificationRepository = (*emailVerificationRepository)(nil)
+
+// EmailVerificationRepository 在 Redis 中保存一次性的邮箱验证令牌，令牌不存在时返回 redis.Nil
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, verification *model.EmailVerification, ttl time.Duration) error
+	Consume(ctx context.Context, hash string) (*model.EmailVerification, error)
+}
+
+type emailVerificationRepository struct {
+	redisClient *redis.Client
+}
+
+func NewEmailVerificationRepository(redisClient *redis.Client) EmailVerificationRepository {
+	return &emailVerificationRepository{
+		redisClient: redisClient,
+	}
+}
+
+func (r *emailVerificationRepository) Create(ctx context.Context, verification *model.EmailVerification, ttl time.Duration) error {
+	data, err := json.Marshal(verification)
+
+	if err != nil {
+		return err
+	}
+
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.EmailVerifyKeyLayout, verification.Hash), data, ttl).Err()
+}
+
+// Consume 原子地取出并删除令牌，保证令牌只能使用一次
+func (r *emailVerificationRepository) Consume(ctx context.Context, hash string) (*model.EmailVerification, error) {
+	data, err := r.redisClient.GetDel(ctx, fmt.Sprintf(def.EmailVerifyKeyLayout, hash)).Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var verification model.EmailVerification
+
+	err = json.Unmarshal(data, &verification)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verification.Hash = hash
+
+	return &verification, nil
+}
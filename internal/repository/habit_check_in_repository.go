@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ HabitCheckInRepository = (*habitCheckInRepository)(nil)
+
+type HabitCheckInRepository interface {
+	Create(ctx context.Context, checkIn *model.HabitCheckIn) error
+	GetByID(ctx context.Context, id uint64) (*model.HabitCheckIn, error)
+	GetByHabitAndDay(ctx context.Context, habitID uint64, day time.Time) (*model.HabitCheckIn, error)
+	Upsert(ctx context.Context, habitID uint64, day time.Time, apply func(checkIn *model.HabitCheckIn, created bool) error) (*model.HabitCheckIn, error)
+	Update(ctx context.Context, checkIn *model.HabitCheckIn) error
+	Delete(ctx context.Context, id uint64) error
+	ListByHabit(ctx context.Context, habitID uint64, from time.Time, to time.Time) ([]*model.HabitCheckIn, error)
+	ListAllByHabit(ctx context.Context, habitID uint64) ([]*model.HabitCheckIn, error)
+	ListByHabitIDsBetween(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*model.HabitCheckIn, error)
+	ListDaysByHabit(ctx context.Context, habitID uint64) ([]time.Time, error)
+	CountByHabit(ctx context.Context, habitID uint64, from time.Time, to time.Time) (int64, error)
+	CountByHabitIDs(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) (map[uint64]int64, error)
+	CountSinceEach(ctx context.Context, habitIDs []uint64, froms []time.Time, to time.Time) ([]int64, error)
+	CountByDay(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*DayCount, error)
+	CountByWeekday(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*WeekdayCount, error)
+	CountByWeek(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*DayCount, error)
+}
+
+// DayCount 为按日期（或按周起始日期）聚合的打卡统计，Completed 为已完成的打卡数，Amount 为累计完成量
+type DayCount struct {
+	Day       time.Time
+	Completed int64
+	Amount    float64
+}
+
+// WeekdayCount 为按星期聚合的已完成打卡数，Weekday 取值 0-6 对应周日至周六
+type WeekdayCount struct {
+	Weekday   int
+	Completed int64
+}
+
+type habitCheckInRepository struct {
+	*BaseRepository[model.HabitCheckIn]
+}
+
+func NewHabitCheckInRepository(db *gorm.DB) HabitCheckInRepository {
+	return &habitCheckInRepository{
+		BaseRepository: NewBaseRepository[model.HabitCheckIn](db),
+	}
+}
+
+func (r *habitCheckInRepository) GetByHabitAndDay(ctx context.Context, habitID uint64, day time.Time) (*model.HabitCheckIn, error) {
+	var checkIn model.HabitCheckIn
+	err := r.db.WithContext(ctx).Where("habit_id = ? AND day = ?", habitID, day).First(&checkIn).Error
+	if err != nil {
+		return nil, err
+	}
+	return &checkIn, nil
+}
+
+// Upsert 在事务中锁定习惯在 day 的打卡记录，不存在时先创建，再交给 apply 修改后保存
+// created 表示记录是否由本次调用创建，apply 返回错误时回滚，并发打卡依次累加不会互相覆盖
+func (r *habitCheckInRepository) Upsert(
+	ctx context.Context,
+	habitID uint64,
+	day time.Time,
+	apply func(checkIn *model.HabitCheckIn, created bool) error,
+) (*model.HabitCheckIn, error) {
+	var checkIn model.HabitCheckIn
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		completed := false
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.HabitCheckIn{
+			HabitID:   habitID,
+			Day:       day,
+			Completed: &completed,
+		})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("habit_id = ? AND day = ?", habitID, day).
+			First(&checkIn).Error
+
+		if err != nil {
+			return err
+		}
+
+		err = apply(&checkIn, result.RowsAffected > 0)
+
+		if err != nil {
+			return err
+		}
+
+		return tx.Save(&checkIn).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkIn, nil
+}
+
+// ListByHabit 按日期升序返回 [from, to] 闭区间内的打卡记录
+func (r *habitCheckInRepository) ListByHabit(ctx context.Context, habitID uint64, from time.Time, to time.Time) ([]*model.HabitCheckIn, error) {
+	var checkIns []*model.HabitCheckIn
+
+	err := r.db.WithContext(ctx).
+		Where("habit_id = ? AND day BETWEEN ? AND ?", habitID, from, to).
+		Order("day ASC").
+		Find(&checkIns).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return checkIns, nil
+}
+
+func (r *habitCheckInRepository) ListAllByHabit(ctx context.Context, habitID uint64) ([]*model.HabitCheckIn, error) {
+	var checkIns []*model.HabitCheckIn
+
+	err := r.db.WithContext(ctx).Where("habit_id = ?", habitID).Order("day ASC").Find(&checkIns).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return checkIns, nil
+}
+
+func (r *habitCheckInRepository) ListByHabitIDsBetween(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*model.HabitCheckIn, error) {
+	var checkIns []*model.HabitCheckIn
+
+	if len(habitIDs) == 0 {
+		return checkIns, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("habit_id IN ? AND day BETWEEN ? AND ?", habitIDs, from, to).Find(&checkIns).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return checkIns, nil
+}
+
+// ListDaysByHabit 按升序返回习惯所有已完成的打卡日期，用于重建连续打卡状态
+func (r *habitCheckInRepository) ListDaysByHabit(ctx context.Context, habitID uint64) ([]time.Time, error) {
+	var days []time.Time
+
+	err := r.db.WithContext(ctx).
+		Model(&model.HabitCheckIn{}).
+		Where("habit_id = ? AND completed", habitID).
+		Order("day ASC").
+		Pluck("day", &days).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// CountByHabit 统计 [from, to] 闭区间内已完成的打卡天数
+func (r *habitCheckInRepository) CountByHabit(ctx context.Context, habitID uint64, from time.Time, to time.Time) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&model.HabitCheckIn{}).
+		Where("habit_id = ? AND completed AND day BETWEEN ? AND ?", habitID, from, to).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountByHabitIDs 按习惯分组统计 [from, to] 闭区间内已完成的打卡天数，没有打卡的习惯不在结果中
+func (r *habitCheckInRepository) CountByHabitIDs(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) (map[uint64]int64, error) {
+	counts := make(map[uint64]int64, len(habitIDs))
+
+	if len(habitIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		HabitID uint64
+		Count   int64
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&model.HabitCheckIn{}).
+		Select("habit_id, COUNT(*) AS count").
+		Where("habit_id IN ? AND completed AND day BETWEEN ? AND ?", habitIDs, from, to).
+		Group("habit_id").
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.HabitID] = row.Count
+	}
+
+	return counts, nil
+}
+
+// CountSinceEach 在一次查询中分别统计 [froms[i], to] 内已完成的打卡数
+func (r *habitCheckInRepository) CountSinceEach(ctx context.Context, habitIDs []uint64, froms []time.Time, to time.Time) ([]int64, error) {
+	counts := make([]int64, len(froms))
+
+	if len(habitIDs) == 0 || len(froms) == 0 {
+		return counts, nil
+	}
+
+	columns := make([]string, 0, len(froms))
+	args := make([]any, 0, len(froms))
+	earliest := froms[0]
+
+	for i, from := range froms {
+		columns = append(columns, fmt.Sprintf("COUNT(*) FILTER (WHERE day >= ?) AS c%d", i))
+		args = append(args, from)
+
+		if from.Before(earliest) {
+			earliest = from
+		}
+	}
+
+	row := r.db.WithContext(ctx).
+		Model(&model.HabitCheckIn{}).
+		Select(strings.Join(columns, ", "), args...).
+		Where("habit_id IN ? AND completed AND day BETWEEN ? AND ?", habitIDs, earliest, to).
+		Row()
+
+	dest := make([]any, 0, len(counts))
+
+	for i := range counts {
+		dest = append(dest, &counts[i])
+	}
+
+	err := row.Scan(dest...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// CountByDay 按日期升序返回 [from, to] 内每天的打卡统计，没有打卡的日期不在结果中
+func (r *habitCheckInRepository) CountByDay(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*DayCount, error) {
+	var rows []*DayCount
+
+	if len(habitIDs) == 0 {
+		return rows, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&model.HabitCheckIn{}).
+		Select("day, COUNT(*) FILTER (WHERE completed) AS completed, COALESCE(SUM(amount), 0) AS amount").
+		Where("habit_id IN ? AND day BETWEEN ? AND ?", habitIDs, from, to).
+		Group("day").
+		Order("day ASC").
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// CountByWeekday 按星期统计 [from, to] 内已完成的打卡数
+func (r *habitCheckInRepository) CountByWeekday(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*WeekdayCount, error) {
+	var rows []*WeekdayCount
+
+	if len(habitIDs) == 0 {
+		return rows, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&model.HabitCheckIn{}).
+		Select("CAST(EXTRACT(DOW FROM day) AS INTEGER) AS weekday, COUNT(*) AS completed").
+		Where("habit_id IN ? AND completed AND day BETWEEN ? AND ?", habitIDs, from, to).
+		Group("weekday").
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// CountByWeek 按周（周一开始）升序返回 [from, to] 内的打卡统计，Day 为每周的周一
+func (r *habitCheckInRepository) CountByWeek(ctx context.Context, habitIDs []uint64, from time.Time, to time.Time) ([]*DayCount, error) {
+	var rows []*DayCount
+
+	if len(habitIDs) == 0 {
+		return rows, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&model.HabitCheckIn{}).
+		Select("CAST(date_trunc('week', day) AS DATE) AS day, COUNT(*) FILTER (WHERE completed) AS completed, COALESCE(SUM(amount), 0) AS amount").
+		Where("habit_id IN ? AND day BETWEEN ? AND ?", habitIDs, from, to).
+		Group("date_trunc('week', day)").
+		Order("day ASC").
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ HabitPauseRepository = (*habitPauseRepository)(nil)
+
+type HabitPauseRepository interface {
+	Create(ctx context.Context, pause *model.HabitPause) error
+	Update(ctx context.Context, pause *model.HabitPause) error
+	Delete(ctx context.Context, id uint64) error
+	GetLatestByHabit(ctx context.Context, habitID uint64) (*model.HabitPause, error)
+}
+
+type habitPauseRepository struct {
+	*BaseRepository[model.HabitPause]
+}
+
+func NewHabitPauseRepository(db *gorm.DB) HabitPauseRepository {
+	return &habitPauseRepository{
+		BaseRepository: NewBaseRepository[model.HabitPause](db),
+	}
+}
+
+func (r *habitPauseRepository) GetLatestByHabit(ctx context.Context, habitID uint64) (*model.HabitPause, error) {
+	var pause model.HabitPause
+	err := r.db.WithContext(ctx).Where("habit_id = ?", habitID).Order("start_day DESC").First(&pause).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pause, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+
+	"gorm.io/gorm"
+)
+
+var _ HabitReminderRepository = (*habitReminderRepository)(nil)
+
+type HabitReminderRepository interface {
+	Create(ctx context.Context, reminder *model.HabitReminder) error
+	GetByID(ctx context.Context, id uint64) (*model.HabitReminder, error)
+	Update(ctx context.Context, reminder *model.HabitReminder) error
+	Delete(ctx context.Context, id uint64) error
+	ListByHabit(ctx context.Context, habitID uint64) ([]*model.HabitReminder, error)
+	ListActive(ctx context.Context, now time.Time) ([]*model.HabitReminder, error)
+	MarkFired(ctx context.Context, id uint64, firedAt time.Time) error
+	ClearSnooze(ctx context.Context, id uint64, at time.Time) error
+}
+
+type habitReminderRepository struct {
+	*BaseRepository[model.HabitReminder]
+}
+
+func NewHabitReminderRepository(db *gorm.DB) HabitReminderRepository {
+	return &habitReminderRepository{
+		BaseRepository: NewBaseRepository[model.HabitReminder](db),
+	}
+}
+
+func (r *habitReminderRepository) ListByHabit(ctx context.Context, habitID uint64) ([]*model.HabitReminder, error) {
+	var reminders []*model.HabitReminder
+
+	err := r.db.WithContext(ctx).Where("habit_id = ?", habitID).Order("id ASC").Find(&reminders).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// ListActive 返回所有活跃习惯的提醒，并加载习惯及其暂停记录和用户，供调度器使用。
+// 定时暂停只在读取习惯时才恢复，因此恢复日期已到的暂停习惯也一并返回；
+// 用户所在时区可能领先 UTC 一天，具体某天是否仍在暂停由调度器按暂停记录判断
+func (r *habitReminderRepository) ListActive(ctx context.Context, now time.Time) ([]*model.HabitReminder, error) {
+	var reminders []*model.HabitReminder
+
+	resumeBefore := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+
+	err := r.db.WithContext(ctx).
+		Joins("JOIN habits ON habits.id = habit_reminders.habit_id").
+		Where("habits.status = ? OR (habits.status = ? AND habits.paused_until <= ?)",
+			def.HabitStatusActive, def.HabitStatusPaused, resumeBefore).
+		Preload("Habit.Pauses").
+		Preload("User").
+		Order("habit_reminders.id ASC").
+		Find(&reminders).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// MarkFired 记录提醒的触发时刻，已到期的稍后提醒随之清空
+func (r *habitReminderRepository) MarkFired(ctx context.Context, id uint64, firedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.HabitReminder{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{
+			"last_fired_at": firedAt,
+			"snoozed_until": gorm.Expr("CASE WHEN snoozed_until <= ? THEN NULL ELSE snoozed_until END", firedAt),
+		}).Error
+}
+
+// ClearSnooze 清空已到期但无需发送的稍后提醒
+func (r *habitReminderRepository) ClearSnooze(ctx context.Context, id uint64, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.HabitReminder{}).
+		Where("id = ? AND snoozed_until <= ?", id, at).
+		UpdateColumn("snoozed_until", nil).Error
+}
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"w2learn/internal/model"
+	"w2learn/pkg/def"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ HabitRepository = (*habitRepository)(nil)
@@ -15,6 +18,18 @@ type HabitRepository interface {
 	Update(ctx context.Context, user *model.Habit) error
 	Delete(ctx context.Context, id uint64) error
 	List(ctx context.Context, offset, limit int) ([]*model.Habit, error)
+	ListByUserID(ctx context.Context, userID uint64, filter *HabitFilter) ([]*model.Habit, error)
+	ListPageByUserID(ctx context.Context, userID uint64, filter *HabitFilter, offset, limit int) ([]*model.Habit, error)
+	GetByUserAndName(ctx context.Context, userID uint64, name string) (*model.Habit, error)
+	ReplaceTags(ctx context.Context, habit *model.Habit, tags []model.Tag) error
+	RenameDuplicateNames(ctx context.Context) (int64, error)
+}
+
+// HabitFilter 为习惯列表的过滤条件，零值字段表示不限
+type HabitFilter struct {
+	Statuses   []string
+	TagID      uint64
+	CategoryID uint64
 }
 
 type habitRepository struct {
@@ -26,3 +41,114 @@ func NewHabitRepository(db *gorm.DB) HabitRepository {
 		BaseRepository: NewBaseRepository[model.Habit](db),
 	}
 }
+
+// Update 只保存习惯本身，暂停记录等关联数据由各自的仓库维护，标签通过 ReplaceTags 修改
+func (r *habitRepository) Update(ctx context.Context, habit *model.Habit) error {
+	if habit == nil {
+		return errors.New("entity is nil")
+	}
+
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(habit).Error
+}
+
+func (r *habitRepository) GetByID(ctx context.Context, id uint64) (*model.Habit, error) {
+	var habit model.Habit
+	err := r.preload(ctx).First(&habit, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &habit, nil
+}
+
+// ListByUserID 返回用户满足过滤条件的全部习惯，filter 为空时不限条件
+func (r *habitRepository) ListByUserID(ctx context.Context, userID uint64, filter *HabitFilter) ([]*model.Habit, error) {
+	var habits []*model.Habit
+
+	err := r.byUser(ctx, userID, filter).Order("id ASC").Find(&habits).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return habits, nil
+}
+
+func (r *habitRepository) ListPageByUserID(ctx context.Context, userID uint64, filter *HabitFilter, offset, limit int) ([]*model.Habit, error) {
+	var habits []*model.Habit
+
+	err := r.byUser(ctx, userID, filter).
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&habits).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return habits, nil
+}
+
+func (r *habitRepository) ReplaceTags(ctx context.Context, habit *model.Habit, tags []model.Tag) error {
+	if habit == nil {
+		return errors.New("entity is nil")
+	}
+
+	return r.db.WithContext(ctx).Model(habit).Association("Tags").Replace(tags)
+}
+
+// GetByUserAndName 返回用户名下同名且未归档的习惯
+func (r *habitRepository) GetByUserAndName(ctx context.Context, userID uint64, name string) (*model.Habit, error) {
+	var habit model.Habit
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND name = ? AND archived_at IS NULL", userID, name).
+		First(&habit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &habit, nil
+}
+
+func (r *habitRepository) preload(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Preload("Pauses").Preload("Tags").Preload("Category")
+}
+
+func (r *habitRepository) byUser(ctx context.Context, userID uint64, filter *HabitFilter) *gorm.DB {
+	tx := r.preload(ctx).Where("user_id = ?", userID)
+
+	if filter == nil {
+		return tx
+	}
+
+	if len(filter.Statuses) > 0 {
+		tx = tx.Where("status IN ?", filter.Statuses)
+	}
+
+	if filter.TagID != 0 {
+		tx = tx.Where("id IN (SELECT habit_id FROM habit_tags WHERE tag_id = ?)", filter.TagID)
+	}
+
+	if filter.CategoryID != 0 {
+		tx = tx.Where("category_id = ?", filter.CategoryID)
+	}
+
+	return tx
+}
+
+// RenameDuplicateNames 为同一用户重名的未归档习惯追加 ID 后缀，只保留最早创建的一个原名
+// 在 AutoMigrate 创建 idx_habits_user_name 之前执行，否则已有的重复数据会使建索引失败
+func (r *habitRepository) RenameDuplicateNames(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE habits
+		SET name = LEFT(habits.name, ? - LENGTH(' (' || habits.id || ')')) || ' (' || habits.id || ')'
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, name ORDER BY id) AS rn
+			FROM habits
+			WHERE archived_at IS NULL
+		) AS duplicates
+		WHERE habits.id = duplicates.id AND duplicates.rn > 1`,
+		def.HabitNameMaxLen,
+	)
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ HabitStreakRepository = (*habitStreakRepository)(nil)
+
+type HabitStreakRepository interface {
+	GetByHabitID(ctx context.Context, habitID uint64) (*model.HabitStreak, error)
+	ListByHabitIDs(ctx context.Context, habitIDs []uint64) ([]*model.HabitStreak, error)
+	Update(ctx context.Context, streak *model.HabitStreak) error
+}
+
+type habitStreakRepository struct {
+	*BaseRepository[model.HabitStreak]
+}
+
+func NewHabitStreakRepository(db *gorm.DB) HabitStreakRepository {
+	return &habitStreakRepository{
+		BaseRepository: NewBaseRepository[model.HabitStreak](db),
+	}
+}
+
+func (r *habitStreakRepository) GetByHabitID(ctx context.Context, habitID uint64) (*model.HabitStreak, error) {
+	var streak model.HabitStreak
+	err := r.db.WithContext(ctx).Where("habit_id = ?", habitID).First(&streak).Error
+	if err != nil {
+		return nil, err
+	}
+	return &streak, nil
+}
+
+func (r *habitStreakRepository) ListByHabitIDs(ctx context.Context, habitIDs []uint64) ([]*model.HabitStreak, error) {
+	var streaks []*model.HabitStreak
+
+	if len(habitIDs) == 0 {
+		return streaks, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("habit_id IN ?", habitIDs).Find(&streaks).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return streaks, nil
+}
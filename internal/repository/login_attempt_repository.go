@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	"w2learn/pkg/def"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ LoginAttemptRepository = (*loginAttemptRepository)(nil)
+
+// LoginAttemptRepository 在 Redis 中按用户名与 IP 记录登录失败，scope 取值为 def.LoginScope*
+type LoginAttemptRepository interface {
+	RecordFailure(ctx context.Context, scope string, subject string, at time.Time, window time.Duration) (int64, error)
+	SetBackoff(ctx context.Context, scope string, subject string, ttl time.Duration) error
+	Lock(ctx context.Context, username string, ttl time.Duration) error
+	RetryAfter(ctx context.Context, username string, ip string) (time.Duration, bool, error)
+	Reset(ctx context.Context, username string) error
+	Unlock(ctx context.Context, username string) (bool, error)
+}
+
+type loginAttemptRepository struct {
+	redisClient *redis.Client
+}
+
+func NewLoginAttemptRepository(redisClient *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		redisClient: redisClient,
+	}
+}
+
+// RecordFailure 记录一次失败并返回滑动窗口内的失败次数
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, scope string, subject string, at time.Time, window time.Duration) (int64, error) {
+	key := fmt.Sprintf(def.LoginFailureKeyLayout, scope, subject)
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(at.Add(-window).UnixNano(), 10))
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixNano()), Member: uuid.NewString()})
+	count := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, window)
+
+	_, err := pipe.Exec(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+func (r *loginAttemptRepository) SetBackoff(ctx context.Context, scope string, subject string, ttl time.Duration) error {
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.LoginBackoffKeyLayout, scope, subject), 1, ttl).Err()
+}
+
+// Lock 锁定账户，同时清空失败记录，解锁后重新开始计数
+func (r *loginAttemptRepository) Lock(ctx context.Context, username string, ttl time.Duration) error {
+	pipe := r.redisClient.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf(def.LoginLockKeyLayout, username), 1, ttl)
+	pipe.Del(ctx,
+		fmt.Sprintf(def.LoginFailureKeyLayout, def.LoginScopeUser, username),
+		fmt.Sprintf(def.LoginBackoffKeyLayout, def.LoginScopeUser, username),
+	)
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+// RetryAfter 返回允许再次尝试前需要等待的时间，账户被锁定时第二个返回值为 true
+func (r *loginAttemptRepository) RetryAfter(ctx context.Context, username string, ip string) (time.Duration, bool, error) {
+	pipe := r.redisClient.Pipeline()
+	lock := pipe.PTTL(ctx, fmt.Sprintf(def.LoginLockKeyLayout, username))
+	userBackoff := pipe.PTTL(ctx, fmt.Sprintf(def.LoginBackoffKeyLayout, def.LoginScopeUser, username))
+	ipBackoff := pipe.PTTL(ctx, fmt.Sprintf(def.LoginBackoffKeyLayout, def.LoginScopeIP, ip))
+
+	_, err := pipe.Exec(ctx)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	// 键不存在时 PTTL 返回负值
+	if lock.Val() > 0 {
+		return lock.Val(), true, nil
+	}
+
+	return max(userBackoff.Val(), ipBackoff.Val(), 0), false, nil
+}
+
+// Reset 在登录成功后清空用户名的失败记录与退避，不影响 IP 的计数
+func (r *loginAttemptRepository) Reset(ctx context.Context, username string) error {
+	return r.redisClient.Del(ctx,
+		fmt.Sprintf(def.LoginFailureKeyLayout, def.LoginScopeUser, username),
+		fmt.Sprintf(def.LoginBackoffKeyLayout, def.LoginScopeUser, username),
+	).Err()
+}
+
+// Unlock 解除账户锁定并清空失败记录，账户此前未被锁定时返回 false
+func (r *loginAttemptRepository) Unlock(ctx context.Context, username string) (bool, error) {
+	pipe := r.redisClient.TxPipeline()
+	lock := pipe.Del(ctx, fmt.Sprintf(def.LoginLockKeyLayout, username))
+	pipe.Del(ctx,
+		fmt.Sprintf(def.LoginFailureKeyLayout, def.LoginScopeUser, username),
+		fmt.Sprintf(def.LoginBackoffKeyLayout, def.LoginScopeUser, username),
+	)
+
+	_, err := pipe.Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return lock.Val() > 0, nil
+}
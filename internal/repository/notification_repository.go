@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ NotificationRepository = (*notificationRepository)(nil)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	GetByID(ctx context.Context, id uint64) (*model.Notification, error)
+	ListPageByUserID(ctx context.Context, userID uint64, unread bool, offset, limit int) ([]*model.Notification, error)
+	CountUnread(ctx context.Context, userID uint64) (int64, error)
+	MarkRead(ctx context.Context, id uint64, readAt time.Time) error
+	MarkAllRead(ctx context.Context, userID uint64, readAt time.Time) error
+}
+
+type notificationRepository struct {
+	*BaseRepository[model.Notification]
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{
+		BaseRepository: NewBaseRepository[model.Notification](db),
+	}
+}
+
+// ListPageByUserID 按时间倒序分页返回用户的站内信，unread 为 true 时只返回未读消息
+func (r *notificationRepository) ListPageByUserID(ctx context.Context, userID uint64, unread bool, offset, limit int) ([]*model.Notification, error) {
+	var notifications []*model.Notification
+
+	tx := r.db.WithContext(ctx).Where("user_id = ?", userID)
+
+	if unread {
+		tx = tx.Where("read_at IS NULL")
+	}
+
+	err := tx.Order("id DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint64) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, id uint64, readAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", readAt).Error
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uint64, readAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", readAt).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ OIDCStateRepository = (*oidcStateRepository)(nil)
+
+// OIDCStateRepository 在 Redis 中保存授权请求，state 不存在时返回 redis.Nil
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state *model.OIDCState, ttl time.Duration) error
+	Consume(ctx context.Context, hash string) (*model.OIDCState, error)
+}
+
+type oidcStateRepository struct {
+	redisClient *redis.Client
+}
+
+func NewOIDCStateRepository(redisClient *redis.Client) OIDCStateRepository {
+	return &oidcStateRepository{
+		redisClient: redisClient,
+	}
+}
+
+func (r *oidcStateRepository) Create(ctx context.Context, state *model.OIDCState, ttl time.Duration) error {
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return err
+	}
+
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.OIDCStateKeyLayout, state.Hash), data, ttl).Err()
+}
+
+// Consume 原子地取出并删除授权请求，保证 state 只能使用一次
+func (r *oidcStateRepository) Consume(ctx context.Context, hash string) (*model.OIDCState, error) {
+	data, err := r.redisClient.GetDel(ctx, fmt.Sprintf(def.OIDCStateKeyLayout, hash)).Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var state model.OIDCState
+
+	err = json.Unmarshal(data, &state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state.Hash = hash
+
+	return &state, nil
+}
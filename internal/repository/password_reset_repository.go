@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"w2learn/pkg/def"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ PasswordResetRepository = (*passwordResetRepository)(nil)
+
+// PasswordResetRepository 在 Redis 中保存一次性的重置口令令牌，令牌不存在时返回 redis.Nil
+type PasswordResetRepository interface {
+	Create(ctx context.Context, userID uint64, hash string, ttl time.Duration) error
+	Consume(ctx context.Context, hash string) (uint64, error)
+}
+
+type passwordResetRepository struct {
+	redisClient *redis.Client
+}
+
+func NewPasswordResetRepository(redisClient *redis.Client) PasswordResetRepository {
+	return &passwordResetRepository{
+		redisClient: redisClient,
+	}
+}
+
+// Create 保存新的重置令牌，并作废该用户此前签发的令牌
+func (r *passwordResetRepository) Create(ctx context.Context, userID uint64, hash string, ttl time.Duration) error {
+	userKey := fmt.Sprintf(def.PasswordResetUserKeyLayout, userID)
+
+	previous, err := r.redisClient.Get(ctx, userKey).Result()
+
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	pipe := r.redisClient.TxPipeline()
+
+	if previous != "" {
+		pipe.Del(ctx, fmt.Sprintf(def.PasswordResetKeyLayout, previous))
+	}
+
+	pipe.Set(ctx, fmt.Sprintf(def.PasswordResetKeyLayout, hash), userID, ttl)
+	pipe.Set(ctx, userKey, hash, ttl)
+
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+// Consume 原子地取出并删除令牌，保证令牌只能使用一次
+func (r *passwordResetRepository) Consume(ctx context.Context, hash string) (uint64, error) {
+	userID, err := r.redisClient.GetDel(ctx, fmt.Sprintf(def.PasswordResetKeyLayout, hash)).Uint64()
+
+	if err != nil {
+		return 0, err
+	}
+
+	_ = r.redisClient.Del(ctx, fmt.Sprintf(def.PasswordResetUserKeyLayout, userID)).Err()
+
+	return userID, nil
+}
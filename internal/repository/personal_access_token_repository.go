@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ PersonalAccessTokenRepository = (*personalAccessTokenRepository)(nil)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *model.PersonalAccessToken) error
+	GetByHash(ctx context.Context, hash string) (*model.PersonalAccessToken, error)
+	ListActiveByUser(ctx context.Context, userID uint64, now time.Time) ([]*model.PersonalAccessToken, error)
+	CountActiveByUser(ctx context.Context, userID uint64, now time.Time) (int64, error)
+	Touch(ctx context.Context, id uint64, usedAt time.Time) error
+	Revoke(ctx context.Context, userID uint64, id uint64, revokedAt time.Time) (bool, error)
+	RevokeAllByUser(ctx context.Context, userID uint64, revokedAt time.Time) (int64, error)
+}
+
+type personalAccessTokenRepository struct {
+	*BaseRepository[model.PersonalAccessToken]
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{
+		BaseRepository: NewBaseRepository[model.PersonalAccessToken](db),
+	}
+}
+
+func (r *personalAccessTokenRepository) GetByHash(ctx context.Context, hash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListActiveByUser 按创建时间降序返回用户未撤销且未过期的令牌
+func (r *personalAccessTokenRepository) ListActiveByUser(ctx context.Context, userID uint64, now time.Time) ([]*model.PersonalAccessToken, error) {
+	var tokens []*model.PersonalAccessToken
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at DESC").
+		Find(&tokens).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *personalAccessTokenRepository) CountActiveByUser(ctx context.Context, userID uint64, now time.Time) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&model.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (r *personalAccessTokenRepository) Touch(ctx context.Context, id uint64, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.PersonalAccessToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
+
+// Revoke 撤销用户自己的令牌，令牌不存在、不属于该用户或已撤销时返回 false
+func (r *personalAccessTokenRepository) Revoke(ctx context.Context, userID uint64, id uint64, revokedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", revokedAt)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeAllByUser 撤销用户全部未撤销的令牌，返回撤销的数量
+func (r *personalAccessTokenRepository) RevokeAllByUser(ctx context.Context, userID uint64, revokedAt time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt)
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ RefreshTokenRepository = (*refreshTokenRepository)(nil)
+
+// RefreshTokenRepository 在 Redis 中保存刷新令牌，令牌不存在时返回 redis.Nil
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	MarkUsed(ctx context.Context, token *model.RefreshToken) (bool, error)
+	ActivateFamily(ctx context.Context, familyID string, ttl time.Duration) error
+	IsFamilyActive(ctx context.Context, familyID string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+
+type refreshTokenRepository struct {
+	redisClient *redis.Client
+}
+
+func NewRefreshTokenRepository(redisClient *redis.Client) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		redisClient: redisClient,
+	}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	data, err := json.Marshal(token)
+
+	if err != nil {
+		return err
+	}
+
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.RefreshTokenKeyLayout, token.Hash), data, time.Until(token.ExpiresAt)).Err()
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	data, err := r.redisClient.Get(ctx, fmt.Sprintf(def.RefreshTokenKeyLayout, hash)).Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var token model.RefreshToken
+
+	err = json.Unmarshal(data, &token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token.Hash = hash
+
+	return &token, nil
+}
+
+// MarkUsed 原子地将令牌标记为已轮换，令牌此前已被使用过时返回 false
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, token *model.RefreshToken) (bool, error) {
+	return r.redisClient.SetNX(ctx, fmt.Sprintf(def.RefreshTokenUsedKeyLayout, token.Hash), 1, time.Until(token.ExpiresAt)).Result()
+}
+
+func (r *refreshTokenRepository) ActivateFamily(ctx context.Context, familyID string, ttl time.Duration) error {
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.RefreshFamilyKeyLayout, familyID), 1, ttl).Err()
+}
+
+func (r *refreshTokenRepository) IsFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	n, err := r.redisClient.Exists(ctx, fmt.Sprintf(def.RefreshFamilyKeyLayout, familyID)).Result()
+
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.redisClient.Del(ctx, fmt.Sprintf(def.RefreshFamilyKeyLayout, familyID)).Err()
+}
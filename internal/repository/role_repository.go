@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ RoleRepository = (*roleRepository)(nil)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *model.Role) error
+	GetByID(ctx context.Context, id uint64) (*model.Role, error)
+	GetByName(ctx context.Context, name string) (*model.Role, error)
+	Update(ctx context.Context, role *model.Role) error
+	Delete(ctx context.Context, id uint64) error
+	ListAll(ctx context.Context) ([]*model.Role, error)
+	ListByNames(ctx context.Context, names []string) ([]*model.Role, error)
+}
+
+type roleRepository struct {
+	*BaseRepository[model.Role]
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{
+		BaseRepository: NewBaseRepository[model.Role](db),
+	}
+}
+
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) ListAll(ctx context.Context) ([]*model.Role, error) {
+	var roles []*model.Role
+
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&roles).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *roleRepository) ListByNames(ctx context.Context, names []string) ([]*model.Role, error) {
+	var roles []*model.Role
+
+	if len(names) == 0 {
+		return roles, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&roles).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ TagRepository = (*tagRepository)(nil)
+
+type TagRepository interface {
+	Create(ctx context.Context, tag *model.Tag) error
+	GetByID(ctx context.Context, id uint64) (*model.Tag, error)
+	GetByUserAndName(ctx context.Context, userID uint64, name string) (*model.Tag, error)
+	Update(ctx context.Context, tag *model.Tag) error
+	Delete(ctx context.Context, id uint64) error
+	ListByUserID(ctx context.Context, userID uint64) ([]*model.Tag, error)
+	ListByUserAndIDs(ctx context.Context, userID uint64, ids []uint64) ([]*model.Tag, error)
+}
+
+type tagRepository struct {
+	*BaseRepository[model.Tag]
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{
+		BaseRepository: NewBaseRepository[model.Tag](db),
+	}
+}
+
+func (r *tagRepository) GetByUserAndName(ctx context.Context, userID uint64, name string) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.db.WithContext(ctx).Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Delete 删除标签及其与习惯的关联
+func (r *tagRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("DELETE FROM habit_tags WHERE tag_id = ?", id).Error
+
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Tag{}, id).Error
+	})
+}
+
+func (r *tagRepository) ListByUserID(ctx context.Context, userID uint64) ([]*model.Tag, error) {
+	var tags []*model.Tag
+
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("name ASC").Find(&tags).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *tagRepository) ListByUserAndIDs(ctx context.Context, userID uint64, ids []uint64) ([]*model.Tag, error) {
+	var tags []*model.Tag
+
+	if len(ids) == 0 {
+		return tags, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("user_id = ? AND id IN ?", userID, ids).Find(&tags).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
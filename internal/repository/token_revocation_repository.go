@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"w2learn/pkg/def"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ TokenRevocationRepository = (*tokenRevocationRepository)(nil)
+
+// TokenRevocationRepository 在 Redis 中保存访问令牌黑名单与用户的令牌失效水位，由 JWTAuthMiddleware 检查
+type TokenRevocationRepository interface {
+	Blacklist(ctx context.Context, tokenID string, expiresAt time.Time) error
+	RevokeBefore(ctx context.Context, uid uint64, at time.Time) error
+}
+
+type tokenRevocationRepository struct {
+	redisClient      *redis.Client
+	revokedBeforeTTL time.Duration
+}
+
+// NewTokenRevocationRepository 中 accessTokenTTL 为配置的访问令牌有效期，失效水位至少保留到水位前签发的令牌全部过期
+func NewTokenRevocationRepository(redisClient *redis.Client, accessTokenTTL time.Duration) TokenRevocationRepository {
+	if accessTokenTTL <= 0 {
+		accessTokenTTL = def.AccessTokenDefaultTTL * time.Second
+	}
+
+	return &tokenRevocationRepository{
+		redisClient:      redisClient,
+		revokedBeforeTTL: accessTokenTTL,
+	}
+}
+
+// Blacklist 将访问令牌加入黑名单直到其过期，已过期的令牌无需记录
+func (r *tokenRevocationRepository) Blacklist(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+
+	if ttl <= 0 {
+		return nil
+	}
+
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.TokenBlacklistKeyLayout, tokenID), 1, ttl).Err()
+}
+
+// RevokeBefore 使用户在 at 之前签发的访问令牌全部失效
+func (r *tokenRevocationRepository) RevokeBefore(ctx context.Context, uid uint64, at time.Time) error {
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.TokenRevokedBeforeKeyLayout, uid), at.UnixMilli(), r.revokedBeforeTTL).Err()
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ TwoFactorChallengeRepository = (*twoFactorChallengeRepository)(nil)
+
+// TwoFactorChallengeRepository 在 Redis 中保存登录二次验证挑战，挑战不存在时返回 redis.Nil
+type TwoFactorChallengeRepository interface {
+	Create(ctx context.Context, challenge *model.TwoFactorChallenge) error
+	GetByHash(ctx context.Context, hash string) (*model.TwoFactorChallenge, error)
+	IncrAttempts(ctx context.Context, challenge *model.TwoFactorChallenge) (int64, error)
+	Delete(ctx context.Context, hash string) (bool, error)
+}
+
+type twoFactorChallengeRepository struct {
+	redisClient *redis.Client
+}
+
+func NewTwoFactorChallengeRepository(redisClient *redis.Client) TwoFactorChallengeRepository {
+	return &twoFactorChallengeRepository{
+		redisClient: redisClient,
+	}
+}
+
+func (r *twoFactorChallengeRepository) Create(ctx context.Context, challenge *model.TwoFactorChallenge) error {
+	data, err := json.Marshal(challenge)
+
+	if err != nil {
+		return err
+	}
+
+	return r.redisClient.Set(ctx, fmt.Sprintf(def.TwoFactorChallengeKeyLayout, challenge.Hash), data, time.Until(challenge.ExpiresAt)).Err()
+}
+
+func (r *twoFactorChallengeRepository) GetByHash(ctx context.Context, hash string) (*model.TwoFactorChallenge, error) {
+	data, err := r.redisClient.Get(ctx, fmt.Sprintf(def.TwoFactorChallengeKeyLayout, hash)).Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var challenge model.TwoFactorChallenge
+
+	err = json.Unmarshal(data, &challenge)
+
+	if err != nil {
+		return nil, err
+	}
+
+	challenge.Hash = hash
+
+	return &challenge, nil
+}
+
+// IncrAttempts 记录一次验证失败并返回累计失败次数
+func (r *twoFactorChallengeRepository) IncrAttempts(ctx context.Context, challenge *model.TwoFactorChallenge) (int64, error) {
+	key := fmt.Sprintf(def.TwoFactorAttemptsKeyLayout, challenge.Hash)
+
+	pipe := r.redisClient.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireAt(ctx, key, challenge.ExpiresAt)
+
+	_, err := pipe.Exec(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// Delete 删除挑战，挑战此前已被删除时返回 false，用于保证挑战只能兑换一次
+func (r *twoFactorChallengeRepository) Delete(ctx context.Context, hash string) (bool, error) {
+	n, err := r.redisClient.Del(ctx,
+		fmt.Sprintf(def.TwoFactorChallengeKeyLayout, hash),
+		fmt.Sprintf(def.TwoFactorAttemptsKeyLayout, hash),
+	).Result()
+
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
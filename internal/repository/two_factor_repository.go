@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ TwoFactorRepository = (*twoFactorRepository)(nil)
+
+// TwoFactorRepository 保存用户的 TOTP 配置与恢复码
+type TwoFactorRepository interface {
+	GetByUserID(ctx context.Context, userID uint64) (*model.UserTwoFactor, error)
+	Save(ctx context.Context, twoFactor *model.UserTwoFactor) error
+	Delete(ctx context.Context, userID uint64) error
+	UseCounter(ctx context.Context, userID uint64, counter int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint64, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint64, hash string, usedAt time.Time) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uint64) (int64, error)
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{
+		db: db,
+	}
+}
+
+func (r *twoFactorRepository) GetByUserID(ctx context.Context, userID uint64) (*model.UserTwoFactor, error) {
+	var twoFactor model.UserTwoFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&twoFactor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *twoFactorRepository) Save(ctx context.Context, twoFactor *model.UserTwoFactor) error {
+	return r.db.WithContext(ctx).Save(twoFactor).Error
+}
+
+// Delete 删除用户的 TOTP 配置及全部恢复码
+func (r *twoFactorRepository) Delete(ctx context.Context, userID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error
+
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&model.UserTwoFactor{}).Error
+	})
+}
+
+// UseCounter 原子地记录已使用的时间步，时间步不晚于上次使用的时间步时返回 false
+func (r *twoFactorRepository) UseCounter(ctx context.Context, userID uint64, counter int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.UserTwoFactor{}).
+		Where("user_id = ? AND last_counter < ?", userID, counter).
+		UpdateColumn("last_counter", counter)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint64, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error
+
+		if err != nil {
+			return err
+		}
+
+		if len(hashes) == 0 {
+			return nil
+		}
+
+		codes := make([]*model.UserRecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, &model.UserRecoveryCode{
+				UserID:   userID,
+				CodeHash: hash,
+			})
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode 原子地将未使用的恢复码标记为已使用，恢复码不存在或已使用时返回 false
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID uint64, hash string, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		UpdateColumn("used_at", usedAt)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes 统计用户未使用的恢复码数量
+func (r *twoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uint64) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
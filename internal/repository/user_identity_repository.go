@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ UserIdentityRepository = (*userIdentityRepository)(nil)
+
+type UserIdentityRepository interface {
+	GetByIssuerSubject(ctx context.Context, issuer string, subject string) (*model.UserIdentity, error)
+	CreateWithUser(ctx context.Context, user *model.User, identity *model.UserIdentity) error
+}
+
+type userIdentityRepository struct {
+	*BaseRepository[model.UserIdentity]
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{
+		BaseRepository: NewBaseRepository[model.UserIdentity](db),
+	}
+}
+
+func (r *userIdentityRepository) GetByIssuerSubject(ctx context.Context, issuer string, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.WithContext(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CreateWithUser 在同一事务中创建用户及其外部身份
+func (r *userIdentityRepository) CreateWithUser(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(user).Error
+
+		if err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+
+		return tx.Create(identity).Error
+	})
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 	"w2learn/internal/model"
+	"w2learn/pkg/def"
 
 	"gorm.io/gorm"
 )
@@ -12,8 +14,15 @@ var _ UserRepository = (*userRepository)(nil)
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uint64) (*model.User, error)
+	GetPlainByID(ctx context.Context, id uint64) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	UpdateRoles(ctx context.Context, id uint64, roles model.StringList) error
+	UpdateStatus(ctx context.Context, id uint64, status int8) error
+	MarkEmailVerified(ctx context.Context, id uint64, email string, verifiedAt time.Time) (bool, error)
+	CountByRole(ctx context.Context, role string) (int64, error)
 	Delete(ctx context.Context, id uint64) error
 	List(ctx context.Context, offset, limit int) ([]*model.User, error)
 }
@@ -37,6 +46,11 @@ func (r *userRepository) GetByID(ctx context.Context, id uint64) (*model.User, e
 	return &user, nil
 }
 
+// GetPlainByID 查询用户但不预加载习惯列表
+func (r *userRepository) GetPlainByID(ctx context.Context, id uint64) (*model.User, error) {
+	return r.BaseRepository.GetByID(ctx, id)
+}
+
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
 	err := r.db.WithContext(ctx).Preload("Habits").Where("username = ?", username).First(&user).Error
@@ -45,3 +59,70 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	}
 	return &user, nil
 }
+
+// UpdatePassword 更新口令摘要并清空旧版摘要使用的盐
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"password": password,
+			"salt":     "",
+		}).Error
+}
+
+func (r *userRepository) UpdateRoles(ctx context.Context, id uint64, roles model.StringList) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Update("roles", roles).Error
+}
+
+// CountByRole 统计拥有指定角色的用户数
+func (r *userRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("? = ANY(string_to_array(roles, ','))", role).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, id uint64, status int8) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// MarkEmailVerified 在用户邮箱仍为 email 时标记为已验证，待验证的用户随之激活
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint64, email string, verifiedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ? AND email = ?", id, email).
+		Updates(map[string]any{
+			"email_verified_at": verifiedAt,
+			"status":            gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", def.UserStatusPending, def.UserStatusActive),
+		})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"w2learn/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var _ UserSessionRepository = (*userSessionRepository)(nil)
+
+type UserSessionRepository interface {
+	Create(ctx context.Context, session *model.UserSession) error
+	GetBySessionID(ctx context.Context, id string) (*model.UserSession, error)
+	ListActiveByUser(ctx context.Context, userID uint64, now time.Time) ([]*model.UserSession, error)
+	Touch(ctx context.Context, id string, ip string, seenAt time.Time, expiresAt time.Time) error
+	Revoke(ctx context.Context, ids []string, revokedAt time.Time) error
+}
+
+type userSessionRepository struct {
+	*BaseRepository[model.UserSession]
+}
+
+func NewUserSessionRepository(db *gorm.DB) UserSessionRepository {
+	return &userSessionRepository{
+		BaseRepository: NewBaseRepository[model.UserSession](db),
+	}
+}
+
+func (r *userSessionRepository) GetBySessionID(ctx context.Context, id string) (*model.UserSession, error) {
+	var session model.UserSession
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveByUser 按创建时间升序返回用户未撤销且未过期的会话
+func (r *userSessionRepository) ListActiveByUser(ctx context.Context, userID uint64, now time.Time) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at ASC").
+		Find(&sessions).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch 在令牌轮换时更新会话的访问信息与过期时间
+func (r *userSessionRepository) Touch(ctx context.Context, id string, ip string, seenAt time.Time, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.UserSession{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{
+			"ip":           ip,
+			"last_seen_at": seenAt,
+			"expires_at":   expiresAt,
+			"updated_at":   seenAt,
+		}).Error
+}
+
+func (r *userSessionRepository) Revoke(ctx context.Context, ids []string, revokedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		UpdateColumn("revoked_at", revokedAt).Error
+}
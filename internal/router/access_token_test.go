@@ -0,0 +1,138 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"w2learn/internal/config"
+	"w2learn/internal/controller"
+	"w2learn/internal/dto"
+	"w2learn/internal/service"
+	"w2learn/pkg/def"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scopedTokens 按令牌返回个人访问令牌的权限范围，未登记的令牌视为无效
+type scopedTokens map[string][]string
+
+func (s scopedTokens) AuthenticateAccessToken(ctx context.Context, token string) (*dto.UserToken, []string, error) {
+	scopes, ok := s[token]
+
+	if !ok {
+		return nil, nil, service.ErrAccessTokenInvalid
+	}
+
+	return &dto.UserToken{UID: 7, Username: "alice", Status: def.UserStatusActive}, scopes, nil
+}
+
+// listingHabitService 只实现列出习惯，用于确认请求通过了鉴权与权限范围检查
+type listingHabitService struct {
+	service.HabitService
+	calls int
+}
+
+func (s *listingHabitService) ListHabits(ctx context.Context, uid uint64, req *dto.ListHabitsRequest) ([]*dto.HabitResponse, error) {
+	s.calls++
+	return []*dto.HabitResponse{}, nil
+}
+
+// newScopeTestRouter 使用完整的路由配置，不需要 Redis 的请求在访问 Redis 之前就已经得到结果
+func newScopeTestRouter(t *testing.T, habitService service.HabitService, tokens scopedTokens) *gin.Engine {
+	t.Helper()
+
+	r := SetupRouter(
+		&config.Config{},
+		nil,
+		controller.NewHealthController(nil),
+		controller.NewUserController(nil),
+		controller.NewHabitsController(habitService),
+		controller.NewAuthController(nil),
+		controller.NewTagController(nil),
+		controller.NewCategoryController(nil),
+		controller.NewReminderController(nil),
+		controller.NewNotificationController(nil),
+		controller.NewStatsController(nil),
+		controller.NewSessionController(nil),
+		controller.NewRoleController(nil),
+		controller.NewTwoFactorController(nil),
+		controller.NewPasswordController(nil),
+		controller.NewEmailController(nil),
+		controller.NewJWKSController(),
+		controller.NewAccessTokenController(nil),
+		controller.NewOIDCController(nil, false),
+		controller.NewAuditController(nil),
+		nil,
+		tokens,
+	)
+
+	gin.SetMode(gin.TestMode)
+
+	return r
+}
+
+func TestAccessTokenScopes(t *testing.T) {
+	const (
+		readOnly  = def.PersonalAccessTokenPrefix + "read-only"
+		readWrite = def.PersonalAccessTokenPrefix + "read-write"
+		noScopes  = def.PersonalAccessTokenPrefix + "no-scopes"
+		revoked   = def.PersonalAccessTokenPrefix + "revoked"
+	)
+
+	tokens := scopedTokens{
+		readOnly:  {def.ScopeHabitsRead},
+		readWrite: {def.ScopeHabitsRead, def.ScopeHabitsWrite, def.ScopeCheckinsWrite},
+		noScopes:  {},
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+		listed bool
+	}{
+		{"read scope lists habits", http.MethodGet, "/habit", readOnly, http.StatusOK, true},
+		{"read scope lists own habits", http.MethodGet, "/me/habits", readOnly, http.StatusOK, true},
+		{"no scopes", http.MethodGet, "/habit", noScopes, http.StatusForbidden, false},
+		{"read scope cannot create", http.MethodPost, "/habit", readOnly, http.StatusForbidden, false},
+		{"read scope cannot delete", http.MethodDelete, "/me/habits/1", readOnly, http.StatusForbidden, false},
+		{"read scope cannot check in", http.MethodPost, "/habit/1/checkins", readOnly, http.StatusForbidden, false},
+		{"read scope cannot add reminders", http.MethodPost, "/me/habits/1/reminders", readOnly, http.StatusForbidden, false},
+		{"unknown token", http.MethodGet, "/habit", revoked, http.StatusUnauthorized, false},
+		{"token management", http.MethodGet, "/token", readWrite, http.StatusForbidden, false},
+		{"token creation", http.MethodPost, "/token", readWrite, http.StatusForbidden, false},
+		{"token revocation", http.MethodDelete, "/token/1", readWrite, http.StatusForbidden, false},
+		{"own token listing", http.MethodGet, "/me/tokens", readWrite, http.StatusForbidden, false},
+		{"own token creation", http.MethodPost, "/me/tokens", readWrite, http.StatusForbidden, false},
+		{"own token revocation", http.MethodDelete, "/me/tokens/1", readWrite, http.StatusForbidden, false},
+		{"profile", http.MethodGet, "/me", readWrite, http.StatusForbidden, false},
+		{"sessions", http.MethodGet, "/session", readWrite, http.StatusForbidden, false},
+		{"password change", http.MethodPost, "/auth/password/change", readWrite, http.StatusForbidden, false},
+		{"two-factor", http.MethodPost, "/auth/2fa/disable", readWrite, http.StatusForbidden, false},
+		{"user admin", http.MethodGet, "/user", readWrite, http.StatusForbidden, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habitService := &listingHabitService{}
+			r := newScopeTestRouter(t, habitService, tokens)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("%s %s: status %d, want %d: %s", tt.method, tt.path, w.Code, tt.status, w.Body.String())
+			}
+
+			if listed := habitService.calls > 0; listed != tt.listed {
+				t.Fatalf("%s %s: habits listed = %v, want %v", tt.method, tt.path, listed, tt.listed)
+			}
+		})
+	}
+}
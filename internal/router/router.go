@@ -2,9 +2,11 @@ package router
 
 import (
 	"log"
+	"time"
 	"w2learn/internal/config"
 	"w2learn/internal/controller"
 	"w2learn/internal/middleware"
+	"w2learn/pkg/def"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -17,6 +19,22 @@ func SetupRouter(
 	userCtrl controller.UserController,
 	habitCtrl controller.HabitController,
 	authCtrl controller.AuthController,
+	tagCtrl controller.TagController,
+	categoryCtrl controller.CategoryController,
+	reminderCtrl controller.ReminderController,
+	notificationCtrl controller.NotificationController,
+	statsCtrl controller.StatsController,
+	sessionCtrl controller.SessionController,
+	roleCtrl controller.RoleController,
+	twoFactorCtrl controller.TwoFactorController,
+	passwordCtrl controller.PasswordController,
+	emailCtrl controller.EmailController,
+	jwksCtrl controller.JWKSController,
+	accessTokenCtrl controller.AccessTokenController,
+	oidcCtrl controller.OIDCController,
+	auditCtrl controller.AuditController,
+	permissionChecker middleware.PermissionChecker,
+	accessTokenAuthenticator middleware.AccessTokenAuthenticator,
 ) *gin.Engine {
 	if cfg == nil {
 		log.Fatal("config is nil")
@@ -24,7 +42,15 @@ func SetupRouter(
 	}
 
 	gin.SetMode(gin.ReleaseMode)
-	r := gin.New()
+	r, err := newEngine(&cfg.Server)
+
+	if err != nil {
+		log.Fatal("set trusted proxies err: ", err)
+		return nil
+	}
+
+	// 会话最近访问时间与刷新令牌的有效期一致
+	sessionSeen := middleware.SessionSeenTTL(time.Duration(cfg.Session.RefreshTokenTTL) * time.Second)
 
 	// 配置 Gin 中间件
 	r.Use(
@@ -32,38 +58,224 @@ func SetupRouter(
 		middleware.Logger(),
 	)
 
+	// 配置 /.well-known 路由，公开令牌验证公钥
+	r.GET("/.well-known/jwks.json", jwksCtrl.GetJWKS)
+
 	// 配置 /health 的路由
 	healthGroup := r.Group("/health")
 
 	healthGroup.GET("/", healthCtrl.HealthCheck)
 	healthGroup.GET("/:flag", healthCtrl.HealthCheckWithFlag)
 
-	// 配置 /user 路由
+	// 配置 /user 路由，仅管理员可用
+	userRead := middleware.RequirePermission(permissionChecker, def.PermissionUserRead)
+	userWrite := middleware.RequirePermission(permissionChecker, def.PermissionUserWrite)
+
 	userGroup := r.Group("/user")
-	userGroup.Use(middleware.JWTAuthMiddleware(rdb))
+	userGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	userGroup.GET("", userRead, userCtrl.ListUsers)
+	userGroup.POST("", userWrite, userCtrl.CreateUser)
+	userGroup.GET("/i/:id", userRead, userCtrl.GetUser)
+	userGroup.GET("/u/:username", userRead, userCtrl.GetUserByUsername)
+	userGroup.PUT("/:id", userWrite, userCtrl.UpdateUser)
+	userGroup.DELETE("/:id", userWrite, userCtrl.DeleteUser)
+	userGroup.POST("/:id/suspend", userWrite, userCtrl.SuspendUser)
+	userGroup.POST("/:id/reinstate", userWrite, userCtrl.ReinstateUser)
+	userGroup.POST("/:id/unlock", userWrite, userCtrl.UnlockUser)
+	userGroup.PUT("/:id/roles", middleware.RequirePermission(permissionChecker, def.PermissionRoleWrite), roleCtrl.SetUserRoles)
+
+	// 配置 /role 路由，仅管理员可用
+	roleWrite := middleware.RequirePermission(permissionChecker, def.PermissionRoleWrite)
+
+	roleGroup := r.Group("/role")
+	roleGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	roleGroup.GET("", middleware.RequirePermission(permissionChecker, def.PermissionRoleRead), roleCtrl.ListRoles)
+	roleGroup.POST("", roleWrite, roleCtrl.CreateRole)
+	roleGroup.PUT("/:id", roleWrite, roleCtrl.UpdateRole)
+	roleGroup.DELETE("/:id", roleWrite, roleCtrl.DeleteRole)
 
-	userGroup.GET("", userCtrl.ListUsers)
-	userGroup.POST("", userCtrl.CreateUser)
-	userGroup.GET("/i/:id", userCtrl.GetUser)
-	userGroup.GET("/u/:username", userCtrl.GetUserByUsername)
-	userGroup.PUT("/:id", userCtrl.UpdateUser)
-	userGroup.DELETE("/:id", userCtrl.DeleteUser)
+	// 配置 /admin 路由，审计记录只读
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	adminGroup.GET("/audit", middleware.RequirePermission(permissionChecker, def.PermissionAuditRead), auditCtrl.ListAuditLogs)
+
+	// 配置 /habit 路由，同时接受个人访问令牌，每个路由都需要声明权限范围
+	habitsRead := middleware.RequireScope(def.ScopeHabitsRead)
+	habitsWrite := middleware.RequireScope(def.ScopeHabitsWrite)
+	checkinsWrite := middleware.RequireScope(def.ScopeCheckinsWrite)
 
-	// 配置 /habit 路由
 	habitGroup := r.Group("/habit")
-	habitGroup.Use(middleware.JWTAuthMiddleware(rdb))
+	habitGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen, middleware.AcceptAccessTokens(accessTokenAuthenticator)))
+
+	habitGroup.GET("", habitsRead, habitCtrl.ListHabits)
+	habitGroup.POST("", habitsWrite, habitCtrl.CreateHabit)
+	habitGroup.GET("/due", habitsRead, habitCtrl.ListDueHabits)
+	habitGroup.GET("/:id", habitsRead, habitCtrl.GetHabit)
+	habitGroup.PUT("/:id", habitsWrite, habitCtrl.UpdateHabit)
+	habitGroup.DELETE("", habitsWrite, habitCtrl.DeleteHabit)
+	habitGroup.POST("/:id/checkins", checkinsWrite, habitCtrl.CreateCheckIn)
+	habitGroup.GET("/:id/checkins", habitsRead, habitCtrl.ListCheckIns)
+	habitGroup.DELETE("/:id/checkins/:day", checkinsWrite, habitCtrl.DeleteCheckIn)
+	habitGroup.POST("/:id/streak/rebuild", habitsWrite, habitCtrl.RebuildStreak)
+	habitGroup.POST("/:id/pause", habitsWrite, habitCtrl.PauseHabit)
+	habitGroup.POST("/:id/resume", habitsWrite, habitCtrl.ResumeHabit)
+	habitGroup.POST("/:id/archive", habitsWrite, habitCtrl.ArchiveHabit)
+	habitGroup.POST("/:id/unarchive", habitsWrite, habitCtrl.UnarchiveHabit)
+	habitGroup.GET("/:id/stats", habitsRead, statsCtrl.GetHabitStats)
+	habitGroup.GET("/:id/reminders", habitsRead, reminderCtrl.ListReminders)
+	habitGroup.POST("/:id/reminders", habitsWrite, reminderCtrl.CreateReminder)
+	habitGroup.PUT("/:id/reminders/:rid", habitsWrite, reminderCtrl.UpdateReminder)
+	habitGroup.DELETE("/:id/reminders/:rid", habitsWrite, reminderCtrl.DeleteReminder)
+	habitGroup.POST("/:id/reminders/:rid/snooze", habitsWrite, reminderCtrl.SnoozeReminder)
+
+	// 配置 /tag 路由
+	tagGroup := r.Group("/tag")
+	tagGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	tagGroup.GET("", tagCtrl.ListTags)
+	tagGroup.POST("", tagCtrl.CreateTag)
+	tagGroup.GET("/stats", tagCtrl.ListTagStats)
+	tagGroup.PUT("/:id", tagCtrl.UpdateTag)
+	tagGroup.DELETE("/:id", tagCtrl.DeleteTag)
+
+	// 配置 /category 路由
+	categoryGroup := r.Group("/category")
+	categoryGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	categoryGroup.GET("", categoryCtrl.ListCategories)
+	categoryGroup.POST("", categoryCtrl.CreateCategory)
+	categoryGroup.PUT("/:id", categoryCtrl.UpdateCategory)
+	categoryGroup.DELETE("/:id", categoryCtrl.DeleteCategory)
+
+	// 配置 /notification 路由
+	notificationGroup := r.Group("/notification")
+	notificationGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	notificationGroup.GET("", notificationCtrl.ListNotifications)
+	notificationGroup.POST("/read", notificationCtrl.ReadAllNotifications)
+	notificationGroup.POST("/:id/read", notificationCtrl.ReadNotification)
+
+	// 配置 /session 路由
+	sessionGroup := r.Group("/session")
+	sessionGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
 
-	habitGroup.GET("", habitCtrl.ListHabits)
-	habitGroup.POST("", habitCtrl.CreateHabit)
-	habitGroup.GET("/:id", habitCtrl.GetHabit)
-	habitGroup.PUT("/:id", habitCtrl.UpdateHabit)
-	habitGroup.DELETE("", habitCtrl.DeleteHabit)
+	sessionGroup.GET("", sessionCtrl.ListSessions)
+	sessionGroup.DELETE("", sessionCtrl.RevokeOtherSessions)
+	sessionGroup.DELETE("/:id", sessionCtrl.RevokeSession)
+
+	// 配置 /token 路由，管理个人访问令牌，只能使用 JWT 访问
+	tokenGroup := r.Group("/token")
+	tokenGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	tokenGroup.GET("", accessTokenCtrl.ListTokens)
+	tokenGroup.POST("", accessTokenCtrl.CreateToken)
+	tokenGroup.DELETE("/:id", accessTokenCtrl.RevokeToken)
+
+	// 配置 /me 路由，操作当前登录用户本人的资源，待验证用户只能查看自己的信息
+	r.GET("/me", middleware.JWTAuthMiddleware(rdb, sessionSeen, middleware.AllowPending()), userCtrl.GetCurrentUser)
+
+	meGroup := r.Group("/me")
+	meGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	meGroup.PUT("", userCtrl.UpdateCurrentUser)
+	meGroup.GET("/stats", statsCtrl.GetUserStats)
+	meGroup.GET("/tags", tagCtrl.ListTags)
+	meGroup.POST("/tags", tagCtrl.CreateTag)
+	meGroup.GET("/tags/stats", tagCtrl.ListTagStats)
+	meGroup.PUT("/tags/:id", tagCtrl.UpdateTag)
+	meGroup.DELETE("/tags/:id", tagCtrl.DeleteTag)
+	meGroup.GET("/categories", categoryCtrl.ListCategories)
+	meGroup.POST("/categories", categoryCtrl.CreateCategory)
+	meGroup.PUT("/categories/:id", categoryCtrl.UpdateCategory)
+	meGroup.DELETE("/categories/:id", categoryCtrl.DeleteCategory)
+	meGroup.GET("/notifications", notificationCtrl.ListNotifications)
+	meGroup.POST("/notifications/read", notificationCtrl.ReadAllNotifications)
+	meGroup.POST("/notifications/:id/read", notificationCtrl.ReadNotification)
+	meGroup.GET("/sessions", sessionCtrl.ListSessions)
+	meGroup.DELETE("/sessions", sessionCtrl.RevokeOtherSessions)
+	meGroup.DELETE("/sessions/:id", sessionCtrl.RevokeSession)
+	meGroup.GET("/tokens", accessTokenCtrl.ListTokens)
+	meGroup.POST("/tokens", accessTokenCtrl.CreateToken)
+	meGroup.DELETE("/tokens/:id", accessTokenCtrl.RevokeToken)
+
+	// 配置 /me/habits 路由，与 /habit 一样接受个人访问令牌
+	meHabitGroup := r.Group("/me/habits")
+	meHabitGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen, middleware.AcceptAccessTokens(accessTokenAuthenticator)))
+
+	meHabitGroup.GET("", habitsRead, habitCtrl.ListHabits)
+	meHabitGroup.POST("", habitsWrite, habitCtrl.CreateHabit)
+	meHabitGroup.GET("/due", habitsRead, habitCtrl.ListDueHabits)
+	meHabitGroup.GET("/:id", habitsRead, habitCtrl.GetHabit)
+	meHabitGroup.PUT("/:id", habitsWrite, habitCtrl.UpdateHabit)
+	meHabitGroup.DELETE("/:id", habitsWrite, habitCtrl.DeleteHabit)
+	meHabitGroup.POST("/:id/checkins", checkinsWrite, habitCtrl.CreateCheckIn)
+	meHabitGroup.GET("/:id/checkins", habitsRead, habitCtrl.ListCheckIns)
+	meHabitGroup.DELETE("/:id/checkins/:day", checkinsWrite, habitCtrl.DeleteCheckIn)
+	meHabitGroup.POST("/:id/streak/rebuild", habitsWrite, habitCtrl.RebuildStreak)
+	meHabitGroup.POST("/:id/pause", habitsWrite, habitCtrl.PauseHabit)
+	meHabitGroup.POST("/:id/resume", habitsWrite, habitCtrl.ResumeHabit)
+	meHabitGroup.POST("/:id/archive", habitsWrite, habitCtrl.ArchiveHabit)
+	meHabitGroup.POST("/:id/unarchive", habitsWrite, habitCtrl.UnarchiveHabit)
+	meHabitGroup.GET("/:id/stats", habitsRead, statsCtrl.GetHabitStats)
+	meHabitGroup.GET("/:id/reminders", habitsRead, reminderCtrl.ListReminders)
+	meHabitGroup.POST("/:id/reminders", habitsWrite, reminderCtrl.CreateReminder)
+	meHabitGroup.PUT("/:id/reminders/:rid", habitsWrite, reminderCtrl.UpdateReminder)
+	meHabitGroup.DELETE("/:id/reminders/:rid", habitsWrite, reminderCtrl.DeleteReminder)
+	meHabitGroup.POST("/:id/reminders/:rid/snooze", habitsWrite, reminderCtrl.SnoozeReminder)
 
 	// 配置 /auth 路由
 	authGroup := r.Group("/auth")
 	authGroup.POST("/register", authCtrl.Register)
 	authGroup.POST("/login", authCtrl.Login)
-	authGroup.POST("/logout", middleware.JWTAuthMiddleware(rdb), authCtrl.Logout)
+	authGroup.POST("/refresh", authCtrl.Refresh)
+	authGroup.POST("/logout", middleware.JWTAuthMiddleware(rdb, sessionSeen, middleware.AllowPending()), authCtrl.Logout)
+	authGroup.POST("/logout-all", middleware.JWTAuthMiddleware(rdb, sessionSeen, middleware.AllowPending()), authCtrl.LogoutAll)
+
+	// 配置 /auth/oidc 路由，通过外部身份提供方登录
+	oidcGroup := authGroup.Group("/oidc")
+	oidcGroup.GET("/login", oidcCtrl.Login)
+	oidcGroup.GET("/callback", oidcCtrl.Callback)
+
+	// 配置 /auth/email 路由，待验证用户可以重新发送验证邮件
+	emailGroup := authGroup.Group("/email")
+	emailGroup.POST("/verify", emailCtrl.VerifyEmail)
+	emailGroup.POST("/verify/resend", middleware.JWTAuthMiddleware(rdb, sessionSeen, middleware.AllowPending()), emailCtrl.ResendVerification)
+
+	// 配置 /auth/password 路由
+	passwordGroup := authGroup.Group("/password")
+	passwordGroup.POST("/change", middleware.JWTAuthMiddleware(rdb, sessionSeen, middleware.AllowPending()), passwordCtrl.ChangePassword)
+	passwordGroup.POST("/forgot", passwordCtrl.ForgotPassword)
+	passwordGroup.POST("/reset", passwordCtrl.ResetPassword)
+
+	// 配置 /auth/2fa 路由，verify 使用登录返回的挑战令牌，其余接口需要登录
+	twoFactorGroup := authGroup.Group("/2fa")
+	twoFactorGroup.POST("/verify", authCtrl.VerifyTwoFactor)
+
+	twoFactorAuthGroup := twoFactorGroup.Group("")
+	twoFactorAuthGroup.Use(middleware.JWTAuthMiddleware(rdb, sessionSeen))
+
+	twoFactorAuthGroup.GET("", twoFactorCtrl.GetStatus)
+	twoFactorAuthGroup.POST("/enroll", twoFactorCtrl.Enroll)
+	twoFactorAuthGroup.POST("/confirm", twoFactorCtrl.Confirm)
+	twoFactorAuthGroup.POST("/disable", twoFactorCtrl.Disable)
+	twoFactorAuthGroup.POST("/recovery-codes", twoFactorCtrl.RegenerateRecoveryCodes)
 
 	return r
 }
+
+// newEngine 创建 gin 引擎，只信任配置的代理转发的客户端地址，避免客户端伪造 X-Forwarded-For 绕过按 IP 的限流
+func newEngine(cfg *config.ServerConfig) (*gin.Engine, error) {
+	r := gin.New()
+	r.TrustedPlatform = cfg.TrustedPlatform
+
+	err := r.SetTrustedProxies(cfg.TrustedProxies)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
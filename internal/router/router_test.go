@@ -0,0 +1,180 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"w2learn/internal/config"
+	"w2learn/internal/controller"
+	"w2learn/internal/dto"
+	"w2learn/internal/middleware"
+	"w2learn/internal/model"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingAuthService 按客户端 IP 统计登录失败次数，模拟按 IP 的登录限流，并记录审计使用的操作者
+type failingAuthService struct {
+	failures map[string]int
+	actors   []*dto.Actor
+}
+
+func (s *failingAuthService) Register(ctx context.Context, req *dto.RegisterRequest, client *dto.ClientInfo) error {
+	return nil
+}
+
+func (s *failingAuthService) Login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	s.failures[client.IP]++
+	return nil, response.Unauthorized("invalid credentials")
+}
+
+func (s *failingAuthService) VerifyTwoFactor(ctx context.Context, req *dto.TwoFactorVerifyRequest, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	return nil, nil
+}
+
+func (s *failingAuthService) Refresh(ctx context.Context, req *dto.RefreshRequest, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	return nil, nil
+}
+
+func (s *failingAuthService) Logout(ctx context.Context, actor *dto.Actor, token *dto.UserToken, refreshToken string) error {
+	return nil
+}
+
+func (s *failingAuthService) LogoutAll(ctx context.Context, actor *dto.Actor) error {
+	s.actors = append(s.actors, actor)
+	return nil
+}
+
+func (s *failingAuthService) StartSession(ctx context.Context, user *model.User, device string, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	return nil, nil
+}
+
+func newLoginEngine(t *testing.T, cfg *config.ServerConfig, authService *failingAuthService) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r, err := newEngine(cfg)
+
+	if err != nil {
+		t.Fatalf("newEngine: %v", err)
+	}
+
+	r.POST("/auth/login", controller.NewAuthController(authService).Login)
+
+	return r
+}
+
+func login(r *gin.Engine, remoteAddr string, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice","password":"wrong"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestSpoofedForwardedForDoesNotResetIPCounter(t *testing.T) {
+	authService := &failingAuthService{failures: map[string]int{}}
+	r := newLoginEngine(t, &config.ServerConfig{}, authService)
+
+	spoofed := []string{"", "10.0.0.1", "198.51.100.7", "203.0.113.9, 10.0.0.2"}
+
+	for _, xff := range spoofed {
+		if code := login(r, "192.0.2.10:51234", xff); code != http.StatusUnauthorized {
+			t.Fatalf("login with X-Forwarded-For %q: status %d, want %d", xff, code, http.StatusUnauthorized)
+		}
+	}
+
+	if len(authService.failures) != 1 {
+		t.Fatalf("failures counted for %d IPs, want 1: %v", len(authService.failures), authService.failures)
+	}
+
+	if got := authService.failures["192.0.2.10"]; got != len(spoofed) {
+		t.Fatalf("failures for remote address = %d, want %d", got, len(spoofed))
+	}
+}
+
+func TestForwardedForFromTrustedProxy(t *testing.T) {
+	authService := &failingAuthService{failures: map[string]int{}}
+	r := newLoginEngine(t, &config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}}, authService)
+
+	login(r, "10.1.2.3:40000", "198.51.100.7")
+	login(r, "192.0.2.10:51234", "198.51.100.8")
+
+	if got := authService.failures["198.51.100.7"]; got != 1 {
+		t.Fatalf("failures for forwarded client = %d, want 1: %v", got, authService.failures)
+	}
+
+	if got := authService.failures["192.0.2.10"]; got != 1 {
+		t.Fatalf("failures for untrusted peer = %d, want 1: %v", got, authService.failures)
+	}
+
+	if _, ok := authService.failures["198.51.100.8"]; ok {
+		t.Fatalf("X-Forwarded-For from untrusted peer was used: %v", authService.failures)
+	}
+}
+
+func TestInvalidTrustedProxy(t *testing.T) {
+	_, err := newEngine(&config.ServerConfig{TrustedProxies: []string{"not-an-ip"}})
+
+	if err == nil {
+		t.Fatal("newEngine accepted an invalid trusted proxy")
+	}
+}
+
+func TestAuditActorIP(t *testing.T) {
+	trusted := &config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}}
+
+	tests := []struct {
+		name       string
+		cfg        *config.ServerConfig
+		remoteAddr string
+		headers    map[string]string
+		ip         string
+	}{
+		{"direct", &config.ServerConfig{}, "192.0.2.10:51234", nil, "192.0.2.10"},
+		{"spoofed forwarded for", &config.ServerConfig{}, "192.0.2.10:51234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "192.0.2.10"},
+		{"spoofed real ip", &config.ServerConfig{}, "192.0.2.10:51234", map[string]string{"X-Real-IP": "198.51.100.7"}, "192.0.2.10"},
+		{"untrusted peer with proxies configured", trusted, "192.0.2.10:51234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "192.0.2.10"},
+		{"trusted proxy", trusted, "10.1.2.3:40000", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "198.51.100.7"},
+		{"trusted proxy chain", trusted, "10.1.2.3:40000", map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.7, 10.0.0.5"}, "198.51.100.7"},
+		{"trusted proxy without header", trusted, "10.1.2.3:40000", nil, "10.1.2.3"},
+		{"spoofed platform header", &config.ServerConfig{}, "192.0.2.10:51234", map[string]string{"CF-Connecting-IP": "198.51.100.7"}, "192.0.2.10"},
+		{"trusted platform", &config.ServerConfig{TrustedPlatform: gin.PlatformCloudflare}, "192.0.2.10:51234", map[string]string{"CF-Connecting-IP": "198.51.100.7"}, "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := &failingAuthService{failures: map[string]int{}}
+			r := newLoginEngine(t, tt.cfg, authService)
+			// 以固定用户代替鉴权中间件
+			authenticated := func(c *gin.Context) {
+				c.Set(middleware.ContextKeyUserID, uint64(7))
+				c.Set(middleware.ContextKeyUserToken, &dto.UserToken{UID: 7, Username: "alice"})
+			}
+			r.POST("/auth/logout-all", authenticated, controller.NewAuthController(authService).LogoutAll)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/logout-all", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(authService.actors) != 1 || authService.actors[0].IP != tt.ip || authService.actors[0].UserID != 7 {
+				t.Fatalf("audit actors = %+v, want IP %s", authService.actors, tt.ip)
+			}
+		})
+	}
+}
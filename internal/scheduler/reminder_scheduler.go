@@ -0,0 +1,260 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/internal/notifier"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ReminderConfig struct {
+	Interval time.Duration
+	LockTTL  time.Duration
+}
+
+// ReminderScheduler 定期扫描提醒规则，按用户时区在提醒时刻投递消息
+// 多个实例同时运行时，通过 Redis 锁保证每条提醒每分钟只发送一次
+type ReminderScheduler struct {
+	config                  *ReminderConfig
+	habitReminderRepository repository.HabitReminderRepository
+	habitStreakRepository   repository.HabitStreakRepository
+	habitCheckInRepository  repository.HabitCheckInRepository
+	redisClient             *redis.Client
+	dispatcher              *notifier.Dispatcher
+
+	// last 为上一次已处理到的分钟（UTC）
+	last time.Time
+}
+
+func NewReminderScheduler(
+	config *ReminderConfig,
+	habitReminderRepository repository.HabitReminderRepository,
+	habitStreakRepository repository.HabitStreakRepository,
+	habitCheckInRepository repository.HabitCheckInRepository,
+	redisClient *redis.Client,
+	dispatcher *notifier.Dispatcher,
+) *ReminderScheduler {
+	if config.Interval <= 0 {
+		config.Interval = def.ReminderDefaultInterval * time.Second
+	}
+
+	if config.LockTTL <= 0 {
+		config.LockTTL = def.ReminderDefaultLockTTL * time.Second
+	}
+
+	return &ReminderScheduler{
+		config:                  config,
+		habitReminderRepository: habitReminderRepository,
+		habitStreakRepository:   habitStreakRepository,
+		habitCheckInRepository:  habitCheckInRepository,
+		redisClient:             redisClient,
+		dispatcher:              dispatcher,
+	}
+}
+
+// Run 阻塞运行调度循环，直到 ctx 被取消
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	s.Tick(ctx, time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Tick(ctx, now)
+		}
+	}
+}
+
+// Tick 处理上一次扫描之后到 now 为止的每一分钟，停顿过久时只补发最近的若干分钟
+func (s *ReminderScheduler) Tick(ctx context.Context, now time.Time) {
+	end := now.UTC().Truncate(time.Minute)
+	start := s.last
+
+	if start.IsZero() || end.Sub(start) > def.ReminderMaxCatchUpMinutes*time.Minute {
+		start = end.Add(-time.Minute)
+	}
+
+	if !end.After(start) {
+		return
+	}
+
+	reminders, err := s.habitReminderRepository.ListActive(ctx, end)
+
+	if err != nil {
+		logger.Error("habitReminderRepository.ListActive", zap.Error(err))
+		return
+	}
+
+	for _, reminder := range reminders {
+		for minute := start.Add(time.Minute); !minute.After(end); minute = minute.Add(time.Minute) {
+			if !s.matches(reminder, minute) {
+				continue
+			}
+
+			err = s.fire(ctx, reminder, minute)
+
+			if err != nil {
+				logger.Error("Failed to send habit reminder", zap.Error(err), zap.Uint64("reminder_id", reminder.ID))
+			}
+
+			break
+		}
+	}
+
+	s.last = end
+}
+
+// matches 判断提醒是否应在 minute 触发：到达稍后提醒时刻，或到达提醒时刻且当天需要提醒
+func (s *ReminderScheduler) matches(reminder *model.HabitReminder, minute time.Time) bool {
+	if reminder.Habit == nil || reminder.User == nil {
+		return false
+	}
+
+	if reminder.SnoozedUntil != nil && !reminder.SnoozedUntil.After(minute) {
+		return true
+	}
+
+	clock := utils.UserClock(reminder.User)
+	local := minute.In(clock.Location)
+
+	if !resumed(reminder.Habit, clock.DayOf(minute)) {
+		return false
+	}
+
+	if !reminder.Times.Has(local.Hour()*60 + local.Minute()) {
+		return false
+	}
+
+	return reminder.Weekdays == 0 || reminder.Weekdays.Has(clock.DayOf(minute).Weekday())
+}
+
+// resumed 判断习惯在 day 是否处于可提醒状态：定时暂停的习惯到达恢复日期即视为已恢复
+func resumed(habit *model.Habit, day time.Time) bool {
+	if habit.Status != def.HabitStatusPaused {
+		return true
+	}
+
+	return habit.PausedUntil != nil && !day.Before(*habit.PausedUntil)
+}
+
+func (s *ReminderScheduler) fire(ctx context.Context, reminder *model.HabitReminder, minute time.Time) error {
+	day := utils.UserClock(reminder.User).DayOf(minute)
+
+	pending, err := s.isPending(ctx, reminder, day)
+
+	if err != nil {
+		return err
+	}
+
+	if !pending {
+		if reminder.SnoozedUntil == nil {
+			return nil
+		}
+
+		return s.habitReminderRepository.ClearSnooze(ctx, reminder.ID, minute)
+	}
+
+	locked, err := s.lock(ctx, reminder, minute)
+
+	if err != nil || !locked {
+		return err
+	}
+
+	habit := reminder.Habit
+
+	msg := &notifier.Message{
+		UserID:     reminder.UserID,
+		HabitID:    habit.ID,
+		ReminderID: reminder.ID,
+		To:         reminder.Target,
+		Title:      fmt.Sprintf("Reminder: %s", habit.Name),
+		Body:       s.body(habit),
+		SentAt:     minute,
+	}
+
+	err = s.dispatcher.Notify(ctx, reminder.Channel, msg)
+
+	if err != nil {
+		return err
+	}
+
+	return s.habitReminderRepository.MarkFired(ctx, reminder.ID, minute)
+}
+
+// isPending 判断习惯在 day 是否仍未完成：按计划提醒时要求当天需要打卡，按星期提醒时要求当天尚未完成打卡
+func (s *ReminderScheduler) isPending(ctx context.Context, reminder *model.HabitReminder, day time.Time) (bool, error) {
+	habit := reminder.Habit
+
+	schedule, err := utils.ScheduleOf(habit)
+
+	if err != nil {
+		return false, err
+	}
+
+	start, end, ok := schedule.Period(day)
+
+	if ok && utils.IsPaused(schedule, start, end) {
+		return false, nil
+	}
+
+	if reminder.Weekdays == 0 {
+		streak, err := s.habitStreakRepository.GetByHabitID(ctx, habit.ID)
+
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+
+		return utils.IsDue(streak, schedule, day), nil
+	}
+
+	checkIn, err := s.habitCheckInRepository.GetByHabitAndDay(ctx, habit.ID, day)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	return checkIn == nil || !checkIn.IsCompleted(), nil
+}
+
+// lock 抢占提醒在 minute 的发送权，未配置 Redis 时直接放行
+func (s *ReminderScheduler) lock(ctx context.Context, reminder *model.HabitReminder, minute time.Time) (bool, error) {
+	if s.redisClient == nil {
+		return true, nil
+	}
+
+	key := fmt.Sprintf(def.ReminderLockKeyLayout, reminder.ID, minute.Unix()/60)
+
+	return s.redisClient.SetNX(ctx, key, 1, s.config.LockTTL).Result()
+}
+
+func (s *ReminderScheduler) body(habit *model.Habit) string {
+	var b strings.Builder
+
+	b.WriteString(habit.Name)
+
+	if habit.Info != "" {
+		b.WriteString(": ")
+		b.WriteString(habit.Info)
+	}
+
+	if habit.Target.Value > 0 {
+		fmt.Fprintf(&b, "\nTarget: %g %s", habit.Target.Value, habit.Target.Unit)
+	}
+
+	return b.String()
+}
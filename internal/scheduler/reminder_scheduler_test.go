@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+)
+
+func TestMatchesTimedPause(t *testing.T) {
+	resumeDay := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		status      string
+		pausedUntil *time.Time
+		minute      time.Time
+		want        bool
+	}{
+		{"active", def.HabitStatusActive, nil, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), true},
+		{"paused before resume day", def.HabitStatusPaused, &resumeDay, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), false},
+		{"paused on resume day", def.HabitStatusPaused, &resumeDay, time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), true},
+		{"paused after resume day", def.HabitStatusPaused, &resumeDay, time.Date(2026, 3, 12, 8, 0, 0, 0, time.UTC), true},
+		{"paused without resume day", def.HabitStatusPaused, nil, time.Date(2026, 3, 12, 8, 0, 0, 0, time.UTC), false},
+	}
+
+	s := &ReminderScheduler{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminder := &model.HabitReminder{
+				Times: model.ReminderTimes{8 * 60},
+				Habit: &model.Habit{Status: tt.status, PausedUntil: tt.pausedUntil},
+				User:  &model.User{Timezone: "UTC"},
+			}
+
+			if got := s.matches(reminder, tt.minute); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ AccessTokenService = (*accessTokenService)(nil)
+
+var (
+	ErrAccessTokenInvalid  = response.Unauthorized("invalid or expired access token")
+	ErrAccessTokenNotFound = response.NotFound("access token not found")
+)
+
+// AccessTokenService 管理个人访问令牌，并为认证中间件校验令牌
+type AccessTokenService interface {
+	CreateToken(ctx context.Context, actor *dto.Actor, req *dto.CreateAccessTokenRequest) (*dto.AccessTokenCreatedResponse, error)
+	ListTokens(ctx context.Context, uid uint64) ([]*dto.AccessTokenResponse, error)
+	RevokeToken(ctx context.Context, actor *dto.Actor, id uint64) error
+	AuthenticateAccessToken(ctx context.Context, token string) (*dto.UserToken, []string, error)
+}
+
+type accessTokenService struct {
+	userRepository                repository.UserRepository
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository
+	auditLogRepository            repository.AuditLogRepository
+}
+
+func NewAccessTokenService(
+	userRepository repository.UserRepository,
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository,
+	auditLogRepository repository.AuditLogRepository,
+) AccessTokenService {
+	return &accessTokenService{
+		userRepository:                userRepository,
+		personalAccessTokenRepository: personalAccessTokenRepository,
+		auditLogRepository:            auditLogRepository,
+	}
+}
+
+// CreateToken 创建个人访问令牌，完整令牌只在创建时返回一次
+func (s *accessTokenService) CreateToken(ctx context.Context, actor *dto.Actor, req *dto.CreateAccessTokenRequest) (*dto.AccessTokenCreatedResponse, error) {
+	if actor == nil || req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	token, err := s.createToken(ctx, actor.UserID, req)
+
+	var id uint64
+	if token != nil {
+		id = token.ID
+	}
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionTokenCreate, id, err)
+
+	return token, err
+}
+
+func (s *accessTokenService) createToken(ctx context.Context, uid uint64, req *dto.CreateAccessTokenRequest) (*dto.AccessTokenCreatedResponse, error) {
+	name := strings.TrimSpace(req.Name)
+
+	if name == "" {
+		return nil, response.Validation("name is empty")
+	}
+
+	if req.ExpiresInDays <= 0 || req.ExpiresInDays > def.PersonalAccessTokenMaxTTLDays {
+		return nil, response.Validation("invalid token expiry")
+	}
+
+	scopes := make(model.StringList, 0, len(req.Scopes))
+
+	for _, scope := range req.Scopes {
+		if !slices.Contains(def.Scopes, scope) {
+			return nil, response.Validation("unknown scope: " + scope)
+		}
+
+		if !scopes.Has(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	now := time.Now().UTC()
+
+	count, err := s.personalAccessTokenRepository.CountActiveByUser(ctx, uid, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= def.PersonalAccessTokenMaxPerUser {
+		return nil, response.Conflict("too many access tokens")
+	}
+
+	secret, err := utils.GenerateOpaqueToken(def.PersonalAccessTokenBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw := def.PersonalAccessTokenPrefix + secret
+
+	token := &model.PersonalAccessToken{
+		UserID:    uid,
+		Name:      name,
+		Prefix:    raw[:def.PersonalAccessTokenDisplayLen],
+		Hash:      utils.HashToken(raw),
+		Scopes:    scopes,
+		ExpiresAt: now.AddDate(0, 0, req.ExpiresInDays),
+	}
+
+	err = s.personalAccessTokenRepository.Create(ctx, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Personal access token created",
+		zap.Uint64("user_id", uid),
+		zap.Uint64("token_id", token.ID),
+		zap.Strings("scopes", scopes),
+	)
+
+	return &dto.AccessTokenCreatedResponse{
+		AccessTokenResponse: toAccessTokenResponse(token),
+		Token:               raw,
+	}, nil
+}
+
+func (s *accessTokenService) ListTokens(ctx context.Context, uid uint64) ([]*dto.AccessTokenResponse, error) {
+	tokens, err := s.personalAccessTokenRepository.ListActiveByUser(ctx, uid, time.Now().UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.AccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, toAccessTokenResponse(token))
+	}
+
+	return result, nil
+}
+
+func (s *accessTokenService) RevokeToken(ctx context.Context, actor *dto.Actor, id uint64) error {
+	if actor == nil {
+		return response.BadRequest("req is nil")
+	}
+
+	err := s.revokeToken(ctx, actor.UserID, id)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionTokenRevoke, id, err)
+
+	return err
+}
+
+func (s *accessTokenService) revokeToken(ctx context.Context, uid uint64, id uint64) error {
+	ok, err := s.personalAccessTokenRepository.Revoke(ctx, uid, id, time.Now().UTC())
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrAccessTokenNotFound
+	}
+
+	logger.Info("Personal access token revoked", zap.Uint64("user_id", uid), zap.Uint64("token_id", id))
+
+	return nil
+}
+
+// AuthenticateAccessToken 校验个人访问令牌，返回令牌所属用户的身份与令牌的权限范围
+func (s *accessTokenService) AuthenticateAccessToken(ctx context.Context, raw string) (*dto.UserToken, []string, error) {
+	token, err := s.personalAccessTokenRepository.GetByHash(ctx, utils.HashToken(raw))
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrAccessTokenInvalid
+	}
+
+	if err != nil {
+		logger.Error("personalAccessTokenRepository.GetByHash", zap.Error(err))
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+
+	if token.RevokedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, nil, ErrAccessTokenInvalid
+	}
+
+	user, err := s.userRepository.GetPlainByID(ctx, token.UserID)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrAccessTokenInvalid
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 降低写入频率，更新失败不影响本次请求
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= def.PersonalAccessTokenTouchInterval*time.Second {
+		err = s.personalAccessTokenRepository.Touch(ctx, token.ID, now)
+
+		if err != nil {
+			logger.Warn("Failed to touch personal access token", zap.Error(err), zap.Uint64("token_id", token.ID))
+		}
+	}
+
+	return &dto.UserToken{
+		UID:      user.ID,
+		Username: user.Username,
+		Roles:    user.Roles,
+		Status:   user.Status,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(token.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+		},
+	}, token.Scopes, nil
+}
+
+func toAccessTokenResponse(token *model.PersonalAccessToken) *dto.AccessTokenResponse {
+	return &dto.AccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+var _ AuditService = (*auditService)(nil)
+
+// AuditService 供管理员查询安全审计记录
+type AuditService interface {
+	ListAuditLogs(ctx context.Context, req *dto.ListAuditLogsRequest) (*dto.AuditLogListResponse, error)
+}
+
+type auditService struct {
+	auditLogRepository repository.AuditLogRepository
+}
+
+func NewAuditService(auditLogRepository repository.AuditLogRepository) AuditService {
+	return &auditService{
+		auditLogRepository: auditLogRepository,
+	}
+}
+
+func (s *auditService) ListAuditLogs(ctx context.Context, req *dto.ListAuditLogsRequest) (*dto.AuditLogListResponse, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > def.AuditPageSizeMax {
+		pageSize = def.AuditPageSizeMax
+	}
+
+	logs, total, err := s.auditLogRepository.List(ctx, &repository.AuditLogFilter{
+		ActorID:  req.ActorID,
+		TargetID: req.TargetID,
+		Action:   req.Action,
+		Result:   req.Result,
+		IP:       req.IP,
+		From:     req.From,
+		To:       req.To,
+	}, (page-1)*pageSize, pageSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AuditLogListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    logs,
+	}, nil
+}
+
+// recordAudit 写入一条审计记录，cause 为 nil 表示操作成功，否则记录为失败并保存原因
+// 写入失败只记录日志，不影响操作本身
+func recordAudit(
+	ctx context.Context,
+	auditLogRepository repository.AuditLogRepository,
+	actor *dto.Actor,
+	action string,
+	targetID uint64,
+	cause error,
+) {
+	entry := &model.AuditLog{
+		Action: action,
+		Result: def.AuditResultSuccess,
+	}
+
+	if actor != nil {
+		if actor.UserID != 0 {
+			entry.ActorID = &actor.UserID
+		}
+
+		entry.ActorName = truncateRunes(actor.Username, def.AuditActorNameMaxLen)
+		entry.IP = actor.IP
+		entry.UserAgent = truncateRunes(actor.UserAgent, def.SessionUserAgentMaxLen)
+	}
+
+	if targetID != 0 {
+		entry.TargetID = &targetID
+	}
+
+	if cause != nil {
+		entry.Result = def.AuditResultFailure
+		entry.Reason = truncateRunes(cause.Error(), def.AuditReasonMaxLen)
+	}
+
+	err := auditLogRepository.Create(context.WithoutCancel(ctx), entry)
+
+	if err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err), zap.String("action", action))
+	}
+}
+
+// clientActor 根据客户端信息构造操作者，用于登录前或未经访问令牌认证的请求
+func clientActor(client *dto.ClientInfo, uid uint64, username string) *dto.Actor {
+	return &dto.Actor{
+		UserID:    uid,
+		Username:  username,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	}
+}
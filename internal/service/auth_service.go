@@ -5,10 +5,13 @@ import (
 	"errors"
 	"time"
 	"w2learn/internal/dto"
+	"w2learn/internal/mailer"
 	"w2learn/internal/model"
 	"w2learn/internal/repository"
 	"w2learn/internal/utils"
+	"w2learn/pkg/def"
 	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -19,58 +22,193 @@ import (
 
 var _ AuthService = (*authService)(nil)
 
+var (
+	// ErrRefreshTokenInvalid 表示刷新令牌不存在、已过期或所属令牌族已作废
+	ErrRefreshTokenInvalid = response.Unauthorized("invalid refresh token")
+	// ErrRefreshTokenReused 表示已轮换的刷新令牌被再次使用，整个令牌族随之作废
+	ErrRefreshTokenReused = response.Unauthorized("refresh token reused, session revoked")
+	// ErrTwoFactorChallengeInvalid 表示二次验证挑战不存在、已过期或失败次数过多
+	ErrTwoFactorChallengeInvalid = response.Unauthorized("invalid or expired two-factor challenge")
+	// ErrInvalidCredentials 表示用户名不存在或口令错误，两种情况返回相同的错误避免枚举用户名
+	ErrInvalidCredentials = response.Unauthorized("invalid credentials")
+)
+
+// AuthConfig 为访问令牌与刷新令牌的有效期，MaxSessions 为用户未设置时的并发会话数上限
+// TwoFactorChallengeTTL 为登录二次验证挑战的有效期，LoginThrottle 为登录失败的限流参数
+type AuthConfig struct {
+	AccessTokenTTL        time.Duration
+	RefreshTokenTTL       time.Duration
+	MaxSessions           int
+	TwoFactorChallengeTTL time.Duration
+	LoginThrottle         LoginThrottleConfig
+}
+
 type AuthService interface {
-	Register(ctx context.Context, req *dto.RegisterRequest) error
-	Login(ctx context.Context, req *dto.LoginRequest) (string, error)
-	Logout(ctx context.Context, tokenId string) error
+	Register(ctx context.Context, req *dto.RegisterRequest, client *dto.ClientInfo) error
+	Login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, error)
+	VerifyTwoFactor(ctx context.Context, req *dto.TwoFactorVerifyRequest, client *dto.ClientInfo) (*dto.TokenResponse, error)
+	Refresh(ctx context.Context, req *dto.RefreshRequest, client *dto.ClientInfo) (*dto.TokenResponse, error)
+	Logout(ctx context.Context, actor *dto.Actor, token *dto.UserToken, refreshToken string) error
+	LogoutAll(ctx context.Context, actor *dto.Actor) error
+	StartSession(ctx context.Context, user *model.User, device string, client *dto.ClientInfo) (*dto.LoginResponse, error)
 }
 
 type authService struct {
-	userRepository repository.UserRepository
-	redisClient    *redis.Client
+	config                        *AuthConfig
+	userRepository                repository.UserRepository
+	refreshTokenRepository        repository.RefreshTokenRepository
+	userSessionRepository         repository.UserSessionRepository
+	twoFactorRepository           repository.TwoFactorRepository
+	twoFactorChallengeRepository  repository.TwoFactorChallengeRepository
+	emailVerificationRepository   repository.EmailVerificationRepository
+	loginAttemptRepository        repository.LoginAttemptRepository
+	auditLogRepository            repository.AuditLogRepository
+	emailConfig                   *EmailConfig
+	passwordHasher                utils.PasswordHasher
+	secretCipher                  *utils.SecretCipher
+	mailer                        mailer.Mailer
+	tokenRevocationRepository     repository.TokenRevocationRepository
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository
+	dummyPasswordHash             string
 }
 
-func NewAuthService(userRepository repository.UserRepository, redisClient *redis.Client) AuthService {
+func NewAuthService(
+	config *AuthConfig,
+	userRepository repository.UserRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	userSessionRepository repository.UserSessionRepository,
+	twoFactorRepository repository.TwoFactorRepository,
+	twoFactorChallengeRepository repository.TwoFactorChallengeRepository,
+	emailVerificationRepository repository.EmailVerificationRepository,
+	loginAttemptRepository repository.LoginAttemptRepository,
+	auditLogRepository repository.AuditLogRepository,
+	emailConfig *EmailConfig,
+	passwordHasher utils.PasswordHasher,
+	secretCipher *utils.SecretCipher,
+	mailer mailer.Mailer,
+	tokenRevocationRepository repository.TokenRevocationRepository,
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository,
+) AuthService {
+	if config.AccessTokenTTL <= 0 {
+		config.AccessTokenTTL = def.AccessTokenDefaultTTL * time.Second
+	}
+
+	if config.RefreshTokenTTL <= 0 {
+		config.RefreshTokenTTL = def.RefreshTokenDefaultTTL * time.Second
+	}
+
+	if config.MaxSessions <= 0 {
+		config.MaxSessions = def.SessionDefaultMax
+	}
+
+	if config.TwoFactorChallengeTTL <= 0 {
+		config.TwoFactorChallengeTTL = def.TwoFactorChallengeTTL * time.Second
+	}
+
+	config.LoginThrottle.setDefaults()
+
+	// 用户名不存在时校验该摘要，使响应时间与口令错误时一致
+	dummyPasswordHash, err := passwordHasher.Hash(uuid.NewString())
+
+	if err != nil {
+		logger.Error("Failed to hash dummy password", zap.Error(err))
+	}
+
 	return &authService{
-		userRepository: userRepository,
-		redisClient:    redisClient,
+		config:                        config,
+		userRepository:                userRepository,
+		refreshTokenRepository:        refreshTokenRepository,
+		userSessionRepository:         userSessionRepository,
+		twoFactorRepository:           twoFactorRepository,
+		twoFactorChallengeRepository:  twoFactorChallengeRepository,
+		emailVerificationRepository:   emailVerificationRepository,
+		loginAttemptRepository:        loginAttemptRepository,
+		auditLogRepository:            auditLogRepository,
+		emailConfig:                   emailConfig,
+		passwordHasher:                passwordHasher,
+		secretCipher:                  secretCipher,
+		mailer:                        mailer,
+		tokenRevocationRepository:     tokenRevocationRepository,
+		personalAccessTokenRepository: personalAccessTokenRepository,
+		dummyPasswordHash:             dummyPasswordHash,
 	}
 }
 
-func (s *authService) Register(ctx context.Context, req *dto.RegisterRequest) error {
-	if req == nil {
-		return errors.New("request is nil")
+func (s *authService) Register(ctx context.Context, req *dto.RegisterRequest, client *dto.ClientInfo) error {
+	if req == nil || client == nil {
+		return response.BadRequest("request is nil")
+	}
+
+	user, err := s.register(ctx, req)
+
+	var uid uint64
+	if user != nil {
+		uid = user.ID
 	}
 
+	recordAudit(ctx, s.auditLogRepository, clientActor(client, uid, req.Username), def.AuditActionRegister, uid, err)
+
+	return err
+}
+
+func (s *authService) register(ctx context.Context, req *dto.RegisterRequest) (*model.User, error) {
 	user, err := s.userRepository.GetByUsername(ctx, req.Username)
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		logger.Error("Failed to query user", zap.Error(err), zap.String("username", req.Username))
-		return err
+		return nil, err
 	}
 
 	if user != nil {
-		return errors.New("user already exists")
+		return nil, response.Conflict("user already exists")
 	}
 
-	salt, err := utils.GenerateStringSalt(16)
+	email, err := checkEmail(ctx, s.userRepository, req.Email, 0)
 
-	if err != nil || salt == "" {
-		return errors.New("failed to generate salt")
+	if err != nil {
+		return nil, err
+	}
+
+	// 要求验证邮箱时，账户在验证前处于待验证状态
+	status := def.UserStatusActive
+
+	if s.emailConfig.RequireVerification {
+		if email == nil {
+			return nil, response.Validation("email is required")
+		}
+
+		status = def.UserStatusPending
+	}
+
+	password, err := s.passwordHasher.Hash(req.Password)
+
+	if err != nil {
+		logger.Error("passwordHasher.Hash", zap.Error(err))
+		return nil, errors.New("failed to hash password")
 	}
 
 	user = &model.User{
 		Username: req.Username,
-		Password: utils.HashString(req.Password, salt),
-		Salt:     salt,
-		Status:   0,
+		Email:    email,
+		Password: password,
+		Status:   status,
+		Timezone: req.Timezone,
 		Habits:   nil,
 	}
 
 	err = s.userRepository.Create(ctx, user)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// 验证邮件发送失败不影响注册，用户可以稍后重新发送
+	if user.Email != nil {
+		err = sendVerificationMail(ctx, s.emailConfig, s.emailVerificationRepository, s.mailer, user)
+
+		if err != nil {
+			logger.Error("Failed to send verification mail", zap.Error(err), zap.Uint64("user_id", user.ID))
+		}
 	}
 
 	logger.Info("User created successfully",
@@ -78,53 +216,591 @@ func (s *authService) Register(ctx context.Context, req *dto.RegisterRequest) er
 		zap.Uint64("user_id", user.ID),
 	)
 
-	return nil
+	return user, nil
 }
 
-func (s *authService) Login(ctx context.Context, req *dto.LoginRequest) (string, error) {
-	if req == nil {
-		return "", errors.New("request is nil")
+// Login 校验口令，用户开启二次验证时返回挑战令牌，否则直接签发令牌对
+// 用户名或 IP 连续失败过多时返回 LoginThrottledError
+func (s *authService) Login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	if req == nil || client == nil {
+		return nil, response.BadRequest("request is nil")
+	}
+
+	resp, user, err := s.login(ctx, req, client)
+
+	var uid uint64
+	if user != nil {
+		uid = user.ID
+	}
+
+	// 需要二次验证时口令校验已通过，最终结果由 auth.2fa 记录
+	recordAudit(ctx, s.auditLogRepository, clientActor(client, uid, req.Username), def.AuditActionLogin, uid, err)
+
+	return resp, err
+}
+
+func (s *authService) login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, *model.User, error) {
+	err := checkLoginThrottle(ctx, s.loginAttemptRepository, req.Username, client.IP)
+
+	if err != nil {
+		return nil, nil, err
 	}
 
 	user, err := s.userRepository.GetByUsername(ctx, req.Username)
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		logger.Error("Failed to query user", zap.Error(err), zap.String("username", req.Username))
-		return "", err
+		return nil, nil, err
 	}
 
+	// 不存在的用户名同样校验口令并计入失败次数，避免通过错误信息、响应时间或限流差异枚举用户名
 	if user == nil {
-		return "", errors.New("user not found")
+		s.verifyDummyPassword(req.Password)
+		recordLoginFailure(ctx, &s.config.LoginThrottle, s.loginAttemptRepository, req.Username, client.IP)
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	ok, needsRehash, err := verifyPassword(s.passwordHasher, user, req.Password)
+
+	if err != nil {
+		logger.Error("Failed to verify password", zap.Error(err), zap.Uint64("user_id", user.ID))
+		return nil, user, err
+	}
+
+	if !ok {
+		recordLoginFailure(ctx, &s.config.LoginThrottle, s.loginAttemptRepository, req.Username, client.IP)
+		return nil, user, ErrInvalidCredentials
+	}
+
+	// 口令正确后再检查状态，避免泄露账户是否被停用
+	err = checkUserStatus(user)
+
+	if err != nil {
+		return nil, user, err
+	}
+
+	// 旧版摘要或成本参数已调整的摘要在登录成功后透明升级，失败时不影响本次登录
+	if needsRehash {
+		s.rehashPassword(ctx, user, req.Password)
+	}
+
+	twoFactor, err := s.twoFactorRepository.GetByUserID(ctx, user.ID)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("twoFactorRepository.GetByUserID", zap.Error(err))
+		return nil, user, err
 	}
 
-	if !utils.VerifyString(req.Password, user.Salt, user.Password) {
-		return "", errors.New("invalid password")
+	// 需要二次验证时失败计数保留到验证码通过为止，否则知道口令即可反复获取新的挑战猜测验证码
+	if twoFactor != nil && twoFactor.Enabled {
+		resp, err := s.createChallenge(ctx, user, req.Device, client)
+		return resp, user, err
+	}
+
+	s.resetLoginFailures(ctx, req.Username)
+
+	token, err := s.startSession(ctx, user, req.Device, client)
+
+	if err != nil {
+		return nil, user, err
+	}
+
+	return &dto.LoginResponse{
+		TokenResponse: token,
+	}, user, nil
+}
+
+// VerifyTwoFactor 使用验证码或恢复码兑换登录挑战，挑战只能成功兑换一次
+func (s *authService) VerifyTwoFactor(ctx context.Context, req *dto.TwoFactorVerifyRequest, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	if req == nil || client == nil {
+		return nil, response.BadRequest("request is nil")
+	}
+
+	challenge, err := s.twoFactorChallengeRepository.GetByHash(ctx, utils.HashToken(req.ChallengeToken))
+
+	if errors.Is(err, redis.Nil) {
+		err = ErrTwoFactorChallengeInvalid
+	}
+
+	if err != nil {
+		if !errors.Is(err, ErrTwoFactorChallengeInvalid) {
+			logger.Error("twoFactorChallengeRepository.GetByHash", zap.Error(err))
+		}
+
+		recordAudit(ctx, s.auditLogRepository, clientActor(client, 0, ""), def.AuditActionTwoFactor, 0, err)
+		return nil, err
+	}
+
+	token, err := s.verifyTwoFactor(ctx, req, challenge, client)
+
+	recordAudit(ctx, s.auditLogRepository, clientActor(client, challenge.UserID, ""), def.AuditActionTwoFactor, challenge.UserID, err)
+
+	return token, err
+}
+
+// verifyTwoFactor 校验登录挑战的验证码，验证码错误与口令错误一样计入用户名与 IP 的登录失败次数
+func (s *authService) verifyTwoFactor(ctx context.Context, req *dto.TwoFactorVerifyRequest, challenge *model.TwoFactorChallenge, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	user, err := s.userRepository.GetPlainByID(ctx, challenge.UserID)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_, _ = s.twoFactorChallengeRepository.Delete(ctx, challenge.Hash)
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	if err != nil {
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
+		return nil, err
+	}
+
+	err = checkLoginThrottle(ctx, s.loginAttemptRepository, user.Username, client.IP)
+
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.twoFactorRepository.GetByUserID(ctx, challenge.UserID)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("twoFactorRepository.GetByUserID", zap.Error(err))
+		return nil, err
+	}
+
+	if twoFactor == nil || !twoFactor.Enabled {
+		_, _ = s.twoFactorChallengeRepository.Delete(ctx, challenge.Hash)
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	ok, err := verifyTwoFactorCode(ctx, s.twoFactorRepository, s.secretCipher, twoFactor, req.Code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		recordLoginFailure(ctx, &s.config.LoginThrottle, s.loginAttemptRepository, user.Username, client.IP)
+
+		attempts, err := s.twoFactorChallengeRepository.IncrAttempts(ctx, challenge)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// 失败次数过多时作废挑战，需要重新输入口令
+		if attempts >= def.TwoFactorChallengeMaxAttempts {
+			_, _ = s.twoFactorChallengeRepository.Delete(ctx, challenge.Hash)
+
+			logger.Warn("Two-factor challenge exceeded max attempts", zap.Uint64("user_id", challenge.UserID))
+		}
+
+		return nil, ErrTwoFactorCodeInvalid
+	}
+
+	deleted, err := s.twoFactorChallengeRepository.Delete(ctx, challenge.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !deleted {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	s.resetLoginFailures(ctx, user.Username)
+
+	err = checkUserStatus(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user, challenge.Device, &dto.ClientInfo{
+		IP:        challenge.IP,
+		UserAgent: challenge.UserAgent,
+	})
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧的刷新令牌随即失效
+// 已失效的刷新令牌被再次使用时视为泄露，作废其所在的整个令牌族
+func (s *authService) Refresh(ctx context.Context, req *dto.RefreshRequest, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	if req == nil || client == nil {
+		return nil, response.BadRequest("request is nil")
+	}
+
+	token, err := s.refreshTokenRepository.GetByHash(ctx, utils.HashToken(req.RefreshToken))
+
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if err != nil {
+		logger.Error("refreshTokenRepository.GetByHash", zap.Error(err))
+		return nil, err
+	}
+
+	active, err := s.refreshTokenRepository.IsFamilyActive(ctx, token.FamilyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !active {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	fresh, err := s.refreshTokenRepository.MarkUsed(ctx, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !fresh {
+		logger.Warn("Refresh token reused, revoking token family",
+			zap.Uint64("user_id", token.UserID),
+			zap.String("family_id", token.FamilyID),
+		)
+
+		err = revokeSessions(ctx, s.userSessionRepository, s.refreshTokenRepository, token.FamilyID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		recordAudit(ctx, s.auditLogRepository, clientActor(client, token.UserID, ""), def.AuditActionRefreshReuse, token.UserID, ErrRefreshTokenReused)
+
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepository.GetPlainByID(ctx, token.UserID)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
+		return nil, err
+	}
+
+	if user == nil || checkUserStatus(user) != nil {
+		_ = revokeSessions(ctx, s.userSessionRepository, s.refreshTokenRepository, token.FamilyID)
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	now := time.Now().UTC()
+
+	err = s.userSessionRepository.Touch(ctx, token.FamilyID, client.IP, now, now.Add(s.config.RefreshTokenTTL))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, token.FamilyID)
+}
+
+// Logout 将访问令牌加入黑名单直到其过期，并撤销当前会话以及请求中刷新令牌所在的会话
+func (s *authService) Logout(ctx context.Context, actor *dto.Actor, token *dto.UserToken, refreshToken string) error {
+	if actor == nil || token == nil {
+		return response.BadRequest("request is nil")
+	}
+
+	err := s.logout(ctx, actor.UserID, token, refreshToken)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionLogout, actor.UserID, err)
+
+	return err
+}
+
+func (s *authService) logout(ctx context.Context, uid uint64, token *dto.UserToken, refreshToken string) error {
+	// 未携带过期时间的旧令牌按访问令牌的有效期保留黑名单
+	expiresAt := time.Now().Add(s.config.AccessTokenTTL)
+
+	if token.ExpiresAt != nil {
+		expiresAt = token.ExpiresAt.Time
+	}
+
+	err := s.tokenRevocationRepository.Blacklist(ctx, token.ID, expiresAt)
+
+	if err != nil {
+		return err
+	}
+
+	sessionID := token.SessionID
+
+	if sessionID != "" {
+		err = revokeSessions(ctx, s.userSessionRepository, s.refreshTokenRepository, sessionID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	refresh, err := s.refreshTokenRepository.GetByHash(ctx, utils.HashToken(refreshToken))
+
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if refresh.UserID != uid || refresh.FamilyID == sessionID {
+		return nil
+	}
+
+	return revokeSessions(ctx, s.userSessionRepository, s.refreshTokenRepository, refresh.FamilyID)
+}
+
+// LogoutAll 撤销用户的全部会话，并使此前签发的访问令牌全部失效
+func (s *authService) LogoutAll(ctx context.Context, actor *dto.Actor) error {
+	if actor == nil {
+		return response.BadRequest("request is nil")
+	}
+
+	err := revokeUserTokens(ctx, s.userSessionRepository, s.refreshTokenRepository, s.tokenRevocationRepository, s.personalAccessTokenRepository, actor.UserID, "")
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionLogoutAll, actor.UserID, err)
+
+	return err
+}
+
+func (s *authService) rehashPassword(ctx context.Context, user *model.User, password string) {
+	hash, err := s.passwordHasher.Hash(password)
+
+	if err != nil {
+		logger.Error("passwordHasher.Hash", zap.Error(err), zap.Uint64("user_id", user.ID))
+		return
+	}
+
+	err = s.userRepository.UpdatePassword(ctx, user.ID, hash)
+
+	if err != nil {
+		logger.Error("userRepository.UpdatePassword", zap.Error(err), zap.Uint64("user_id", user.ID))
+		return
+	}
+
+	user.Password = hash
+	user.Salt = ""
+
+	logger.Info("User password rehashed", zap.Uint64("user_id", user.ID))
+}
+
+// resetLoginFailures 在完成全部验证后清除用户名的登录失败次数，清除失败不影响登录结果
+func (s *authService) resetLoginFailures(ctx context.Context, username string) {
+	err := s.loginAttemptRepository.Reset(ctx, username)
+
+	if err != nil {
+		logger.Warn("Failed to reset login failures", zap.Error(err), zap.String("username", username))
+	}
+}
+
+// verifyDummyPassword 以当前摘要参数执行一次结果被丢弃的口令校验
+func (s *authService) verifyDummyPassword(password string) {
+	if s.dummyPasswordHash == "" {
+		return
+	}
+
+	_, _, _ = s.passwordHasher.Verify(password, s.dummyPasswordHash)
+}
+
+// verifyPassword 校验用户口令，未使用 PHC 格式的口令视为旧版 SHA-256 摘要，校验通过后需要升级
+func verifyPassword(hasher utils.PasswordHasher, user *model.User, password string) (bool, bool, error) {
+	if hasher.IsHash(user.Password) {
+		return hasher.Verify(password, user.Password)
+	}
+
+	ok := utils.VerifyString(password, user.Salt, user.Password)
+
+	return ok, ok, nil
+}
+
+// evictSessions 在用户的有效会话超过上限时，按创建时间从旧到新撤销多余的会话
+func (s *authService) evictSessions(ctx context.Context, user *model.User) error {
+	limit := user.MaxSessions
+
+	if limit <= 0 {
+		limit = s.config.MaxSessions
+	}
+
+	sessions, err := s.userSessionRepository.ListActiveByUser(ctx, user.ID, time.Now().UTC())
+
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) <= limit {
+		return nil
+	}
+
+	ids := make([]string, 0, len(sessions)-limit)
+	for _, session := range sessions[:len(sessions)-limit] {
+		ids = append(ids, session.ID)
+	}
+
+	logger.Info("Evicting sessions over limit",
+		zap.Uint64("user_id", user.ID),
+		zap.Int("limit", limit),
+		zap.Strings("session_ids", ids),
+	)
+
+	return revokeSessions(ctx, s.userSessionRepository, s.refreshTokenRepository, ids...)
+}
+
+func (s *authService) createChallenge(ctx context.Context, user *model.User, device string, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	token, err := utils.GenerateOpaqueToken(def.RefreshTokenBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.twoFactorChallengeRepository.Create(ctx, &model.TwoFactorChallenge{
+		Hash:      utils.HashToken(token),
+		UserID:    user.ID,
+		Device:    device,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: time.Now().Add(s.config.TwoFactorChallengeTTL).UTC(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     token,
+		ChallengeExpiresIn: int64(s.config.TwoFactorChallengeTTL / time.Second),
+	}, nil
+}
+
+// StartSession 为已通过外部身份验证的用户创建会话并签发令牌对，用户开启二次验证时与口令登录一样返回挑战令牌
+func (s *authService) StartSession(ctx context.Context, user *model.User, device string, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	if user == nil || client == nil {
+		return nil, response.BadRequest("request is nil")
+	}
+
+	resp, err := s.startExternalSession(ctx, user, device, client)
+
+	// 需要二次验证时外部身份已验证通过，最终结果由 auth.2fa 记录
+	recordAudit(ctx, s.auditLogRepository, clientActor(client, user.ID, user.Username), def.AuditActionLoginOIDC, user.ID, err)
+
+	return resp, err
+}
+
+func (s *authService) startExternalSession(ctx context.Context, user *model.User, device string, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	err := checkUserStatus(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.twoFactorRepository.GetByUserID(ctx, user.ID)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("twoFactorRepository.GetByUserID", zap.Error(err))
+		return nil, err
+	}
+
+	if twoFactor != nil && twoFactor.Enabled {
+		return s.createChallenge(ctx, user, device, client)
+	}
+
+	token, err := s.startSession(ctx, user, device, client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		TokenResponse: token,
+	}, nil
+}
+
+// startSession 创建设备会话并签发令牌对，每次登录开启一个新的令牌族，令牌族 ID 同时作为设备会话 ID
+func (s *authService) startSession(ctx context.Context, user *model.User, device string, client *dto.ClientInfo) (*dto.TokenResponse, error) {
+	now := time.Now().UTC()
+
+	session := &model.UserSession{
+		ID:         uuid.NewString(),
+		UserID:     user.ID,
+		Device:     device,
+		IP:         client.IP,
+		UserAgent:  truncateRunes(client.UserAgent, def.SessionUserAgentMaxLen),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.config.RefreshTokenTTL),
+	}
+
+	err := s.userSessionRepository.Create(ctx, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.evictSessions(ctx, user)
+
+	if err != nil {
+		return nil, err
 	}
 
 	logger.Info("User logged in successfully",
 		zap.String("username", user.Username),
 		zap.Uint64("user_id", user.ID),
+		zap.String("session_id", session.ID),
 	)
 
+	return s.issueTokens(ctx, user, session.ID)
+}
+
+// issueTokens 签发访问令牌，并在令牌族 familyID 中生成新的刷新令牌
+func (s *authService) issueTokens(ctx context.Context, user *model.User, familyID string) (*dto.TokenResponse, error) {
 	now := time.Now()
 
-	token, err := utils.GenerateJwtToken(&dto.UserToken{
-		UID:      user.ID,
-		Username: user.Username,
+	accessToken, err := utils.GenerateJwtToken(&dto.UserToken{
+		UID:       user.ID,
+		Username:  user.Username,
+		SessionID: familyID,
+		Roles:     user.Roles,
+		Status:    user.Status,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.NewString(),
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour * 2)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenTTL)),
 		},
 	})
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return token, nil
-}
+	refreshToken, err := utils.GenerateOpaqueToken(def.RefreshTokenBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.refreshTokenRepository.Create(ctx, &model.RefreshToken{
+		Hash:      utils.HashToken(refreshToken),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		CreatedAt: now.UTC(),
+		ExpiresAt: now.Add(s.config.RefreshTokenTTL).UTC(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.refreshTokenRepository.ActivateFamily(ctx, familyID, s.config.RefreshTokenTTL)
+
+	if err != nil {
+		return nil, err
+	}
 
-func (s *authService) Logout(ctx context.Context, tokenId string) error {
-	return s.redisClient.Set(ctx, tokenId, true, 0).Err()
+	return &dto.TokenResponse{
+		AccessToken:      accessToken,
+		TokenType:        def.TokenTypeBearer,
+		ExpiresIn:        int64(s.config.AccessTokenTTL / time.Second),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int64(s.config.RefreshTokenTTL / time.Second),
+	}, nil
 }
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+)
+
+var testArgon2Params = utils.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newLoginTestService(user *model.User, hasher utils.PasswordHasher) (*authService, *stubUserRepository) {
+	userRepository := &stubUserRepository{user: user}
+
+	return &authService{
+		config: &AuthConfig{
+			TwoFactorChallengeTTL: time.Minute,
+			LoginThrottle:         LoginThrottleConfig{BackoffThreshold: 5, LockThreshold: 10, IPBackoffThreshold: 20},
+		},
+		userRepository:               userRepository,
+		twoFactorRepository:          &stubTwoFactorRepository{twoFactor: &model.UserTwoFactor{UserID: user.ID, Enabled: true}},
+		twoFactorChallengeRepository: newStubTwoFactorChallengeRepository(),
+		loginAttemptRepository:       newStubLoginAttemptRepository(),
+		auditLogRepository:           &stubAuditLogRepository{},
+		passwordHasher:               hasher,
+	}, userRepository
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	hasher := utils.NewArgon2idHasher(&testArgon2Params)
+
+	weaker := testArgon2Params
+	weaker.Iterations = 2
+	weakHash, err := utils.NewArgon2idHasher(&weaker).Hash("secret")
+
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	currentHash, err := hasher.Hash("secret")
+
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		salt     string
+		password string
+		ok       bool
+		upgraded bool
+	}{
+		{"legacy sha256", utils.HashString("secret", "pepper"), "pepper", "secret", true, true},
+		{"legacy sha256 wrong password", utils.HashString("secret", "pepper"), "pepper", "wrong", false, false},
+		{"argon2id with old params", weakHash, "", "secret", true, true},
+		{"argon2id with current params", currentHash, "", "secret", true, false},
+		{"argon2id wrong password", weakHash, "", "wrong", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &model.User{ID: 7, Username: "alice", Password: tt.hash, Salt: tt.salt}
+			s, userRepository := newLoginTestService(user, hasher)
+
+			resp, err := s.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: tt.password}, &dto.ClientInfo{IP: "192.0.2.1"})
+
+			if tt.ok != (err == nil) {
+				t.Fatalf("Login err = %v, want ok = %v", err, tt.ok)
+			}
+
+			if !tt.ok && !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("Login err = %v, want %v", err, ErrInvalidCredentials)
+			}
+
+			if tt.ok && !resp.TwoFactorRequired {
+				t.Fatal("expected a two-factor challenge")
+			}
+
+			if upgraded := len(userRepository.passwords) > 0; upgraded != tt.upgraded {
+				t.Fatalf("password upgraded = %v, want %v", upgraded, tt.upgraded)
+			}
+
+			if !tt.upgraded {
+				return
+			}
+
+			stored := userRepository.passwords[0]
+
+			if !hasher.IsHash(stored) || user.Password != stored || user.Salt != "" {
+				t.Fatalf("stored password = %q, salt = %q", stored, user.Salt)
+			}
+
+			// 升级后的摘要使用当前参数，可用同一口令再次登录且不再触发升级
+			ok, needsRehash, err := verifyPassword(hasher, user, "secret")
+
+			if err != nil || !ok || needsRehash {
+				t.Fatalf("verifyPassword after upgrade = %v, %v, %v", ok, needsRehash, err)
+			}
+		})
+	}
+}
+
+// twoFactorTestEnv 为启用了 TOTP 的用户 alice（口令 secret）准备的登录环境
+type twoFactorTestEnv struct {
+	service    *authService
+	attempts   *stubLoginAttemptRepository
+	challenges *stubTwoFactorChallengeRepository
+	audit      *stubAuditLogRepository
+	secret     string
+}
+
+func newTwoFactorTestEnv(t *testing.T) *twoFactorTestEnv {
+	t.Helper()
+	setupJWT(t)
+
+	hasher := utils.NewArgon2idHasher(&testArgon2Params)
+	hash, err := hasher.Hash("secret")
+
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	cipher, err := utils.NewSecretCipher("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+
+	if err != nil {
+		t.Fatalf("NewSecretCipher: %v", err)
+	}
+
+	secret, _ := utils.GenerateTOTPSecret()
+	encrypted, err := cipher.Encrypt(secret)
+
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	env := &twoFactorTestEnv{
+		attempts:   newStubLoginAttemptRepository(),
+		challenges: newStubTwoFactorChallengeRepository(),
+		audit:      &stubAuditLogRepository{},
+		secret:     secret,
+	}
+
+	env.service = &authService{
+		config: &AuthConfig{
+			AccessTokenTTL:        15 * time.Minute,
+			RefreshTokenTTL:       24 * time.Hour,
+			MaxSessions:           5,
+			TwoFactorChallengeTTL: time.Minute,
+			LoginThrottle:         LoginThrottleConfig{BackoffThreshold: 5, LockThreshold: 10, LockDuration: time.Hour, IPBackoffThreshold: 20},
+		},
+		userRepository: &stubUserRepository{user: &model.User{ID: 7, Username: "alice", Password: hash}},
+		twoFactorRepository: &stubTwoFactorRepository{
+			twoFactor:     &model.UserTwoFactor{UserID: 7, Secret: encrypted, Enabled: true},
+			recoveryCodes: map[string]bool{},
+		},
+		twoFactorChallengeRepository: env.challenges,
+		loginAttemptRepository:       env.attempts,
+		auditLogRepository:           env.audit,
+		userSessionRepository:        newStubUserSessionRepository(),
+		refreshTokenRepository:       newStubRefreshTokenRepository(),
+		passwordHasher:               hasher,
+		secretCipher:                 cipher,
+	}
+
+	return env
+}
+
+var testClient = &dto.ClientInfo{IP: "192.0.2.1", UserAgent: "test"}
+
+// challenge 以正确口令登录并返回二次验证挑战
+func (env *twoFactorTestEnv) challenge(t *testing.T) string {
+	t.Helper()
+
+	resp, err := env.service.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "secret"}, testClient)
+
+	if err != nil || !resp.TwoFactorRequired {
+		t.Fatalf("Login = %+v, %v, want a two-factor challenge", resp, err)
+	}
+
+	return resp.ChallengeToken
+}
+
+// code 返回当前时间步偏移 offset 后的验证码，偏移超出允许范围的验证码必然无效
+func (env *twoFactorTestEnv) code(offset int64) string {
+	code, _ := utils.TOTPCode(env.secret, utils.TOTPCounter(time.Now())+offset)
+	return code
+}
+
+func (env *twoFactorTestEnv) verify(challenge string, code string) (*dto.TokenResponse, error) {
+	return env.service.VerifyTwoFactor(context.Background(), &dto.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: code}, testClient)
+}
+
+func TestLoginKeepsFailuresUntilSecondFactor(t *testing.T) {
+	env := newTwoFactorTestEnv(t)
+
+	_, err := env.service.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "wrong"}, testClient)
+
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login with wrong password = %v", err)
+	}
+
+	challenge := env.challenge(t)
+
+	// 口令正确但尚未完成二次验证，失败次数不清零
+	if env.attempts.resets != 0 || env.attempts.failures["user:alice"] != 1 {
+		t.Fatalf("after password: resets = %d, failures = %v", env.attempts.resets, env.attempts.failures)
+	}
+
+	token, err := env.verify(challenge, env.code(0))
+
+	if err != nil || token.AccessToken == "" || token.RefreshToken == "" {
+		t.Fatalf("VerifyTwoFactor = %+v, %v", token, err)
+	}
+
+	if env.attempts.resets != 1 || env.attempts.failures["user:alice"] != 0 {
+		t.Fatalf("after second factor: resets = %d, failures = %v", env.attempts.resets, env.attempts.failures)
+	}
+
+	// 挑战只能兑换一次
+	_, err = env.verify(challenge, env.code(0))
+
+	if !errors.Is(err, ErrTwoFactorChallengeInvalid) {
+		t.Fatalf("second VerifyTwoFactor = %v, want %v", err, ErrTwoFactorChallengeInvalid)
+	}
+}
+
+func TestVerifyTwoFactorFailuresLockAccount(t *testing.T) {
+	env := newTwoFactorTestEnv(t)
+
+	first := env.challenge(t)
+	second := env.challenge(t)
+	spare := env.challenge(t)
+
+	// 每个挑战最多尝试 TwoFactorChallengeMaxAttempts 次，但每次失败都计入用户名的登录失败
+	wrong := []struct {
+		challenge string
+		code      string
+	}{
+		{first, env.code(10)},
+		{first, "abcd-efgh-ijkl"},
+		{first, env.code(-10)},
+		{first, env.code(20)},
+		{first, env.code(30)},
+		{second, env.code(10)},
+		{second, env.code(11)},
+		{second, "wrong-recovery"},
+		{second, env.code(12)},
+		{second, env.code(13)},
+	}
+
+	for i, attempt := range wrong {
+		_, err := env.verify(attempt.challenge, attempt.code)
+
+		if !errors.Is(err, ErrTwoFactorCodeInvalid) {
+			t.Fatalf("attempt %d: VerifyTwoFactor = %v, want %v", i+1, err, ErrTwoFactorCodeInvalid)
+		}
+
+		if got := env.attempts.failures["user:alice"]; got != int64(i+1) {
+			t.Fatalf("attempt %d: user failures = %d", i+1, got)
+		}
+
+		if got := env.attempts.failures["ip:"+testClient.IP]; got != int64(i+1) {
+			t.Fatalf("attempt %d: ip failures = %d", i+1, got)
+		}
+	}
+
+	if _, ok := env.challenges.challenges[utils.HashToken(first)]; ok {
+		t.Fatal("challenge was not deleted after max attempts")
+	}
+
+	// 账户已锁定：未用完的挑战即使提交正确的验证码也被拒绝，也无法重新登录获取新的挑战
+	var throttled *LoginThrottledError
+
+	_, err := env.verify(spare, env.code(0))
+
+	if !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("VerifyTwoFactor after lock = %v, want a locked error", err)
+	}
+
+	_, err = env.service.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "secret"}, testClient)
+
+	if !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("Login after lock = %v, want a locked error", err)
+	}
+
+	if env.attempts.resets != 0 {
+		t.Fatalf("failures were reset %d times", env.attempts.resets)
+	}
+}
+
+func TestStartSessionRequiresSecondFactor(t *testing.T) {
+	env := newTwoFactorTestEnv(t)
+	user := &model.User{ID: 7, Username: "alice", Status: def.UserStatusActive}
+
+	resp, err := env.service.StartSession(context.Background(), user, "", testClient)
+
+	if err != nil || !resp.TwoFactorRequired || resp.TokenResponse != nil {
+		t.Fatalf("StartSession = %+v, %v, want a two-factor challenge", resp, err)
+	}
+
+	token, err := env.verify(resp.ChallengeToken, env.code(0))
+
+	if err != nil || token.AccessToken == "" {
+		t.Fatalf("VerifyTwoFactor = %+v, %v", token, err)
+	}
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	env := newTwoFactorTestEnv(t)
+	env.service.twoFactorRepository = &stubTwoFactorRepository{}
+	sessions := env.service.userSessionRepository.(*stubUserSessionRepository)
+
+	login, err := env.service.Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "secret"}, testClient)
+
+	if err != nil || login.TokenResponse == nil {
+		t.Fatalf("Login = %+v, %v", login, err)
+	}
+
+	refresh := func(token string) (*dto.TokenResponse, error) {
+		return env.service.Refresh(context.Background(), &dto.RefreshRequest{RefreshToken: token}, testClient)
+	}
+
+	_, err = refresh("unknown")
+
+	if !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("Refresh with unknown token = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+
+	first := login.RefreshToken
+	second, err := refresh(first)
+
+	if err != nil || second.RefreshToken == "" || second.RefreshToken == first {
+		t.Fatalf("first Refresh = %+v, %v, want a rotated refresh token", second, err)
+	}
+
+	third, err := refresh(second.RefreshToken)
+
+	if err != nil || third.RefreshToken == second.RefreshToken {
+		t.Fatalf("second Refresh = %+v, %v, want a rotated refresh token", third, err)
+	}
+
+	// 已轮换的令牌再次出现说明令牌泄露，整个令牌族作废，最新的令牌也随之失效
+	_, err = refresh(second.RefreshToken)
+
+	if !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("Refresh with rotated token = %v, want %v", err, ErrRefreshTokenReused)
+	}
+
+	_, err = refresh(third.RefreshToken)
+
+	if !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("Refresh after reuse = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+
+	for id, session := range sessions.sessions {
+		if session.RevokedAt == nil {
+			t.Errorf("session %s not revoked", id)
+		}
+	}
+
+	if !slices.Contains(env.audit.actions(), def.AuditActionRefreshReuse+":"+def.AuditResultFailure) {
+		t.Errorf("audit actions = %v, want refresh reuse recorded", env.audit.actions())
+	}
+}
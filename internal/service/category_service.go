@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ CategoryService = (*categoryService)(nil)
+
+// ErrCategoryForbidden 表示当前用户试图访问其他用户的分类
+var ErrCategoryForbidden = response.Forbidden("forbidden: category belongs to another user")
+
+type CategoryService interface {
+	CreateCategory(ctx context.Context, uid uint64, req *dto.CreateCategoryRequest) (*model.Category, error)
+	UpdateCategory(ctx context.Context, uid uint64, cid uint64, req *dto.UpdateCategoryRequest) (*model.Category, error)
+	DeleteCategory(ctx context.Context, uid uint64, cid uint64) error
+	ListCategories(ctx context.Context, uid uint64) ([]*model.Category, error)
+}
+
+type categoryService struct {
+	categoryRepository repository.CategoryRepository
+}
+
+func NewCategoryService(categoryRepository repository.CategoryRepository) CategoryService {
+	return &categoryService{
+		categoryRepository: categoryRepository,
+	}
+}
+
+func (s *categoryService) CreateCategory(ctx context.Context, uid uint64, req *dto.CreateCategoryRequest) (*model.Category, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	name := strings.TrimSpace(req.Name)
+
+	err := s.checkName(ctx, uid, name, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	category := model.Category{
+		UserID: uid,
+		Name:   name,
+		Info:   req.Info,
+	}
+
+	err = s.categoryRepository.Create(ctx, &category)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (s *categoryService) UpdateCategory(ctx context.Context, uid uint64, cid uint64, req *dto.UpdateCategoryRequest) (*model.Category, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	category, err := s.getCategory(ctx, uid, cid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+
+	err = s.checkName(ctx, uid, name, category.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	category.Name = name
+	category.Info = req.Info
+
+	err = s.categoryRepository.Update(ctx, category)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) DeleteCategory(ctx context.Context, uid uint64, cid uint64) error {
+	category, err := s.getCategory(ctx, uid, cid)
+
+	if err != nil {
+		return err
+	}
+
+	return s.categoryRepository.Delete(ctx, category.ID)
+}
+
+func (s *categoryService) ListCategories(ctx context.Context, uid uint64) ([]*model.Category, error) {
+	return s.categoryRepository.ListByUserID(ctx, uid)
+}
+
+// getCategory 查询分类并校验其归属于 uid
+func (s *categoryService) getCategory(ctx context.Context, uid uint64, cid uint64) (*model.Category, error) {
+	category, err := s.categoryRepository.GetByID(ctx, cid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("categoryRepository.GetByID", zap.Error(err))
+		return nil, err
+	}
+
+	if category == nil {
+		return nil, response.NotFound("category not found")
+	}
+
+	if category.UserID != uid {
+		return nil, ErrCategoryForbidden
+	}
+
+	return category, nil
+}
+
+// checkName 校验分类名在用户下唯一，exclude 为正在修改的分类
+func (s *categoryService) checkName(ctx context.Context, uid uint64, name string, exclude uint64) error {
+	if name == "" {
+		return response.Validation("category name is empty")
+	}
+
+	category, err := s.categoryRepository.GetByUserAndName(ctx, uid, name)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("categoryRepository.GetByUserAndName", zap.Error(err))
+		return err
+	}
+
+	if category != nil && category.ID != exclude {
+		return response.Conflict("category name already exists")
+	}
+
+	return nil
+}
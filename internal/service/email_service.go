@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/mailer"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var _ EmailService = (*emailService)(nil)
+
+var (
+	ErrEmailVerifyTokenInvalid = response.BadRequest("invalid or expired email verification token")
+	ErrEmailAlreadyVerified    = response.Conflict("email already verified")
+	ErrEmailNotSet             = response.Conflict("email is not set")
+)
+
+// EmailConfig 中 RequireVerification 为 true 时注册必须提供邮箱，验证前账户处于待验证状态
+// VerifyURL 为前端验证页面地址，令牌以 token 查询参数附加，未配置时邮件中只包含令牌
+type EmailConfig struct {
+	RequireVerification bool
+	VerifyTokenTTL      time.Duration
+	VerifyURL           string
+}
+
+// EmailService 提供邮箱验证邮件的重发与验证
+type EmailService interface {
+	ResendVerification(ctx context.Context, uid uint64) error
+	VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error
+}
+
+type emailService struct {
+	config                      *EmailConfig
+	userRepository              repository.UserRepository
+	emailVerificationRepository repository.EmailVerificationRepository
+	mailer                      mailer.Mailer
+}
+
+func NewEmailService(
+	config *EmailConfig,
+	userRepository repository.UserRepository,
+	emailVerificationRepository repository.EmailVerificationRepository,
+	mailer mailer.Mailer,
+) EmailService {
+	return &emailService{
+		config:                      config,
+		userRepository:              userRepository,
+		emailVerificationRepository: emailVerificationRepository,
+		mailer:                      mailer,
+	}
+}
+
+func (s *emailService) ResendVerification(ctx context.Context, uid uint64) error {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil {
+		return err
+	}
+
+	if user.Email == nil {
+		return ErrEmailNotSet
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	return sendVerificationMail(ctx, s.config, s.emailVerificationRepository, s.mailer, user)
+}
+
+// VerifyEmail 使用一次性令牌验证邮箱，待验证的账户随之激活
+func (s *emailService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error {
+	if req == nil {
+		return response.BadRequest("req is nil")
+	}
+
+	verification, err := s.emailVerificationRepository.Consume(ctx, utils.HashToken(req.Token))
+
+	if errors.Is(err, redis.Nil) {
+		return ErrEmailVerifyTokenInvalid
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.userRepository.MarkEmailVerified(ctx, verification.UserID, verification.Email, time.Now().UTC())
+
+	if err != nil {
+		return err
+	}
+
+	// 令牌签发后用户修改了邮箱
+	if !ok {
+		return ErrEmailVerifyTokenInvalid
+	}
+
+	logger.Info("User email verified", zap.Uint64("user_id", verification.UserID))
+
+	return nil
+}
+
+// sendVerificationMail 为用户当前的邮箱签发验证令牌并发送验证邮件
+func sendVerificationMail(
+	ctx context.Context,
+	config *EmailConfig,
+	emailVerificationRepository repository.EmailVerificationRepository,
+	mail mailer.Mailer,
+	user *model.User,
+) error {
+	if user.Email == nil {
+		return ErrEmailNotSet
+	}
+
+	ttl := config.VerifyTokenTTL
+
+	if ttl <= 0 {
+		ttl = def.EmailVerifyTokenTTL * time.Second
+	}
+
+	token, err := utils.GenerateOpaqueToken(def.EmailVerifyTokenBytes)
+
+	if err != nil {
+		return err
+	}
+
+	err = emailVerificationRepository.Create(ctx, &model.EmailVerification{
+		Hash:   utils.HashToken(token),
+		UserID: user.ID,
+		Email:  *user.Email,
+	}, ttl)
+
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Hi %s,\n\n", user.Username)
+	fmt.Fprintf(&b, "Please confirm your email address. The link expires in %d hours.\n\n", int(ttl/time.Hour))
+
+	link, err := url.Parse(config.VerifyURL)
+
+	if config.VerifyURL != "" && err == nil {
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+
+		fmt.Fprintf(&b, "Verify your email: %s\n\n", link.String())
+	} else {
+		fmt.Fprintf(&b, "Verification token: %s\n\n", token)
+	}
+
+	b.WriteString("If you did not create a w2learn account, you can ignore this email.\n")
+
+	err = mail.Send(ctx, &mailer.Mail{
+		To:      *user.Email,
+		Subject: "Verify your w2learn email",
+		Body:    b.String(),
+		SentAt:  time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Verification mail sent", zap.Uint64("user_id", user.ID))
+
+	return nil
+}
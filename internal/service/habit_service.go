@@ -3,101 +3,186 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 	"w2learn/internal/dto"
 	"w2learn/internal/model"
 	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
 	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+var _ HabitService = (*habitService)(nil)
+
+var (
+	// ErrHabitForbidden 表示当前用户试图访问其他用户的习惯
+	ErrHabitForbidden = response.Forbidden("forbidden: habit belongs to another user")
+	// ErrHabitNameExists 表示用户未归档的习惯中已有同名习惯
+	ErrHabitNameExists = response.Conflict("habit name already exists")
+)
+
+// HabitService 的所有方法都以 uid 标识当前认证用户，只允许操作其本人的习惯
 type HabitService interface {
-	CreateHabit(ctx context.Context, req *dto.CreateHabitRequest) (*model.Habit, error)
-	GetHabitByID(ctx context.Context, id uint64) (*model.Habit, error)
-	UpdateHabit(ctx context.Context, hid uint64, req *dto.UpdateHabitRequest) (*model.Habit, error)
-	DeleteHabit(ctx context.Context, req *dto.DeleteHabitRequest) error
-	ListHabits(ctx context.Context, page int, pageSize int) ([]*model.Habit, error)
+	CreateHabit(ctx context.Context, uid uint64, req *dto.CreateHabitRequest) (*model.Habit, error)
+	GetHabitByID(ctx context.Context, uid uint64, hid uint64) (*dto.HabitResponse, error)
+	UpdateHabit(ctx context.Context, uid uint64, hid uint64, req *dto.UpdateHabitRequest) (*model.Habit, error)
+	DeleteHabit(ctx context.Context, uid uint64, hid uint64) error
+	ListHabits(ctx context.Context, uid uint64, req *dto.ListHabitsRequest) ([]*dto.HabitResponse, error)
+	PauseHabit(ctx context.Context, uid uint64, hid uint64, req *dto.PauseHabitRequest) (*model.Habit, error)
+	ResumeHabit(ctx context.Context, uid uint64, hid uint64) (*model.Habit, error)
+	ArchiveHabit(ctx context.Context, uid uint64, hid uint64) (*model.Habit, error)
+	UnarchiveHabit(ctx context.Context, uid uint64, hid uint64) (*model.Habit, error)
+	CheckIn(ctx context.Context, uid uint64, hid uint64, req *dto.CheckInRequest) (*dto.CheckInResponse, error)
+	ListCheckIns(ctx context.Context, uid uint64, hid uint64, req *dto.ListCheckInsRequest) ([]*dto.CheckInResponse, error)
+	UndoCheckIn(ctx context.Context, uid uint64, hid uint64, day string) error
+	RebuildStreak(ctx context.Context, uid uint64, hid uint64) (*dto.HabitStreakResponse, error)
+	ListDueHabits(ctx context.Context, uid uint64) ([]*dto.HabitResponse, error)
 }
 
 type habitService struct {
-	habitRepository repository.HabitRepository
-	userRepository  repository.UserRepository
+	habitRepository        repository.HabitRepository
+	userRepository         repository.UserRepository
+	habitCheckInRepository repository.HabitCheckInRepository
+	habitStreakRepository  repository.HabitStreakRepository
+	habitPauseRepository   repository.HabitPauseRepository
+	tagRepository          repository.TagRepository
+	categoryRepository     repository.CategoryRepository
 }
 
 func NewHabitService(
 	habitRepository repository.HabitRepository,
 	userRepository repository.UserRepository,
+	habitCheckInRepository repository.HabitCheckInRepository,
+	habitStreakRepository repository.HabitStreakRepository,
+	habitPauseRepository repository.HabitPauseRepository,
+	tagRepository repository.TagRepository,
+	categoryRepository repository.CategoryRepository,
 ) HabitService {
 	return &habitService{
-		habitRepository: habitRepository,
-		userRepository:  userRepository,
+		habitRepository:        habitRepository,
+		userRepository:         userRepository,
+		habitCheckInRepository: habitCheckInRepository,
+		habitStreakRepository:  habitStreakRepository,
+		habitPauseRepository:   habitPauseRepository,
+		tagRepository:          tagRepository,
+		categoryRepository:     categoryRepository,
 	}
 }
 
-func (s *habitService) CreateHabit(ctx context.Context, req *dto.CreateHabitRequest) (*model.Habit, error) {
-	user, err := s.userRepository.GetByID(ctx, req.UserID)
+func (s *habitService) CreateHabit(ctx context.Context, uid uint64, req *dto.CreateHabitRequest) (*model.Habit, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		logger.Error("userRepository.GetByID", zap.Error(err))
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
 		return nil, err
 	}
 
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, response.NotFound("user not found")
 	}
 
-	if user.Habits == nil {
-		user.Habits = make([]model.Habit, 0)
+	today := utils.UserClock(user).Today()
+
+	schedule, err := s.toHabitSchedule(req.Schedule, today)
+
+	if err != nil {
+		return nil, err
 	}
 
-	habit := model.Habit{
-		UserID: req.UserID,
-		Name:   req.Name,
-		Info:   req.Info,
+	target, err := s.toHabitTarget(req.Target)
+
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.habitRepository.Create(ctx, &habit)
+	tags, err := s.getTags(ctx, user.ID, req.TagIDs)
 
 	if err != nil {
 		return nil, err
 	}
 
-	user.Habits = append(user.Habits, habit)
+	habit := model.Habit{
+		UserID:   user.ID,
+		Name:     req.Name,
+		Info:     req.Info,
+		Schedule: *schedule,
+		Target:   *target,
+		Status:   def.HabitStatusActive,
+		Tags:     tags,
+	}
+
+	if req.CategoryID != nil && *req.CategoryID != 0 {
+		category, err := s.getCategory(ctx, user.ID, *req.CategoryID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		habit.CategoryID = &category.ID
+		habit.Category = category
+	}
 
-	err = s.userRepository.Update(ctx, user)
+	err = s.habitRepository.Create(ctx, &habit)
 
 	if err != nil {
-		return nil, err
+		return nil, habitNameConflict(err)
 	}
 
 	return &habit, nil
 }
 
-func (s *habitService) GetHabitByID(ctx context.Context, id uint64) (*model.Habit, error) {
-	habit, err := s.habitRepository.GetByID(ctx, id)
+func (s *habitService) GetHabitByID(ctx context.Context, uid uint64, hid uint64) (*dto.HabitResponse, error) {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return habit, nil
-}
+	clock, err := s.getClock(ctx, habit.UserID)
+
+	if err != nil {
+		return nil, err
+	}
 
-func (s *habitService) UpdateHabit(ctx context.Context, hid uint64, req *dto.UpdateHabitRequest) (*model.Habit, error) {
-	habit, err := s.habitRepository.GetByID(ctx, hid)
+	err = s.syncStatus(ctx, habit, clock.Today())
 
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		logger.Error("habitRepository.GetByID", zap.Error(err))
+	if err != nil {
 		return nil, err
 	}
 
-	if habit == nil {
-		return nil, errors.New("habit not found")
+	streak, err := s.getStreak(ctx, habit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	today, err := s.habitCheckInRepository.GetByHabitAndDay(ctx, habit.ID, clock.Today())
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("habitCheckInRepository.GetByHabitAndDay", zap.Error(err))
+		return nil, err
 	}
 
+	return s.toHabitResponse(habit, streak, today, clock.Today())
+}
+
+func (s *habitService) UpdateHabit(ctx context.Context, uid uint64, hid uint64, req *dto.UpdateHabitRequest) (*model.Habit, error) {
 	if req == nil {
-		return nil, errors.New("req is nil")
+		return nil, response.BadRequest("req is nil")
+	}
+
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
 	}
 
 	if req.Name != "" {
@@ -108,91 +193,1070 @@ func (s *habitService) UpdateHabit(ctx context.Context, hid uint64, req *dto.Upd
 		habit.Info = req.Info
 	}
 
+	// 未指定起始日期时沿用原有锚点，避免按间隔或 COUNT 计算的周期随修改日期平移
+	if req.Schedule != nil {
+		schedule, err := s.toHabitSchedule(req.Schedule, utils.ScheduleAnchor(habit))
+
+		if err != nil {
+			return nil, err
+		}
+
+		habit.Schedule = *schedule
+	}
+
+	if req.Target != nil {
+		target, err := s.toHabitTarget(req.Target)
+
+		if err != nil {
+			return nil, err
+		}
+
+		habit.Target = *target
+	}
+
+	if req.CategoryID != nil {
+		if *req.CategoryID == 0 {
+			habit.CategoryID = nil
+			habit.Category = nil
+		} else {
+			category, err := s.getCategory(ctx, habit.UserID, *req.CategoryID)
+
+			if err != nil {
+				return nil, err
+			}
+
+			habit.CategoryID = &category.ID
+			habit.Category = category
+		}
+	}
+
 	err = s.habitRepository.Update(ctx, habit)
 
 	if err != nil {
-		return nil, err
+		return nil, habitNameConflict(err)
+	}
+
+	if req.TagIDs != nil {
+		tags, err := s.getTags(ctx, habit.UserID, req.TagIDs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.habitRepository.ReplaceTags(ctx, habit, tags)
+
+		if err != nil {
+			return nil, err
+		}
+
+		habit.Tags = tags
+	}
+
+	if req.Target != nil {
+		err = s.refreshCompletion(ctx, habit)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 重复规则或目标变化后周期完成情况随之改变，需要重建连续打卡状态
+	if req.Schedule != nil || req.Target != nil {
+		_, err = s.rebuildStreak(ctx, habit)
+
+		if err != nil {
+			logger.Error("Failed to rebuild habit streak", zap.Error(err), zap.Uint64("habit_id", habit.ID))
+		}
 	}
 
 	return habit, nil
 }
 
-func (s *habitService) DeleteHabit(ctx context.Context, req *dto.DeleteHabitRequest) error {
+func (s *habitService) DeleteHabit(ctx context.Context, uid uint64, hid uint64) error {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return err
+	}
+
+	return s.habitRepository.Delete(ctx, habit.ID)
+}
+
+// ListHabits 分页返回用户的习惯，可按状态、标签和分类过滤
+func (s *habitService) ListHabits(ctx context.Context, uid uint64, req *dto.ListHabitsRequest) ([]*dto.HabitResponse, error) {
 	if req == nil {
-		return errors.New("req is nil")
+		return nil, response.BadRequest("req is nil")
 	}
 
-	user, err := s.userRepository.GetByID(ctx, req.UserID)
+	filter := repository.HabitFilter{
+		TagID:      req.TagID,
+		CategoryID: req.CategoryID,
+	}
 
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		logger.Error("userRepository.GetByID", zap.Error(err))
-		return err
+	switch req.Status {
+	case "":
+		filter.Statuses = []string{def.HabitStatusActive, def.HabitStatusPaused}
+	case def.HabitStatusAll:
+	case def.HabitStatusActive, def.HabitStatusPaused, def.HabitStatusArchived:
+		filter.Statuses = []string{req.Status}
+	default:
+		return nil, response.Validation("invalid habit status")
 	}
 
-	if user == nil {
-		return errors.New("user not found")
+	page := req.Page
+	pageSize := req.PageSize
+
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	offset := (page - 1) * pageSize
+
+	list, err := s.habitRepository.ListPageByUserID(ctx, uid, &filter, offset, pageSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toHabitResponses(ctx, list)
+}
+
+func (s *habitService) CheckIn(ctx context.Context, uid uint64, hid uint64, req *dto.CheckInRequest) (*dto.CheckInResponse, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if habit.Status == def.HabitStatusArchived {
+		return nil, response.Conflict("habit is archived")
 	}
 
-	if user.Habits == nil {
-		return errors.New("habits is nil")
+	clock, err := s.getClock(ctx, habit.UserID)
+
+	if err != nil {
+		return nil, err
 	}
 
-	deleteIndex := -1
-	var deleteID uint64
+	day := clock.Today()
+
+	if req.Day != "" {
+		day, err = utils.ParseDay(req.Day)
 
-	for index, habit := range user.Habits {
-		if habit.ID == req.HabitID {
-			deleteIndex = index
-			deleteID = habit.ID
-			break
+		if err != nil {
+			return nil, response.Validation("invalid day")
+		}
+
+		if day.After(clock.Today()) {
+			return nil, response.Validation("can't check in for a future day")
 		}
 	}
 
-	if deleteIndex == -1 {
-		return errors.New("habit not found")
+	quantitative := habit.Target.Value > 0
+
+	if quantitative && req.Amount <= 0 && habit.Target.Mode != def.HabitTargetAtMost {
+		return nil, response.Validation("amount must be positive")
 	}
 
-	if deleteIndex == 0 {
-		user.Habits = user.Habits[deleteIndex+1:]
-	} else if deleteIndex == len(user.Habits)-1 {
-		user.Habits = user.Habits[:deleteIndex]
-	} else {
-		user.Habits = append(user.Habits[:deleteIndex], user.Habits[deleteIndex+1:]...)
+	wasCompleted := false
+
+	// 可量化习惯同一天的多次打卡累加到同一条记录，记录在事务中加锁，并发打卡不会丢失累计量
+	checkIn, err := s.habitCheckInRepository.Upsert(ctx, habit.ID, day, func(checkIn *model.HabitCheckIn, created bool) error {
+		if !created {
+			if !quantitative {
+				return response.Conflict("habit already checked in on this day")
+			}
+
+			wasCompleted = checkIn.IsCompleted()
+		}
+
+		checkIn.Amount += req.Amount
+
+		if req.Note != "" {
+			checkIn.Note = req.Note
+		}
+
+		completed := utils.IsTargetMet(habit.Target, checkIn.Amount)
+		checkIn.Completed = &completed
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	completed := checkIn.IsCompleted()
+
+	if completed != wasCompleted {
+		apply := utils.ApplyStreakCheckIn
+
+		if !completed {
+			apply = utils.ApplyStreakUndo
+		}
+
+		err = s.updateStreak(ctx, habit, day, apply)
+
+		if err != nil {
+			logger.Error("Failed to update habit streak", zap.Error(err), zap.Uint64("habit_id", habit.ID))
+		}
+	}
+
+	return s.toCheckInResponse(habit, checkIn), nil
+}
+
+func (s *habitService) ListCheckIns(ctx context.Context, uid uint64, hid uint64, req *dto.ListCheckInsRequest) ([]*dto.CheckInResponse, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	clock, err := s.getClock(ctx, habit.UserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := parseDayRange(req.From, req.To, clock.Today())
+
+	if err != nil {
+		return nil, err
+	}
+
+	checkIns, err := s.habitCheckInRepository.ListByHabit(ctx, habit.ID, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.CheckInResponse, 0, len(checkIns))
+
+	for _, checkIn := range checkIns {
+		responses = append(responses, s.toCheckInResponse(habit, checkIn))
+	}
+
+	return responses, nil
+}
+
+func (s *habitService) UndoCheckIn(ctx context.Context, uid uint64, hid uint64, day string) error {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return err
 	}
 
-	err = s.userRepository.Update(ctx, user)
+	d, err := utils.ParseDay(day)
 
 	if err != nil {
+		return response.Validation("invalid day")
+	}
+
+	checkIn, err := s.habitCheckInRepository.GetByHabitAndDay(ctx, habit.ID, d)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("habitCheckInRepository.GetByHabitAndDay", zap.Error(err))
 		return err
 	}
 
-	err = s.habitRepository.Delete(ctx, deleteID)
+	if checkIn == nil {
+		return response.NotFound("check-in not found")
+	}
+
+	err = s.habitCheckInRepository.Delete(ctx, checkIn.ID)
 
 	if err != nil {
 		return err
 	}
 
+	if !checkIn.IsCompleted() {
+		return nil
+	}
+
+	err = s.updateStreak(ctx, habit, d, utils.ApplyStreakUndo)
+
+	if err != nil {
+		logger.Error("Failed to update habit streak", zap.Error(err), zap.Uint64("habit_id", habit.ID))
+	}
+
 	return nil
 }
 
-func (s *habitService) ListHabits(ctx context.Context, page int, pageSize int) ([]*model.Habit, error) {
-	if page <= 0 {
-		page = 1
+func (s *habitService) RebuildStreak(ctx context.Context, uid uint64, hid uint64) (*dto.HabitStreakResponse, error) {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
 	}
-	if pageSize <= 0 {
-		pageSize = 10
+
+	streak, err := s.rebuildStreak(ctx, habit)
+
+	if err != nil {
+		return nil, err
 	}
-	if pageSize > 100 {
-		pageSize = 100
+
+	schedule, err := utils.ScheduleOf(habit)
+
+	if err != nil {
+		return nil, err
 	}
 
-	offset := (page - 1) * pageSize
+	clock, err := s.getClock(ctx, habit.UserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toStreakResponse(streak, schedule, clock.Today()), nil
+}
+
+func (s *habitService) ListDueHabits(ctx context.Context, uid uint64) ([]*dto.HabitResponse, error) {
+	habits, err := s.habitRepository.ListByUserID(ctx, uid, &repository.HabitFilter{
+		Statuses: []string{def.HabitStatusActive, def.HabitStatusPaused},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := s.toHabitResponses(ctx, habits)
 
-	list, err := s.habitRepository.List(ctx, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]*dto.HabitResponse, 0, len(responses))
+
+	for _, response := range responses {
+		if response.DueToday {
+			due = append(due, response)
+		}
+	}
+
+	return due, nil
+}
+
+// getTags 查询 uid 名下的标签，任一标签不存在时返回错误
+func (s *habitService) getTags(ctx context.Context, uid uint64, ids []uint64) ([]model.Tag, error) {
+	tags := make([]model.Tag, 0, len(ids))
+
+	if len(ids) == 0 {
+		return tags, nil
+	}
+
+	list, err := s.tagRepository.ListByUserAndIDs(ctx, uid, ids)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return list, err
+	found := make(map[uint64]bool, len(list))
+
+	for _, tag := range list {
+		found[tag.ID] = true
+		tags = append(tags, *tag)
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return nil, response.NotFound("tag not found")
+		}
+	}
+
+	return tags, nil
+}
+
+// getCategory 查询 uid 名下的分类
+func (s *habitService) getCategory(ctx context.Context, uid uint64, cid uint64) (*model.Category, error) {
+	category, err := s.categoryRepository.GetByID(ctx, cid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("categoryRepository.GetByID", zap.Error(err))
+		return nil, err
+	}
+
+	if category == nil || category.UserID != uid {
+		return nil, response.NotFound("category not found")
+	}
+
+	return category, nil
+}
+
+// getOwnedHabit 查询习惯并校验其归属于 uid
+func getOwnedHabit(ctx context.Context, habitRepository repository.HabitRepository, uid uint64, hid uint64) (*model.Habit, error) {
+	habit, err := habitRepository.GetByID(ctx, hid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("habitRepository.GetByID", zap.Error(err))
+		return nil, err
+	}
+
+	if habit == nil {
+		return nil, response.NotFound("habit not found")
+	}
+
+	if habit.UserID != uid {
+		return nil, ErrHabitForbidden
+	}
+
+	return habit, nil
+}
+
+func (s *habitService) PauseHabit(ctx context.Context, uid uint64, hid uint64, req *dto.PauseHabitRequest) (*model.Habit, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	clock, err := s.getClock(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	today := clock.Today()
+
+	err = s.syncStatus(ctx, habit, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if habit.Status != def.HabitStatusActive {
+		return nil, response.Conflict("only active habits can be paused")
+	}
+
+	pause := model.HabitPause{
+		HabitID:  habit.ID,
+		StartDay: today,
+	}
+
+	if req.ResumeDay != "" {
+		resumeDay, err := utils.ParseDay(req.ResumeDay)
+
+		if err != nil {
+			return nil, response.Validation("invalid resume day")
+		}
+
+		if !resumeDay.After(today) {
+			return nil, response.Validation("resume day must be after today")
+		}
+
+		endDay := resumeDay.AddDate(0, 0, -1)
+		pause.EndDay = &endDay
+		habit.PausedUntil = &resumeDay
+	}
+
+	err = s.habitPauseRepository.Create(ctx, &pause)
+
+	if err != nil {
+		return nil, err
+	}
+
+	habit.Status = def.HabitStatusPaused
+	habit.Pauses = append(habit.Pauses, pause)
+
+	return s.saveStatus(ctx, habit)
+}
+
+func (s *habitService) ResumeHabit(ctx context.Context, uid uint64, hid uint64) (*model.Habit, error) {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	clock, err := s.getClock(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.syncStatus(ctx, habit, clock.Today())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if habit.Status != def.HabitStatusPaused {
+		return nil, response.Conflict("habit is not paused")
+	}
+
+	err = s.closePause(ctx, habit, clock.Today())
+
+	if err != nil {
+		return nil, err
+	}
+
+	habit.Status = def.HabitStatusActive
+	habit.PausedUntil = nil
+
+	return s.saveStatus(ctx, habit)
+}
+
+// ArchiveHabit 归档习惯，归档后默认列表不再展示，但打卡记录与统计数据保留
+func (s *habitService) ArchiveHabit(ctx context.Context, uid uint64, hid uint64) (*model.Habit, error) {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if habit.Status == def.HabitStatusArchived {
+		return nil, response.Conflict("habit is already archived")
+	}
+
+	if habit.Status == def.HabitStatusPaused {
+		clock, err := s.getClock(ctx, uid)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.closePause(ctx, habit, clock.Today())
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+
+	habit.Status = def.HabitStatusArchived
+	habit.PausedUntil = nil
+	habit.ArchivedAt = &now
+
+	return s.saveStatus(ctx, habit)
+}
+
+func (s *habitService) UnarchiveHabit(ctx context.Context, uid uint64, hid uint64) (*model.Habit, error) {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if habit.Status != def.HabitStatusArchived {
+		return nil, response.Conflict("habit is not archived")
+	}
+
+	// 归档期间可能已新建同名习惯，恢复前需要重新检查名称
+	err = s.checkName(ctx, habit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	habit.Status = def.HabitStatusActive
+	habit.ArchivedAt = nil
+
+	return s.saveStatus(ctx, habit)
+}
+
+// saveStatus 保存习惯状态，暂停期变化会影响周期豁免，因此同时重建连续打卡状态
+func (s *habitService) saveStatus(ctx context.Context, habit *model.Habit) (*model.Habit, error) {
+	err := s.habitRepository.Update(ctx, habit)
+
+	if err != nil {
+		return nil, habitNameConflict(err)
+	}
+
+	_, err = s.rebuildStreak(ctx, habit)
+
+	if err != nil {
+		logger.Error("Failed to rebuild habit streak", zap.Error(err), zap.Uint64("habit_id", habit.ID))
+	}
+
+	return habit, nil
+}
+
+// checkName 检查用户未归档的习惯中是否已有与 habit 同名的其他习惯
+func (s *habitService) checkName(ctx context.Context, habit *model.Habit) error {
+	other, err := s.habitRepository.GetByUserAndName(ctx, habit.UserID, habit.Name)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("habitRepository.GetByUserAndName", zap.Error(err))
+		return err
+	}
+
+	if other != nil && other.ID != habit.ID {
+		return ErrHabitNameExists
+	}
+
+	return nil
+}
+
+// habitNameConflict 将 idx_habits_user_name 唯一索引冲突转换为 ErrHabitNameExists
+// 请求校验中的名称检查与写入之间存在竞争，并发创建或恢复同名习惯时由数据库拒绝
+func habitNameConflict(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrHabitNameExists
+	}
+
+	return err
+}
+
+// closePause 将仍在进行中的暂停期截止到 today 前一天，当天开始的暂停期直接删除
+func (s *habitService) closePause(ctx context.Context, habit *model.Habit, today time.Time) error {
+	pause, err := s.habitPauseRepository.GetLatestByHabit(ctx, habit.ID)
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+
+		logger.Error("habitPauseRepository.GetLatestByHabit", zap.Error(err))
+		return err
+	}
+
+	if pause.EndDay != nil && pause.EndDay.Before(today) {
+		return nil
+	}
+
+	endDay := today.AddDate(0, 0, -1)
+	deleted := endDay.Before(pause.StartDay)
+
+	if deleted {
+		err = s.habitPauseRepository.Delete(ctx, pause.ID)
+	} else {
+		pause.EndDay = &endDay
+		err = s.habitPauseRepository.Update(ctx, pause)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	pauses := make([]model.HabitPause, 0, len(habit.Pauses))
+
+	for _, p := range habit.Pauses {
+		if p.ID == pause.ID {
+			if deleted {
+				continue
+			}
+
+			p = *pause
+		}
+
+		pauses = append(pauses, p)
+	}
+
+	habit.Pauses = pauses
+
+	return nil
+}
+
+// syncStatus 暂停的习惯到达恢复日期后自动恢复为进行中
+func (s *habitService) syncStatus(ctx context.Context, habit *model.Habit, today time.Time) error {
+	if habit.Status != def.HabitStatusPaused || habit.PausedUntil == nil || today.Before(*habit.PausedUntil) {
+		return nil
+	}
+
+	habit.Status = def.HabitStatusActive
+	habit.PausedUntil = nil
+
+	return s.habitRepository.Update(ctx, habit)
+}
+
+// getClock 返回用户的本地时钟，所有按日期计算的逻辑都以用户本地日期为准
+func (s *habitService) getClock(ctx context.Context, uid uint64) (*utils.Clock, error) {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, response.NotFound("user not found")
+	}
+
+	return utils.UserClock(user), nil
+}
+
+// getStreak 读取习惯的连续打卡状态，缺失时从打卡记录重建
+func (s *habitService) getStreak(ctx context.Context, habit *model.Habit) (*model.HabitStreak, error) {
+	streak, err := s.habitStreakRepository.GetByHabitID(ctx, habit.ID)
+
+	if err == nil {
+		return streak, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("habitStreakRepository.GetByHabitID", zap.Error(err))
+		return nil, err
+	}
+
+	return s.rebuildStreak(ctx, habit)
+}
+
+// updateStreak 使用 apply 增量更新 day 所在周期的连续打卡状态，apply 返回 false 时回退为全量重建
+func (s *habitService) updateStreak(
+	ctx context.Context,
+	habit *model.Habit,
+	day time.Time,
+	apply func(streak *model.HabitStreak, schedule utils.Schedule, day time.Time, done int) bool,
+) error {
+	streak, err := s.habitStreakRepository.GetByHabitID(ctx, habit.ID)
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_, err = s.rebuildStreak(ctx, habit)
+		}
+
+		return err
+	}
+
+	schedule, err := utils.ScheduleOf(habit)
+
+	if err != nil {
+		return err
+	}
+
+	done := 0
+
+	if start, end, ok := schedule.Period(day); ok {
+		count, err := s.habitCheckInRepository.CountByHabit(ctx, habit.ID, start, end)
+
+		if err != nil {
+			return err
+		}
+
+		done = int(count)
+	}
+
+	if !apply(streak, schedule, day, done) {
+		_, err = s.rebuildStreak(ctx, habit)
+		return err
+	}
+
+	return s.habitStreakRepository.Update(ctx, streak)
+}
+
+func (s *habitService) rebuildStreak(ctx context.Context, habit *model.Habit) (*model.HabitStreak, error) {
+	schedule, err := utils.ScheduleOf(habit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	streak, err := s.habitStreakRepository.GetByHabitID(ctx, habit.ID)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("habitStreakRepository.GetByHabitID", zap.Error(err))
+		return nil, err
+	}
+
+	if streak == nil {
+		streak = &model.HabitStreak{HabitID: habit.ID, CreatedAt: time.Now()}
+	}
+
+	days, err := s.habitCheckInRepository.ListDaysByHabit(ctx, habit.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	utils.RebuildStreak(streak, schedule, days)
+
+	err = s.habitStreakRepository.Update(ctx, streak)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return streak, nil
+}
+
+// toHabitTarget 将请求中的每日目标转换为模型，未指定时表示只需打卡即可完成
+func (s *habitService) toHabitTarget(req *dto.HabitTargetRequest) (*model.HabitTarget, error) {
+	target := &model.HabitTarget{
+		Mode: def.HabitTargetAtLeast,
+	}
+
+	if req == nil {
+		return target, nil
+	}
+
+	target.Value = req.Value
+	target.Unit = req.Unit
+
+	if req.Mode != "" {
+		target.Mode = req.Mode
+	}
+
+	return target, nil
+}
+
+// refreshCompletion 在目标变化后按新目标重新判定每条打卡记录是否完成
+func (s *habitService) refreshCompletion(ctx context.Context, habit *model.Habit) error {
+	checkIns, err := s.habitCheckInRepository.ListAllByHabit(ctx, habit.ID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, checkIn := range checkIns {
+		completed := utils.IsTargetMet(habit.Target, checkIn.Amount)
+
+		if completed == checkIn.IsCompleted() {
+			continue
+		}
+
+		checkIn.Completed = &completed
+
+		err = s.habitCheckInRepository.Update(ctx, checkIn)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toHabitSchedule 将请求中的重复规则转换为模型并校验，未指定时默认为每日，未指定起始日期时以 startDay 为锚点
+func (s *habitService) toHabitSchedule(req *dto.HabitScheduleRequest, startDay time.Time) (*model.HabitSchedule, error) {
+	schedule := &model.HabitSchedule{
+		Type:     def.HabitScheduleDaily,
+		StartDay: &startDay,
+	}
+
+	if req == nil {
+		return schedule, nil
+	}
+
+	schedule.Type = req.Type
+	schedule.Interval = req.Interval
+	schedule.Times = req.Times
+	schedule.RRule = req.RRule
+
+	for _, d := range req.Weekdays {
+		schedule.Weekdays |= model.NewWeekdays(time.Weekday(d))
+	}
+
+	if req.StartDay != "" {
+		day, err := utils.ParseDay(req.StartDay)
+
+		if err != nil {
+			return nil, response.Validation("invalid start day")
+		}
+
+		schedule.StartDay = &day
+	}
+
+	_, err := utils.ParseSchedule(schedule, *schedule.StartDay)
+
+	if err != nil {
+		return nil, response.Validation(err.Error())
+	}
+
+	return schedule, nil
+}
+
+func (s *habitService) toStreakResponse(streak *model.HabitStreak, schedule utils.Schedule, today time.Time) *dto.HabitStreakResponse {
+	current, atRisk := utils.StreakStatus(streak, schedule, today)
+
+	return &dto.HabitStreakResponse{
+		CurrentStreak: current,
+		LongestStreak: streak.LongestStreak,
+		AtRisk:        atRisk,
+		LastPeriod:    streak.LastPeriod,
+	}
+}
+
+func (s *habitService) toCheckInResponse(habit *model.Habit, checkIn *model.HabitCheckIn) *dto.CheckInResponse {
+	return &dto.CheckInResponse{
+		HabitCheckIn: checkIn,
+		Percent:      utils.TargetPercent(habit.Target, checkIn.Amount, true),
+	}
+}
+
+// toProgressResponse 汇总用户本地当日的打卡进度，checkIn 为 nil 表示当日尚未打卡
+func (s *habitService) toProgressResponse(habit *model.Habit, checkIn *model.HabitCheckIn, today time.Time) *dto.HabitProgressResponse {
+	progress := &dto.HabitProgressResponse{
+		Day:    today,
+		Target: habit.Target.Value,
+		Unit:   habit.Target.Unit,
+	}
+
+	if checkIn != nil {
+		progress.Amount = checkIn.Amount
+		progress.Completed = checkIn.IsCompleted()
+	}
+
+	progress.Percent = utils.TargetPercent(habit.Target, progress.Amount, checkIn != nil)
+
+	return progress
+}
+
+func (s *habitService) toHabitResponse(
+	habit *model.Habit,
+	streak *model.HabitStreak,
+	checkIn *model.HabitCheckIn,
+	today time.Time,
+) (*dto.HabitResponse, error) {
+	schedule, err := utils.ScheduleOf(habit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.HabitResponse{
+		Habit:    habit,
+		DueToday: utils.IsDue(streak, schedule, today),
+		Today:    s.toProgressResponse(habit, checkIn, today),
+		Streak:   s.toStreakResponse(streak, schedule, today),
+	}, nil
+}
+
+func (s *habitService) toHabitResponses(ctx context.Context, habits []*model.Habit) ([]*dto.HabitResponse, error) {
+	if len(habits) == 0 {
+		return make([]*dto.HabitResponse, 0), nil
+	}
+
+	ids := make([]uint64, 0, len(habits))
+	todays := make(map[uint64]time.Time, len(habits))
+	clocks := make(map[uint64]*utils.Clock)
+
+	var from, to time.Time
+
+	for _, habit := range habits {
+		clock, ok := clocks[habit.UserID]
+
+		if !ok {
+			var err error
+			clock, err = s.getClock(ctx, habit.UserID)
+
+			if err != nil {
+				return nil, err
+			}
+
+			clocks[habit.UserID] = clock
+		}
+
+		today := clock.Today()
+
+		if from.IsZero() || today.Before(from) {
+			from = today
+		}
+
+		if to.IsZero() || today.After(to) {
+			to = today
+		}
+
+		ids = append(ids, habit.ID)
+		todays[habit.ID] = today
+	}
+
+	streaks, err := s.habitStreakRepository.ListByHabitIDs(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	streakMap := make(map[uint64]*model.HabitStreak, len(streaks))
+
+	for _, streak := range streaks {
+		streakMap[streak.HabitID] = streak
+	}
+
+	// 不同用户的本地日期可能相差一天，按日期范围批量查询后再按习惯匹配
+	checkIns, err := s.habitCheckInRepository.ListByHabitIDsBetween(ctx, ids, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	checkInMap := make(map[uint64]*model.HabitCheckIn, len(checkIns))
+
+	for _, checkIn := range checkIns {
+		if checkIn.Day.Equal(todays[checkIn.HabitID]) {
+			checkInMap[checkIn.HabitID] = checkIn
+		}
+	}
+
+	responses := make([]*dto.HabitResponse, 0, len(habits))
+
+	for _, habit := range habits {
+		err = s.syncStatus(ctx, habit, todays[habit.ID])
+
+		if err != nil {
+			return nil, err
+		}
+
+		streak, ok := streakMap[habit.ID]
+
+		if !ok {
+			streak, err = s.rebuildStreak(ctx, habit)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := s.toHabitResponse(habit, streak, checkInMap[habit.ID], todays[habit.ID])
+
+		if err != nil {
+			return nil, err
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// parseDayRange 解析 [from, to] 日期区间，to 默认为 today，from 默认为 to 之前的 def.CheckInListDefaultDays 天
+func parseDayRange(fromStr string, toStr string, today time.Time) (time.Time, time.Time, error) {
+	var err error
+
+	to := today
+
+	if toStr != "" {
+		to, err = utils.ParseDay(toStr)
+
+		if err != nil {
+			return time.Time{}, time.Time{}, response.Validation("invalid to day")
+		}
+	}
+
+	from := to.AddDate(0, 0, -(def.CheckInListDefaultDays - 1))
+
+	if fromStr != "" {
+		from, err = utils.ParseDay(fromStr)
+
+		if err != nil {
+			return time.Time{}, time.Time{}, response.Validation("invalid from day")
+		}
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, response.Validation("from day is after to day")
+	}
+
+	if to.Sub(from) >= def.CheckInListMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, response.Validation("day range is too large")
+	}
+
+	return from, to, nil
 }
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/pkg/def"
+
+	"gorm.io/gorm"
+)
+
+// stubHabitRepository 中 active 为名称查询返回的未归档习惯，duplicate 模拟写入时触发 idx_habits_user_name
+type stubHabitRepository struct {
+	repository.HabitRepository
+	habit     *model.Habit
+	active    *model.Habit
+	duplicate bool
+	created   []*model.Habit
+}
+
+func (r *stubHabitRepository) GetByID(ctx context.Context, id uint64) (*model.Habit, error) {
+	if r.habit == nil || r.habit.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.habit, nil
+}
+
+func (r *stubHabitRepository) GetByUserAndName(ctx context.Context, userID uint64, name string) (*model.Habit, error) {
+	if r.active == nil || r.active.UserID != userID || r.active.Name != name {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.active, nil
+}
+
+func (r *stubHabitRepository) Create(ctx context.Context, habit *model.Habit) error {
+	if r.duplicate {
+		return gorm.ErrDuplicatedKey
+	}
+
+	habit.ID = uint64(len(r.created) + 1)
+	r.created = append(r.created, habit)
+	return nil
+}
+
+func (r *stubHabitRepository) Update(ctx context.Context, habit *model.Habit) error {
+	if r.duplicate {
+		return gorm.ErrDuplicatedKey
+	}
+
+	return nil
+}
+
+func archivedHabit() *model.Habit {
+	archivedAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	return &model.Habit{ID: 10, UserID: 7, Name: "Read", Status: def.HabitStatusArchived, ArchivedAt: &archivedAt}
+}
+
+func TestUnarchiveHabitRechecksName(t *testing.T) {
+	tests := []struct {
+		name      string
+		active    *model.Habit
+		duplicate bool
+	}{
+		{"name taken while archived", &model.Habit{ID: 11, UserID: 7, Name: "Read"}, false},
+		{"name taken concurrently", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habitRepository := &stubHabitRepository{habit: archivedHabit(), active: tt.active, duplicate: tt.duplicate}
+			s := &habitService{habitRepository: habitRepository}
+
+			_, err := s.UnarchiveHabit(context.Background(), 7, 10)
+
+			if !errors.Is(err, ErrHabitNameExists) {
+				t.Fatalf("UnarchiveHabit err = %v, want %v", err, ErrHabitNameExists)
+			}
+		})
+	}
+}
+
+func TestCreateHabit(t *testing.T) {
+	userRepository := &stubUserRepository{user: &model.User{ID: 7, Username: "alice", Timezone: "Asia/Shanghai"}}
+	habitRepository := &stubHabitRepository{}
+	s := &habitService{habitRepository: habitRepository, userRepository: userRepository}
+
+	req := &dto.CreateHabitRequest{Name: "Read", Info: "20 pages", Schedule: &dto.HabitScheduleRequest{Type: def.HabitScheduleDaily}}
+
+	habit, err := s.CreateHabit(context.Background(), 7, req)
+
+	if err != nil {
+		t.Fatalf("CreateHabit: %v", err)
+	}
+
+	if len(habitRepository.created) != 1 || habitRepository.created[0] != habit {
+		t.Fatalf("created habits = %v, want the returned habit", habitRepository.created)
+	}
+
+	if habit.UserID != 7 || habit.Status != def.HabitStatusActive || habit.Schedule.StartDay == nil {
+		t.Fatalf("unexpected habit %+v", habit)
+	}
+
+	// 习惯只通过习惯仓库写入，不再整体保存用户及其关联
+	if userRepository.updates != 0 {
+		t.Fatalf("user updated %d times, want 0", userRepository.updates)
+	}
+}
+
+func TestCreateHabitNameConflict(t *testing.T) {
+	userRepository := &stubUserRepository{user: &model.User{ID: 7, Username: "alice"}}
+	habitRepository := &stubHabitRepository{duplicate: true}
+	s := &habitService{habitRepository: habitRepository, userRepository: userRepository}
+
+	req := &dto.CreateHabitRequest{Name: "Read", Info: "20 pages", Schedule: &dto.HabitScheduleRequest{Type: def.HabitScheduleDaily}}
+
+	_, err := s.CreateHabit(context.Background(), 7, req)
+
+	if !errors.Is(err, ErrHabitNameExists) {
+		t.Fatalf("CreateHabit err = %v, want %v", err, ErrHabitNameExists)
+	}
+}
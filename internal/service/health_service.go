@@ -5,8 +5,15 @@ import (
 	"w2learn/internal/model"
 	"w2learn/internal/repository"
 	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
 )
 
+// ErrDatabaseUnavailable 表示健康检查时数据库无法连接
+var ErrDatabaseUnavailable = response.Unavailable("database unavailable")
+
 var _ HealthService = (*healthService)(nil)
 
 type HealthService interface {
@@ -30,7 +37,8 @@ func (s *healthService) GetHealth(ctx context.Context, flag int) (*model.HealthM
 		status, err := s.healthRepo.GetDatabaseStatus(ctx)
 
 		if err != nil {
-			return nil, err
+			logger.Error("healthRepo.GetDatabaseStatus", zap.Error(err))
+			return nil, ErrDatabaseUnavailable
 		}
 
 		healthModel.DatabaseStatus = status
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"w2learn/internal/repository"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// LoginThrottleConfig 为登录失败的限流参数，Window 为统计失败次数的滑动窗口
+// 同一用户名失败 BackoffThreshold 次后开始指数退避，失败 LockThreshold 次后锁定 LockDuration
+// 同一 IP 失败 IPBackoffThreshold 次后开始指数退避，IP 不会触发锁定
+type LoginThrottleConfig struct {
+	Window             time.Duration
+	BackoffThreshold   int64
+	BackoffBase        time.Duration
+	BackoffMax         time.Duration
+	LockThreshold      int64
+	LockDuration       time.Duration
+	IPBackoffThreshold int64
+}
+
+// LoginThrottledError 表示登录尝试处于退避期或账户被临时锁定，RetryAfter 为需要等待的时间
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account temporarily locked, retry after %d seconds", retryAfterSeconds(e.RetryAfter))
+	}
+
+	return fmt.Sprintf("too many login attempts, retry after %d seconds", retryAfterSeconds(e.RetryAfter))
+}
+
+// RetryAfterSeconds 返回向上取整的等待秒数，用于 Retry-After 响应头
+func (e *LoginThrottledError) RetryAfterSeconds() int {
+	return retryAfterSeconds(e.RetryAfter)
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func (c *LoginThrottleConfig) setDefaults() {
+	if c.Window <= 0 {
+		c.Window = def.LoginFailureWindow * time.Second
+	}
+
+	if c.BackoffThreshold <= 0 {
+		c.BackoffThreshold = def.LoginBackoffThreshold
+	}
+
+	if c.BackoffBase <= 0 {
+		c.BackoffBase = def.LoginBackoffBase * time.Second
+	}
+
+	if c.BackoffMax <= 0 {
+		c.BackoffMax = def.LoginBackoffMax * time.Second
+	}
+
+	if c.LockThreshold <= 0 {
+		c.LockThreshold = def.LoginLockThreshold
+	}
+
+	if c.LockDuration <= 0 {
+		c.LockDuration = def.LoginLockDuration * time.Second
+	}
+
+	if c.IPBackoffThreshold <= 0 {
+		c.IPBackoffThreshold = def.LoginIPBackoffThreshold
+	}
+}
+
+// backoff 返回第 failures 次失败后的等待时间，未达到 threshold 时为 0
+func (c *LoginThrottleConfig) backoff(failures int64, threshold int64) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	delay := c.BackoffBase
+
+	for i := threshold; i < failures && delay < c.BackoffMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, c.BackoffMax)
+}
+
+// checkLoginThrottle 在校验口令前检查用户名与 IP 是否处于退避期或锁定期
+func checkLoginThrottle(ctx context.Context, loginAttemptRepository repository.LoginAttemptRepository, username string, ip string) error {
+	retryAfter, locked, err := loginAttemptRepository.RetryAfter(ctx, username, ip)
+
+	if err != nil {
+		logger.Error("loginAttemptRepository.RetryAfter", zap.Error(err))
+		return err
+	}
+
+	if retryAfter > 0 {
+		return &LoginThrottledError{
+			RetryAfter: retryAfter,
+			Locked:     locked,
+		}
+	}
+
+	return nil
+}
+
+// recordLoginFailure 记录一次登录失败并按失败次数设置退避或锁定账户，记录失败不影响登录结果
+func recordLoginFailure(
+	ctx context.Context,
+	config *LoginThrottleConfig,
+	loginAttemptRepository repository.LoginAttemptRepository,
+	username string,
+	ip string,
+) {
+	now := time.Now()
+
+	failures, err := loginAttemptRepository.RecordFailure(ctx, def.LoginScopeUser, username, now, config.Window)
+
+	if err != nil {
+		logger.Error("Failed to record login failure", zap.Error(err), zap.String("username", username))
+		return
+	}
+
+	if failures >= config.LockThreshold {
+		err = loginAttemptRepository.Lock(ctx, username, config.LockDuration)
+
+		if err != nil {
+			logger.Error("Failed to lock account", zap.Error(err), zap.String("username", username))
+		} else {
+			logger.Warn("Account locked after repeated login failures",
+				zap.String("username", username),
+				zap.String("ip", ip),
+				zap.Int64("failures", failures),
+				zap.Duration("duration", config.LockDuration),
+			)
+		}
+	} else if delay := config.backoff(failures, config.BackoffThreshold); delay > 0 {
+		err = loginAttemptRepository.SetBackoff(ctx, def.LoginScopeUser, username, delay)
+
+		if err != nil {
+			logger.Error("Failed to set login backoff", zap.Error(err), zap.String("username", username))
+		}
+	}
+
+	if ip == "" {
+		return
+	}
+
+	failures, err = loginAttemptRepository.RecordFailure(ctx, def.LoginScopeIP, ip, now, config.Window)
+
+	if err != nil {
+		logger.Error("Failed to record login failure", zap.Error(err), zap.String("ip", ip))
+		return
+	}
+
+	if delay := config.backoff(failures, config.IPBackoffThreshold); delay > 0 {
+		err = loginAttemptRepository.SetBackoff(ctx, def.LoginScopeIP, ip, delay)
+
+		if err != nil {
+			logger.Error("Failed to set login backoff", zap.Error(err), zap.String("ip", ip))
+		} else if failures == config.IPBackoffThreshold {
+			logger.Warn("Login throttled for IP", zap.String("ip", ip), zap.Int64("failures", failures))
+		}
+	}
+}
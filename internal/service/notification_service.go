@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/repository"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ NotificationService = (*notificationService)(nil)
+
+// NotificationService 提供站内信收件箱的查询与已读标记
+type NotificationService interface {
+	ListNotifications(ctx context.Context, uid uint64, req *dto.ListNotificationsRequest) (*dto.NotificationListResponse, error)
+	ReadNotification(ctx context.Context, uid uint64, nid uint64) error
+	ReadAllNotifications(ctx context.Context, uid uint64) error
+}
+
+type notificationService struct {
+	notificationRepository repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepository repository.NotificationRepository) NotificationService {
+	return &notificationService{
+		notificationRepository: notificationRepository,
+	}
+}
+
+func (s *notificationService) ListNotifications(ctx context.Context, uid uint64, req *dto.ListNotificationsRequest) (*dto.NotificationListResponse, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	page := req.Page
+	pageSize := req.PageSize
+
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	offset := (page - 1) * pageSize
+
+	items, err := s.notificationRepository.ListPageByUserID(ctx, uid, req.Unread, offset, pageSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.notificationRepository.CountUnread(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.NotificationListResponse{
+		Unread: unread,
+		Items:  items,
+	}, nil
+}
+
+func (s *notificationService) ReadNotification(ctx context.Context, uid uint64, nid uint64) error {
+	notification, err := s.notificationRepository.GetByID(ctx, nid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("notificationRepository.GetByID", zap.Error(err))
+		return err
+	}
+
+	if notification == nil || notification.UserID != uid {
+		return response.NotFound("notification not found")
+	}
+
+	return s.notificationRepository.MarkRead(ctx, notification.ID, time.Now().UTC())
+}
+
+func (s *notificationService) ReadAllNotifications(ctx context.Context, uid uint64) error {
+	return s.notificationRepository.MarkAllRead(ctx, uid, time.Now().UTC())
+}
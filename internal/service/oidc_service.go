@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/oidc"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ OIDCService = (*oidcService)(nil)
+
+var (
+	ErrOIDCDisabled     = response.Forbidden("oidc login is not enabled")
+	ErrOIDCStateInvalid = response.Unauthorized("invalid or expired oidc state")
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// OIDCConfig 中 StateTTL 为授权请求的有效期
+type OIDCConfig struct {
+	StateTTL time.Duration
+}
+
+// OIDCService 使用外部身份提供方登录，首次登录时自动创建账户并以 issuer 与 subject 关联
+type OIDCService interface {
+	StartLogin(ctx context.Context, req *dto.OIDCLoginRequest) (*dto.OIDCLoginResponse, error)
+	Callback(ctx context.Context, req *dto.OIDCCallbackRequest, client *dto.ClientInfo) (*dto.LoginResponse, error)
+}
+
+type oidcService struct {
+	config                 *OIDCConfig
+	provider               *oidc.Provider
+	authService            AuthService
+	userRepository         repository.UserRepository
+	userIdentityRepository repository.UserIdentityRepository
+	oidcStateRepository    repository.OIDCStateRepository
+	passwordHasher         utils.PasswordHasher
+}
+
+// NewOIDCService 中 provider 为 nil 表示未启用 OIDC 登录
+func NewOIDCService(
+	config *OIDCConfig,
+	provider *oidc.Provider,
+	authService AuthService,
+	userRepository repository.UserRepository,
+	userIdentityRepository repository.UserIdentityRepository,
+	oidcStateRepository repository.OIDCStateRepository,
+	passwordHasher utils.PasswordHasher,
+) OIDCService {
+	if config.StateTTL <= 0 {
+		config.StateTTL = def.OIDCStateTTL * time.Second
+	}
+
+	return &oidcService{
+		config:                 config,
+		provider:               provider,
+		authService:            authService,
+		userRepository:         userRepository,
+		userIdentityRepository: userIdentityRepository,
+		oidcStateRepository:    oidcStateRepository,
+		passwordHasher:         passwordHasher,
+	}
+}
+
+// StartLogin 生成 state、nonce 与 PKCE code_verifier，返回身份提供方的授权地址与需要写入 Cookie 的 state 摘要
+func (s *oidcService) StartLogin(ctx context.Context, req *dto.OIDCLoginRequest) (*dto.OIDCLoginResponse, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	state, err := utils.GenerateOpaqueToken(def.OIDCStateBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := utils.GenerateOpaqueToken(def.OIDCNonceBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := utils.GenerateOpaqueToken(def.OIDCVerifierBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+
+	if err != nil {
+		logger.Error("Failed to build oidc authorization url", zap.Error(err))
+		return nil, err
+	}
+
+	hash := utils.HashToken(state)
+
+	err = s.oidcStateRepository.Create(ctx, &model.OIDCState{
+		Hash:         hash,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		Device:       req.Device,
+	}, s.config.StateTTL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OIDCLoginResponse{
+		AuthorizationURL: authURL,
+		ExpiresIn:        int64(s.config.StateTTL / time.Second),
+		StateHash:        hash,
+	}, nil
+}
+
+// Callback 校验 state 后用授权码换取 ID Token，并为对应的用户签发令牌对，用户开启二次验证时返回挑战令牌
+func (s *oidcService) Callback(ctx context.Context, req *dto.OIDCCallbackRequest, client *dto.ClientInfo) (*dto.LoginResponse, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	if req == nil || client == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	hash := utils.HashToken(req.State)
+
+	// state 必须来自同一浏览器发起的登录，否则攻击者可以诱导受害者以攻击者的外部账户登录
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(req.StateHash)) != 1 {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	// 无论授权是否成功都先作废 state
+	state, err := s.oidcStateRepository.Consume(ctx, hash)
+
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Error != "" {
+		logger.Info("OIDC authorization failed", zap.String("error", req.Error), zap.String("description", req.ErrorDescription))
+		return nil, response.Unauthorized("oidc authorization failed: " + req.Error)
+	}
+
+	if req.Code == "" {
+		return nil, response.Validation("code is empty")
+	}
+
+	token, err := s.provider.Exchange(ctx, req.Code, state.CodeVerifier)
+
+	if err != nil {
+		logger.Error("Failed to exchange oidc code", zap.Error(err))
+		return nil, response.Unauthorized("oidc token exchange failed")
+	}
+
+	claims, err := s.provider.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+
+	if err != nil {
+		logger.Warn("Failed to verify oidc id token", zap.Error(err))
+		return nil, response.Unauthorized("invalid oidc id token")
+	}
+
+	user, err := s.resolveUser(ctx, claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.StartSession(ctx, user, state.Device, client)
+}
+
+// resolveUser 按 issuer 与 subject 查找关联的用户，不存在时创建新用户
+func (s *oidcService) resolveUser(ctx context.Context, claims *oidc.IDTokenClaims) (*model.User, error) {
+	identity, err := s.userIdentityRepository.GetByIssuerSubject(ctx, s.provider.Issuer(), claims.Subject)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userIdentityRepository.GetByIssuerSubject", zap.Error(err))
+		return nil, err
+	}
+
+	if identity != nil {
+		return s.userRepository.GetPlainByID(ctx, identity.UserID)
+	}
+
+	return s.createUser(ctx, claims)
+}
+
+// createUser 为首次登录的外部账户创建用户，只有身份提供方确认过的邮箱才会被采用
+// 新用户的口令为随机值，需要时可以通过重置口令设置
+func (s *oidcService) createUser(ctx context.Context, claims *oidc.IDTokenClaims) (*model.User, error) {
+	username, err := s.availableUsername(ctx, claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var email *string
+	var emailVerifiedAt *time.Time
+
+	if claims.Email != "" && claims.EmailVerified {
+		email, err = checkEmail(ctx, s.userRepository, claims.Email, 0)
+
+		// 邮箱已被其他用户使用时不关联，避免通过外部账户接管已有用户
+		if err != nil {
+			logger.Info("OIDC email not adopted", zap.Error(err), zap.String("subject", claims.Subject))
+			email = nil
+		} else {
+			now := time.Now().UTC()
+			emailVerifiedAt = &now
+		}
+	}
+
+	secret, err := utils.GenerateOpaqueToken(def.PasswordResetBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := s.passwordHasher.Hash(secret)
+
+	if err != nil {
+		logger.Error("passwordHasher.Hash", zap.Error(err))
+		return nil, errors.New("failed to hash password")
+	}
+
+	user := &model.User{
+		Username:        username,
+		Email:           email,
+		EmailVerifiedAt: emailVerifiedAt,
+		Password:        password,
+		Status:          def.UserStatusActive,
+	}
+
+	var identityEmail *string
+
+	if claims.Email != "" {
+		identityEmail = &claims.Email
+	}
+
+	err = s.userIdentityRepository.CreateWithUser(ctx, user, &model.UserIdentity{
+		Issuer:  s.provider.Issuer(),
+		Subject: claims.Subject,
+		Email:   identityEmail,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("User created from oidc login",
+		zap.String("username", user.Username),
+		zap.Uint64("user_id", user.ID),
+		zap.String("subject", claims.Subject),
+	)
+
+	return user, nil
+}
+
+// availableUsername 由声明推导用户名，已被使用时追加随机后缀
+func (s *oidcService) availableUsername(ctx context.Context, claims *oidc.IDTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+
+	if base == "" && claims.Email != "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	base = strings.Trim(usernameInvalidChars.ReplaceAllString(base, "-"), "-.")
+
+	if base == "" {
+		base = "user"
+	}
+
+	// 预留随机后缀的长度
+	base = truncateRunes(base, def.OIDCUsernameMaxLen-7)
+	username := base
+
+	for i := 0; i < 5; i++ {
+		_, err := s.userRepository.GetByUsername(ctx, username)
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return username, nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		suffix := make([]byte, 3)
+
+		_, err = rand.Read(suffix)
+
+		if err != nil {
+			return "", err
+		}
+
+		username = base + "-" + hex.EncodeToString(suffix)
+	}
+
+	return "", errors.New("failed to allocate username")
+}
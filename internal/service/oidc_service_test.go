@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/oidc"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type stubOIDCStateRepository struct {
+	repository.OIDCStateRepository
+	states   map[string]*model.OIDCState
+	consumed int
+}
+
+func (r *stubOIDCStateRepository) Consume(ctx context.Context, hash string) (*model.OIDCState, error) {
+	r.consumed++
+	state, ok := r.states[hash]
+
+	if !ok {
+		return nil, redis.Nil
+	}
+
+	delete(r.states, hash)
+
+	return state, nil
+}
+
+func TestCallbackRequiresStateCookie(t *testing.T) {
+	const state = "state-from-attacker"
+
+	tests := []struct {
+		name      string
+		stateHash string
+	}{
+		{"missing cookie", ""},
+		{"other login", utils.HashToken("state-from-victim")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states := &stubOIDCStateRepository{
+				states: map[string]*model.OIDCState{utils.HashToken(state): {Nonce: "nonce"}},
+			}
+			s := &oidcService{
+				config:              &OIDCConfig{StateTTL: time.Minute},
+				provider:            &oidc.Provider{},
+				oidcStateRepository: states,
+			}
+
+			_, err := s.Callback(context.Background(), &dto.OIDCCallbackRequest{
+				Code:      "code",
+				State:     state,
+				StateHash: tt.stateHash,
+			}, testClient)
+
+			if !errors.Is(err, ErrOIDCStateInvalid) {
+				t.Fatalf("Callback = %v, want %v", err, ErrOIDCStateInvalid)
+			}
+
+			// 不属于本浏览器的 state 不会被消耗，发起登录的用户仍可完成登录
+			if states.consumed != 0 {
+				t.Fatalf("state consumed %d times", states.consumed)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/mailer"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ PasswordService = (*passwordService)(nil)
+
+var ErrPasswordResetTokenInvalid = response.BadRequest("invalid or expired password reset token")
+
+// PasswordConfig 中 ResetURL 为前端重置口令页面地址，令牌以 token 查询参数附加，未配置时邮件中只包含令牌
+type PasswordConfig struct {
+	ResetTokenTTL time.Duration
+	ResetURL      string
+}
+
+// PasswordService 提供修改口令与通过邮件重置口令
+type PasswordService interface {
+	ChangePassword(ctx context.Context, actor *dto.Actor, sessionID string, req *dto.ChangePasswordRequest) error
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest, client *dto.ClientInfo) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest, client *dto.ClientInfo) error
+}
+
+type passwordService struct {
+	config                        *PasswordConfig
+	userRepository                repository.UserRepository
+	userSessionRepository         repository.UserSessionRepository
+	refreshTokenRepository        repository.RefreshTokenRepository
+	passwordResetRepository       repository.PasswordResetRepository
+	auditLogRepository            repository.AuditLogRepository
+	tokenRevocationRepository     repository.TokenRevocationRepository
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository
+	passwordHasher                utils.PasswordHasher
+	mailer                        mailer.Mailer
+}
+
+func NewPasswordService(
+	config *PasswordConfig,
+	userRepository repository.UserRepository,
+	userSessionRepository repository.UserSessionRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	passwordResetRepository repository.PasswordResetRepository,
+	auditLogRepository repository.AuditLogRepository,
+	tokenRevocationRepository repository.TokenRevocationRepository,
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository,
+	passwordHasher utils.PasswordHasher,
+	mailer mailer.Mailer,
+) PasswordService {
+	if config.ResetTokenTTL <= 0 {
+		config.ResetTokenTTL = def.PasswordResetTokenTTL * time.Second
+	}
+
+	return &passwordService{
+		config:                        config,
+		userRepository:                userRepository,
+		userSessionRepository:         userSessionRepository,
+		refreshTokenRepository:        refreshTokenRepository,
+		passwordResetRepository:       passwordResetRepository,
+		auditLogRepository:            auditLogRepository,
+		tokenRevocationRepository:     tokenRevocationRepository,
+		personalAccessTokenRepository: personalAccessTokenRepository,
+		passwordHasher:                passwordHasher,
+		mailer:                        mailer,
+	}
+}
+
+// ChangePassword 校验当前口令后修改口令，撤销当前会话以外的全部会话，并使已签发的访问令牌全部失效
+func (s *passwordService) ChangePassword(ctx context.Context, actor *dto.Actor, sessionID string, req *dto.ChangePasswordRequest) error {
+	if actor == nil || req == nil {
+		return response.BadRequest("req is nil")
+	}
+
+	err := s.changePassword(ctx, actor.UserID, sessionID, req)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionPasswordChange, actor.UserID, err)
+
+	return err
+}
+
+func (s *passwordService) changePassword(ctx context.Context, uid uint64, sessionID string, req *dto.ChangePasswordRequest) error {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := verifyPassword(s.passwordHasher, user, req.CurrentPassword)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return response.Forbidden("invalid password")
+	}
+
+	err = s.setPassword(ctx, user, req.NewPassword)
+
+	if err != nil {
+		return err
+	}
+
+	return revokeUserTokens(ctx, s.userSessionRepository, s.refreshTokenRepository, s.tokenRevocationRepository, s.personalAccessTokenRepository, user.ID, sessionID)
+}
+
+// ForgotPassword 向账户邮箱发送重置链接，账户不存在或未设置邮箱时同样返回成功，避免泄露账户信息
+func (s *passwordService) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest, client *dto.ClientInfo) error {
+	if req == nil || client == nil {
+		return response.BadRequest("req is nil")
+	}
+
+	uid, err := s.forgotPassword(ctx, req)
+
+	recordAudit(ctx, s.auditLogRepository, clientActor(client, 0, ""), def.AuditActionPasswordForgot, uid, err)
+
+	return err
+}
+
+// forgotPassword 返回收到重置邮件的用户 ID，账户不存在或未设置邮箱时返回 0
+func (s *passwordService) forgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) (uint64, error) {
+	account := strings.TrimSpace(req.Account)
+
+	var user *model.User
+	var err error
+
+	if strings.Contains(account, "@") {
+		user, err = s.userRepository.GetByEmail(ctx, strings.ToLower(account))
+	} else {
+		user, err = s.userRepository.GetByUsername(ctx, account)
+	}
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("Failed to query user", zap.Error(err))
+		return 0, err
+	}
+
+	if user == nil || user.Email == nil {
+		logger.Info("Password reset requested for unknown account or account without email")
+		return 0, nil
+	}
+
+	token, err := utils.GenerateOpaqueToken(def.PasswordResetBytes)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.passwordResetRepository.Create(ctx, user.ID, utils.HashToken(token), s.config.ResetTokenTTL)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Mail{
+		To:      *user.Email,
+		Subject: "Reset your w2learn password",
+		Body:    s.resetBody(user, token),
+		SentAt:  time.Now(),
+	})
+
+	if err != nil {
+		logger.Error("Failed to send password reset mail", zap.Error(err), zap.Uint64("user_id", user.ID))
+		return user.ID, nil
+	}
+
+	logger.Info("Password reset mail sent", zap.Uint64("user_id", user.ID))
+
+	return user.ID, nil
+}
+
+// ResetPassword 使用一次性令牌重置口令，撤销该用户的全部会话并使已签发的访问令牌失效
+func (s *passwordService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest, client *dto.ClientInfo) error {
+	if req == nil || client == nil {
+		return response.BadRequest("req is nil")
+	}
+
+	uid, err := s.passwordResetRepository.Consume(ctx, utils.HashToken(req.Token))
+
+	if errors.Is(err, redis.Nil) {
+		err = ErrPasswordResetTokenInvalid
+	}
+
+	if err == nil {
+		err = s.resetPassword(ctx, uid, req)
+	}
+
+	recordAudit(ctx, s.auditLogRepository, clientActor(client, 0, ""), def.AuditActionPasswordReset, uid, err)
+
+	return err
+}
+
+func (s *passwordService) resetPassword(ctx context.Context, uid uint64, req *dto.ResetPasswordRequest) error {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = s.setPassword(ctx, user, req.NewPassword)
+
+	if err != nil {
+		return err
+	}
+
+	return revokeUserTokens(ctx, s.userSessionRepository, s.refreshTokenRepository, s.tokenRevocationRepository, s.personalAccessTokenRepository, user.ID, "")
+}
+
+func (s *passwordService) setPassword(ctx context.Context, user *model.User, password string) error {
+	hash, err := s.passwordHasher.Hash(password)
+
+	if err != nil {
+		logger.Error("passwordHasher.Hash", zap.Error(err))
+		return errors.New("failed to hash password")
+	}
+
+	err = s.userRepository.UpdatePassword(ctx, user.ID, hash)
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("User password changed", zap.Uint64("user_id", user.ID))
+
+	return nil
+}
+
+func (s *passwordService) resetBody(user *model.User, token string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Hi %s,\n\n", user.Username)
+	fmt.Fprintf(&b, "We received a request to reset your password. It expires in %d minutes.\n\n", int(s.config.ResetTokenTTL/time.Minute))
+
+	link, err := url.Parse(s.config.ResetURL)
+
+	if s.config.ResetURL != "" && err == nil {
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+
+		fmt.Fprintf(&b, "Reset your password: %s\n\n", link.String())
+	} else {
+		fmt.Fprintf(&b, "Reset token: %s\n\n", token)
+	}
+
+	b.WriteString("If you did not request a password reset, you can ignore this email.\n")
+
+	return b.String()
+}
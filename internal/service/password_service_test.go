@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/mailer"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type passwordReset struct {
+	userID    uint64
+	expiresAt time.Time
+}
+
+// stubPasswordResetRepository 按 Redis 实现的语义保存重置令牌：新令牌作废同一用户的旧令牌，过期或取出后不可再用
+type stubPasswordResetRepository struct {
+	repository.PasswordResetRepository
+	now    time.Time
+	resets map[string]passwordReset
+	latest map[uint64]string
+}
+
+func newStubPasswordResetRepository() *stubPasswordResetRepository {
+	return &stubPasswordResetRepository{
+		now:    time.Now(),
+		resets: map[string]passwordReset{},
+		latest: map[uint64]string{},
+	}
+}
+
+func (r *stubPasswordResetRepository) Create(ctx context.Context, userID uint64, hash string, ttl time.Duration) error {
+	delete(r.resets, r.latest[userID])
+	r.resets[hash] = passwordReset{userID: userID, expiresAt: r.now.Add(ttl)}
+	r.latest[userID] = hash
+	return nil
+}
+
+func (r *stubPasswordResetRepository) Consume(ctx context.Context, hash string) (uint64, error) {
+	reset, ok := r.resets[hash]
+	delete(r.resets, hash)
+
+	if !ok || !r.now.Before(reset.expiresAt) {
+		return 0, redis.Nil
+	}
+
+	return reset.userID, nil
+}
+
+type stubMailer struct {
+	mails []*mailer.Mail
+}
+
+func (m *stubMailer) Send(ctx context.Context, mail *mailer.Mail) error {
+	m.mails = append(m.mails, mail)
+	return nil
+}
+
+var resetLinkToken = regexp.MustCompile(`token=([^\s&]+)`)
+
+type passwordResetTestEnv struct {
+	service *passwordService
+	resets  *stubPasswordResetRepository
+	mailer  *stubMailer
+	users   *stubUserRepository
+	revoked *stubTokenRevocationRepository
+}
+
+func newPasswordResetTestEnv(t *testing.T) *passwordResetTestEnv {
+	t.Helper()
+
+	email := "alice@example.com"
+
+	env := &passwordResetTestEnv{
+		resets:  newStubPasswordResetRepository(),
+		mailer:  &stubMailer{},
+		users:   &stubUserRepository{user: &model.User{ID: 7, Username: "alice", Email: &email}},
+		revoked: newStubTokenRevocationRepository(),
+	}
+
+	env.service = NewPasswordService(
+		&PasswordConfig{ResetTokenTTL: 30 * time.Minute, ResetURL: "https://app.example.com/reset"},
+		env.users,
+		newStubUserSessionRepository(),
+		newStubRefreshTokenRepository(),
+		env.resets,
+		&stubAuditLogRepository{},
+		env.revoked,
+		newStubPersonalAccessTokenRepository(),
+		utils.NewArgon2idHasher(&testArgon2Params),
+		env.mailer,
+	).(*passwordService)
+
+	return env
+}
+
+// forgot 申请重置口令并返回邮件中的令牌
+func (env *passwordResetTestEnv) forgot(t *testing.T) string {
+	t.Helper()
+
+	err := env.service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Account: "alice"}, testClient)
+
+	if err != nil {
+		t.Fatalf("ForgotPassword = %v", err)
+	}
+
+	match := resetLinkToken.FindStringSubmatch(env.mailer.mails[len(env.mailer.mails)-1].Body)
+
+	if match == nil {
+		t.Fatal("reset mail has no token")
+	}
+
+	token, err := url.QueryUnescape(match[1])
+
+	if err != nil {
+		t.Fatalf("QueryUnescape: %v", err)
+	}
+
+	return token
+}
+
+func (env *passwordResetTestEnv) reset(token string) error {
+	return env.service.ResetPassword(context.Background(), &dto.ResetPasswordRequest{Token: token, NewPassword: "new-secret"}, testClient)
+}
+
+func TestResetPasswordTokenSingleUse(t *testing.T) {
+	env := newPasswordResetTestEnv(t)
+	token := env.forgot(t)
+
+	err := env.reset(token)
+
+	if err != nil {
+		t.Fatalf("ResetPassword = %v", err)
+	}
+
+	if len(env.users.passwords) != 1 {
+		t.Fatalf("password updated %d times, want 1", len(env.users.passwords))
+	}
+
+	if _, ok := env.revoked.revokedBefore[7]; !ok {
+		t.Error("access tokens not revoked after reset")
+	}
+
+	err = env.reset(token)
+
+	if !errors.Is(err, ErrPasswordResetTokenInvalid) {
+		t.Fatalf("second ResetPassword = %v, want %v", err, ErrPasswordResetTokenInvalid)
+	}
+
+	if len(env.users.passwords) != 1 {
+		t.Fatalf("password updated %d times after reuse, want 1", len(env.users.passwords))
+	}
+}
+
+func TestResetPasswordTokenExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		valid   bool
+	}{
+		{"fresh", time.Minute, true},
+		{"just before expiry", 30*time.Minute - time.Second, true},
+		{"at expiry", 30 * time.Minute, false},
+		{"long expired", 24 * time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPasswordResetTestEnv(t)
+			token := env.forgot(t)
+
+			env.resets.now = env.resets.now.Add(tt.elapsed)
+
+			err := env.reset(token)
+
+			if tt.valid && err != nil {
+				t.Fatalf("ResetPassword = %v", err)
+			}
+
+			if !tt.valid && !errors.Is(err, ErrPasswordResetTokenInvalid) {
+				t.Fatalf("ResetPassword = %v, want %v", err, ErrPasswordResetTokenInvalid)
+			}
+		})
+	}
+}
+
+func TestResetPasswordSupersededToken(t *testing.T) {
+	env := newPasswordResetTestEnv(t)
+
+	first := env.forgot(t)
+	second := env.forgot(t)
+
+	err := env.reset(first)
+
+	if !errors.Is(err, ErrPasswordResetTokenInvalid) {
+		t.Fatalf("ResetPassword with superseded token = %v, want %v", err, ErrPasswordResetTokenInvalid)
+	}
+
+	err = env.reset(second)
+
+	if err != nil {
+		t.Fatalf("ResetPassword with latest token = %v", err)
+	}
+}
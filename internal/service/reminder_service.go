@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ ReminderService = (*reminderService)(nil)
+
+// ReminderService 管理习惯的提醒规则，提醒由 scheduler.ReminderScheduler 在后台投递
+type ReminderService interface {
+	CreateReminder(ctx context.Context, uid uint64, hid uint64, req *dto.CreateReminderRequest) (*model.HabitReminder, error)
+	UpdateReminder(ctx context.Context, uid uint64, hid uint64, rid uint64, req *dto.UpdateReminderRequest) (*model.HabitReminder, error)
+	DeleteReminder(ctx context.Context, uid uint64, hid uint64, rid uint64) error
+	ListReminders(ctx context.Context, uid uint64, hid uint64) ([]*model.HabitReminder, error)
+	SnoozeReminder(ctx context.Context, uid uint64, hid uint64, rid uint64, req *dto.SnoozeReminderRequest) (*model.HabitReminder, error)
+}
+
+type reminderService struct {
+	habitRepository         repository.HabitRepository
+	habitReminderRepository repository.HabitReminderRepository
+	userRepository          repository.UserRepository
+}
+
+func NewReminderService(
+	habitRepository repository.HabitRepository,
+	habitReminderRepository repository.HabitReminderRepository,
+	userRepository repository.UserRepository,
+) ReminderService {
+	return &reminderService{
+		habitRepository:         habitRepository,
+		habitReminderRepository: habitReminderRepository,
+		userRepository:          userRepository,
+	}
+}
+
+func (s *reminderService) CreateReminder(ctx context.Context, uid uint64, hid uint64, req *dto.CreateReminderRequest) (*model.HabitReminder, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reminder := model.HabitReminder{
+		HabitID: habit.ID,
+		UserID:  habit.UserID,
+	}
+
+	err = s.apply(ctx, &reminder, req.Times, req.Weekdays, req.Channel, req.Target, req.SnoozeMinutes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.habitReminderRepository.Create(ctx, &reminder)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reminder, nil
+}
+
+func (s *reminderService) UpdateReminder(ctx context.Context, uid uint64, hid uint64, rid uint64, req *dto.UpdateReminderRequest) (*model.HabitReminder, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	reminder, err := s.getReminder(ctx, uid, hid, rid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(ctx, reminder, req.Times, req.Weekdays, req.Channel, req.Target, req.SnoozeMinutes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.habitReminderRepository.Update(ctx, reminder)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (s *reminderService) DeleteReminder(ctx context.Context, uid uint64, hid uint64, rid uint64) error {
+	reminder, err := s.getReminder(ctx, uid, hid, rid)
+
+	if err != nil {
+		return err
+	}
+
+	return s.habitReminderRepository.Delete(ctx, reminder.ID)
+}
+
+func (s *reminderService) ListReminders(ctx context.Context, uid uint64, hid uint64) ([]*model.HabitReminder, error) {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.habitReminderRepository.ListByHabit(ctx, habit.ID)
+}
+
+// SnoozeReminder 在 Minutes 分钟后再次提醒，未指定时长时依次使用提醒规则和全局的默认值
+func (s *reminderService) SnoozeReminder(ctx context.Context, uid uint64, hid uint64, rid uint64, req *dto.SnoozeReminderRequest) (*model.HabitReminder, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	reminder, err := s.getReminder(ctx, uid, hid, rid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	minutes := req.Minutes
+
+	if minutes == 0 {
+		minutes = reminder.SnoozeMinutes
+	}
+
+	if minutes == 0 {
+		minutes = def.ReminderDefaultSnoozeMinutes
+	}
+
+	until := time.Now().UTC().Add(time.Duration(minutes) * time.Minute).Truncate(time.Minute)
+	reminder.SnoozedUntil = &until
+
+	err = s.habitReminderRepository.Update(ctx, reminder)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (s *reminderService) apply(ctx context.Context, reminder *model.HabitReminder, times []string, weekdays []int, channel string, target string, snoozeMinutes int) error {
+	if len(times) == 0 || len(times) > def.ReminderMaxTimes {
+		return response.Validation("invalid reminder times")
+	}
+
+	parsed := make(model.ReminderTimes, 0, len(times))
+
+	for _, t := range times {
+		m, err := model.ParseReminderTime(t)
+
+		if err != nil {
+			return response.Validation("invalid reminder time")
+		}
+
+		if !parsed.Has(m) {
+			parsed = append(parsed, m)
+		}
+	}
+
+	sort.Ints(parsed)
+
+	days := make([]time.Weekday, 0, len(weekdays))
+
+	for _, d := range weekdays {
+		if d < 0 || d > 6 {
+			return response.Validation("invalid weekday")
+		}
+
+		days = append(days, time.Weekday(d))
+	}
+
+	if channel == "" {
+		channel = def.ReminderChannelInbox
+	}
+
+	switch channel {
+	case def.ReminderChannelInbox:
+		target = ""
+	case def.ReminderChannelEmail:
+		// 邮件提醒只发送到用户本人已验证的邮箱，避免借助提醒向第三方反复发信
+		email, err := s.verifiedEmail(ctx, reminder.UserID)
+
+		if err != nil {
+			return err
+		}
+
+		if target != "" {
+			addr, err := mail.ParseAddress(target)
+
+			if err != nil {
+				return response.Validation("invalid reminder email")
+			}
+
+			if !strings.EqualFold(addr.Address, email) {
+				return response.Validation("reminder email must be your verified email")
+			}
+		}
+
+		target = email
+	case def.ReminderChannelWebhook:
+		u, err := url.Parse(target)
+
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return response.Validation("invalid reminder webhook url")
+		}
+
+		// 投递时 notifier 还会在建立连接前再次检查，这里提前拒绝明显指向内网的地址
+		err = utils.CheckPublicHost(ctx, u.Hostname())
+
+		if err != nil {
+			return response.Validation("reminder webhook url must resolve to a public address")
+		}
+	default:
+		return response.Validation("invalid reminder channel")
+	}
+
+	if snoozeMinutes < 0 || snoozeMinutes > def.ReminderMaxSnoozeMinutes {
+		return response.Validation("invalid reminder snooze minutes")
+	}
+
+	reminder.Times = parsed
+	reminder.Weekdays = model.NewWeekdays(days...)
+	reminder.Channel = channel
+	reminder.Target = target
+	reminder.SnoozeMinutes = snoozeMinutes
+
+	return nil
+}
+
+// verifiedEmail 返回用户已验证的邮箱，未设置或未验证邮箱时不能使用邮件提醒
+func (s *reminderService) verifiedEmail(ctx context.Context, uid uint64) (string, error) {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
+		return "", err
+	}
+
+	if user == nil {
+		return "", response.NotFound("user not found")
+	}
+
+	if user.Email == nil || user.EmailVerifiedAt == nil {
+		return "", response.Validation("verify your email before enabling email reminders")
+	}
+
+	return *user.Email, nil
+}
+
+// getReminder 查询习惯下的提醒规则并校验习惯归属于 uid
+func (s *reminderService) getReminder(ctx context.Context, uid uint64, hid uint64, rid uint64) (*model.HabitReminder, error) {
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reminder, err := s.habitReminderRepository.GetByID(ctx, rid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("habitReminderRepository.GetByID", zap.Error(err))
+		return nil, err
+	}
+
+	if reminder == nil || reminder.HabitID != habit.ID {
+		return nil, response.NotFound("reminder not found")
+	}
+
+	return reminder, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+	"w2learn/pkg/response"
+)
+
+func TestApplyReminderWebhookTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		valid  bool
+	}{
+		{"https://93.184.216.34/hooks/habit", true},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook", true},
+		{"ftp://93.184.216.34/hook", false},
+		{"https:///hook", false},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://localhost/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://10.0.0.5/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			s := &reminderService{}
+			reminder := &model.HabitReminder{}
+
+			err := s.apply(context.Background(), reminder, []string{"08:00"}, nil, def.ReminderChannelWebhook, tt.target, 0)
+
+			if tt.valid {
+				if err != nil || reminder.Target != tt.target {
+					t.Fatalf("apply = %v, target %q", err, reminder.Target)
+				}
+				return
+			}
+
+			if appErr := response.FromError(err); err == nil || appErr.Code != response.CodeValidationFailed {
+				t.Fatalf("apply = %v, want a validation error", err)
+			}
+		})
+	}
+}
+
+func TestApplyReminderEmailTarget(t *testing.T) {
+	email := "alice@example.com"
+	verifiedAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	verified := &model.User{ID: 7, Email: &email, EmailVerifiedAt: &verifiedAt}
+	unverified := &model.User{ID: 7, Email: &email}
+
+	tests := []struct {
+		name   string
+		user   *model.User
+		target string
+		want   string
+		code   string
+	}{
+		{"defaults to verified email", verified, "", email, ""},
+		{"own verified email", verified, "alice@example.com", email, ""},
+		{"own email with display name and case", verified, "Alice <ALICE@example.com>", email, ""},
+		{"third party address", verified, "victim@example.org", "", response.CodeValidationFailed},
+		{"malformed address", verified, "not an email", "", response.CodeValidationFailed},
+		{"email not verified", unverified, "alice@example.com", "", response.CodeValidationFailed},
+		{"no email", &model.User{ID: 7}, "", "", response.CodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &reminderService{userRepository: &stubUserRepository{user: tt.user}}
+			reminder := &model.HabitReminder{UserID: 7}
+
+			err := s.apply(context.Background(), reminder, []string{"08:00"}, nil, def.ReminderChannelEmail, tt.target, 0)
+
+			if tt.code == "" {
+				if err != nil || reminder.Target != tt.want {
+					t.Fatalf("apply = %v, target %q, want %q", err, reminder.Target, tt.want)
+				}
+				return
+			}
+
+			if err == nil || response.FromError(err).Code != tt.code {
+				t.Fatalf("apply = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestApplyReminderInvalidTime(t *testing.T) {
+	for _, value := range []string{"25:00", "8am", "08:60", ""} {
+		t.Run(value, func(t *testing.T) {
+			s := &reminderService{}
+			reminder := &model.HabitReminder{}
+
+			err := s.apply(context.Background(), reminder, []string{value}, nil, def.ReminderChannelInbox, "", 0)
+
+			if appErr := response.FromError(err); err == nil || appErr.Code != response.CodeValidationFailed {
+				t.Fatalf("apply = %v, want a validation error", err)
+			}
+		})
+	}
+}
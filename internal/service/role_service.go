@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ RoleService = (*roleService)(nil)
+
+var (
+	ErrRoleNotFound  = response.NotFound("role not found")
+	ErrRoleExists    = response.Conflict("role already exists")
+	ErrRoleBuiltin   = response.Forbidden("builtin role cannot be modified")
+	ErrRoleInUse     = response.Conflict("role is assigned to users")
+	ErrRoleSelfAdmin = response.Forbidden("cannot remove admin role from yourself")
+)
+
+// RoleService 管理角色与用户的角色分配，并为权限中间件提供权限判断
+type RoleService interface {
+	EnsureBuiltinRoles(ctx context.Context) error
+	GrantAdmins(ctx context.Context, usernames []string) error
+	ListRoles(ctx context.Context) ([]*model.Role, error)
+	CreateRole(ctx context.Context, actor *dto.Actor, req *dto.CreateRoleRequest) (*model.Role, error)
+	UpdateRole(ctx context.Context, actor *dto.Actor, id uint64, req *dto.UpdateRoleRequest) (*model.Role, error)
+	DeleteRole(ctx context.Context, actor *dto.Actor, id uint64) error
+	SetUserRoles(ctx context.Context, actor *dto.Actor, uid uint64, req *dto.SetUserRolesRequest) (*model.User, error)
+	HasPermission(ctx context.Context, roles []string, permission string) (bool, error)
+}
+
+type roleService struct {
+	roleRepository                repository.RoleRepository
+	userRepository                repository.UserRepository
+	userSessionRepository         repository.UserSessionRepository
+	refreshTokenRepository        repository.RefreshTokenRepository
+	auditLogRepository            repository.AuditLogRepository
+	tokenRevocationRepository     repository.TokenRevocationRepository
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository
+}
+
+func NewRoleService(
+	roleRepository repository.RoleRepository,
+	userRepository repository.UserRepository,
+	userSessionRepository repository.UserSessionRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	auditLogRepository repository.AuditLogRepository,
+	tokenRevocationRepository repository.TokenRevocationRepository,
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository,
+) RoleService {
+	return &roleService{
+		roleRepository:                roleRepository,
+		userRepository:                userRepository,
+		userSessionRepository:         userSessionRepository,
+		refreshTokenRepository:        refreshTokenRepository,
+		auditLogRepository:            auditLogRepository,
+		tokenRevocationRepository:     tokenRevocationRepository,
+		personalAccessTokenRepository: personalAccessTokenRepository,
+	}
+}
+
+// EnsureBuiltinRoles 创建缺失的内置角色
+func (s *roleService) EnsureBuiltinRoles(ctx context.Context) error {
+	builtins := []*model.Role{
+		{
+			Name:        def.RoleUser,
+			Description: "default role for registered users",
+			Permissions: model.StringList{},
+			Builtin:     true,
+		},
+		{
+			Name:        def.RoleAdmin,
+			Description: "administrator with all permissions",
+			Permissions: model.StringList{def.PermissionAll},
+			Builtin:     true,
+		},
+	}
+
+	for _, role := range builtins {
+		_, err := s.roleRepository.GetByName(ctx, role.Name)
+
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		err = s.roleRepository.Create(ctx, role)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GrantAdmins 为配置中指定的用户授予管理员角色，用于初始化首个管理员
+func (s *roleService) GrantAdmins(ctx context.Context, usernames []string) error {
+	for _, username := range usernames {
+		user, err := s.userRepository.GetByUsername(ctx, username)
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Admin user not found", zap.String("username", username))
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if user.Roles.Has(def.RoleAdmin) {
+			continue
+		}
+
+		err = s.userRepository.UpdateRoles(ctx, user.ID, append(user.Roles, def.RoleAdmin))
+
+		if err != nil {
+			return err
+		}
+
+		logger.Info("Granted admin role", zap.String("username", username), zap.Uint64("user_id", user.ID))
+	}
+
+	return nil
+}
+
+func (s *roleService) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	return s.roleRepository.ListAll(ctx)
+}
+
+func (s *roleService) CreateRole(ctx context.Context, actor *dto.Actor, req *dto.CreateRoleRequest) (*model.Role, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	role, err := s.createRole(ctx, req)
+
+	var id uint64
+	if role != nil {
+		id = role.ID
+	}
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionRoleCreate, id, err)
+
+	return role, err
+}
+
+func (s *roleService) createRole(ctx context.Context, req *dto.CreateRoleRequest) (*model.Role, error) {
+	permissions, err := normalizePermissions(req.Permissions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.roleRepository.GetByName(ctx, req.Name)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("roleRepository.GetByName", zap.Error(err))
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, ErrRoleExists
+	}
+
+	role := &model.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+
+	err = s.roleRepository.Create(ctx, role)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(ctx context.Context, actor *dto.Actor, id uint64, req *dto.UpdateRoleRequest) (*model.Role, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	role, err := s.updateRole(ctx, id, req)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionRoleUpdate, id, err)
+
+	return role, err
+}
+
+func (s *roleService) updateRole(ctx context.Context, id uint64, req *dto.UpdateRoleRequest) (*model.Role, error) {
+	role, err := s.getRole(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Builtin {
+		return nil, ErrRoleBuiltin
+	}
+
+	permissions, err := normalizePermissions(req.Permissions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	role.Description = req.Description
+	role.Permissions = permissions
+
+	err = s.roleRepository.Update(ctx, role)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(ctx context.Context, actor *dto.Actor, id uint64) error {
+	err := s.deleteRole(ctx, id)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionRoleDelete, id, err)
+
+	return err
+}
+
+func (s *roleService) deleteRole(ctx context.Context, id uint64) error {
+	role, err := s.getRole(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if role.Builtin {
+		return ErrRoleBuiltin
+	}
+
+	count, err := s.userRepository.CountByRole(ctx, role.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	return s.roleRepository.Delete(ctx, role.ID)
+}
+
+// SetUserRoles 替换用户的角色，撤销其全部会话、已签发的访问令牌与个人访问令牌，新的角色在重新登录后生效
+func (s *roleService) SetUserRoles(ctx context.Context, actor *dto.Actor, uid uint64, req *dto.SetUserRolesRequest) (*model.User, error) {
+	if actor == nil || req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	user, err := s.setUserRoles(ctx, actor.UserID, uid, req)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionUserRoles, uid, err)
+
+	return user, err
+}
+
+func (s *roleService) setUserRoles(ctx context.Context, operatorID uint64, uid uint64, req *dto.SetUserRolesRequest) (*model.User, error) {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, response.NotFound("user not found")
+	}
+
+	names := slices.Compact(slices.Sorted(slices.Values(req.Roles)))
+
+	roles, err := s.roleRepository.ListByNames(ctx, names)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) != len(names) {
+		return nil, ErrRoleNotFound
+	}
+
+	if operatorID == uid && user.Roles.Has(def.RoleAdmin) && !slices.Contains(names, def.RoleAdmin) {
+		return nil, ErrRoleSelfAdmin
+	}
+
+	err = s.userRepository.UpdateRoles(ctx, user.ID, names)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = revokeUserTokens(ctx, s.userSessionRepository, s.refreshTokenRepository, s.tokenRevocationRepository, s.personalAccessTokenRepository, user.ID, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("User roles changed",
+		zap.Uint64("operator_id", operatorID),
+		zap.Uint64("user_id", user.ID),
+		zap.Strings("roles", names),
+	)
+
+	user.Roles = names
+
+	return user, nil
+}
+
+func (s *roleService) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	list, err := s.roleRepository.ListByNames(ctx, roles)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range list {
+		if role.HasPermission(permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *roleService) getRole(ctx context.Context, id uint64) (*model.Role, error) {
+	role, err := s.roleRepository.GetByID(ctx, id)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("roleRepository.GetByID", zap.Error(err))
+		return nil, err
+	}
+
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	return role, nil
+}
+
+// normalizePermissions 校验权限名并去重
+func normalizePermissions(permissions []string) (model.StringList, error) {
+	for _, permission := range permissions {
+		if !slices.Contains(def.Permissions, permission) {
+			return nil, response.Validation("unknown permission: " + permission)
+		}
+	}
+
+	return slices.Compact(slices.Sorted(slices.Values(permissions))), nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/pkg/def"
+)
+
+type stubRoleRepository struct {
+	repository.RoleRepository
+	roles []*model.Role
+}
+
+func (r *stubRoleRepository) ListByNames(ctx context.Context, names []string) ([]*model.Role, error) {
+	roles := make([]*model.Role, 0, len(names))
+
+	for _, role := range r.roles {
+		for _, name := range names {
+			if role.Name == name {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	return roles, nil
+}
+
+func TestSetUserRolesRevokesTokens(t *testing.T) {
+	now := time.Now().UTC()
+
+	sessions := newStubUserSessionRepository()
+	sessions.sessions["s1"] = &model.UserSession{ID: "s1", UserID: 7, ExpiresAt: now.Add(time.Hour)}
+	refreshTokens := newStubRefreshTokenRepository()
+	refreshTokens.families["s1"] = true
+	revocations := newStubTokenRevocationRepository()
+	accessTokens := newStubPersonalAccessTokenRepository()
+	accessTokens.tokens["pat"] = &model.PersonalAccessToken{ID: 1, UserID: 7, ExpiresAt: now.Add(time.Hour)}
+
+	s := NewRoleService(
+		&stubRoleRepository{roles: []*model.Role{{Name: def.RoleUser}, {Name: def.RoleAdmin}}},
+		&stubUserRepository{user: &model.User{ID: 7, Username: "alice", Roles: model.StringList{def.RoleUser, def.RoleAdmin}}},
+		sessions,
+		refreshTokens,
+		&stubAuditLogRepository{},
+		revocations,
+		accessTokens,
+	)
+
+	user, err := s.SetUserRoles(context.Background(), &dto.Actor{UserID: 1}, 7, &dto.SetUserRolesRequest{Roles: []string{def.RoleUser}})
+
+	if err != nil || !user.Roles.Has(def.RoleUser) || user.Roles.Has(def.RoleAdmin) {
+		t.Fatalf("SetUserRoles = %+v, %v", user, err)
+	}
+
+	// 降权后旧的访问令牌、刷新令牌与个人访问令牌都不能继续携带原有角色
+	if _, ok := revocations.revokedBefore[7]; !ok {
+		t.Error("access token watermark not set")
+	}
+
+	if sessions.sessions["s1"].RevokedAt == nil || refreshTokens.families["s1"] {
+		t.Error("session not revoked")
+	}
+
+	if accessTokens.tokens["pat"].RevokedAt == nil {
+		t.Error("personal access token not revoked")
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	s := &roleService{roleRepository: &stubRoleRepository{roles: []*model.Role{
+		{Name: def.RoleUser, Permissions: model.StringList{}},
+		{Name: def.RoleAdmin, Permissions: model.StringList{def.PermissionAll}},
+		{Name: "auditor", Permissions: model.StringList{def.PermissionAuditRead}},
+	}}}
+
+	tests := []struct {
+		roles      []string
+		permission string
+		want       bool
+	}{
+		{nil, def.PermissionUserRead, false},
+		{[]string{def.RoleUser}, def.PermissionUserRead, false},
+		{[]string{def.RoleAdmin}, def.PermissionRoleWrite, true},
+		{[]string{"auditor"}, def.PermissionAuditRead, true},
+		{[]string{"auditor"}, def.PermissionUserWrite, false},
+		{[]string{def.RoleUser, "auditor"}, def.PermissionAuditRead, true},
+		{[]string{"deleted-role"}, def.PermissionAuditRead, false},
+	}
+
+	for _, tt := range tests {
+		got, err := s.HasPermission(context.Background(), tt.roles, tt.permission)
+
+		if err != nil || got != tt.want {
+			t.Errorf("HasPermission(%v, %s) = %v, %v, want %v", tt.roles, tt.permission, got, err, tt.want)
+		}
+	}
+}
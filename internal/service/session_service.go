@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/repository"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ SessionService = (*sessionService)(nil)
+
+var ErrSessionNotFound = response.NotFound("session not found")
+
+// SessionService 管理用户的设备会话，撤销会话会同时作废其令牌族
+type SessionService interface {
+	ListSessions(ctx context.Context, uid uint64, currentID string) ([]*dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, uid uint64, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, uid uint64, currentID string) error
+}
+
+type sessionService struct {
+	userSessionRepository  repository.UserSessionRepository
+	refreshTokenRepository repository.RefreshTokenRepository
+	redisClient            *redis.Client
+}
+
+func NewSessionService(
+	userSessionRepository repository.UserSessionRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	redisClient *redis.Client,
+) SessionService {
+	return &sessionService{
+		userSessionRepository:  userSessionRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		redisClient:            redisClient,
+	}
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, uid uint64, currentID string) ([]*dto.SessionResponse, error) {
+	sessions, err := s.userSessionRepository.ListActiveByUser(ctx, uid, time.Now().UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.SessionResponse, 0, len(sessions))
+
+	if len(sessions) == 0 {
+		return result, nil
+	}
+
+	// 最近访问时间由认证中间件写入 Redis，比数据库中的记录更新
+	keys := make([]string, len(sessions))
+	for i, session := range sessions {
+		keys[i] = fmt.Sprintf(def.SessionSeenKeyLayout, session.ID)
+	}
+
+	seen, err := s.redisClient.MGet(ctx, keys...).Result()
+
+	if err != nil {
+		logger.Warn("Failed to load session last seen", zap.Error(err))
+		seen = make([]any, len(sessions))
+	}
+
+	for i, session := range sessions {
+		lastSeen := session.LastSeenAt
+
+		if str, ok := seen[i].(string); ok {
+			unix, err := strconv.ParseInt(str, 10, 64)
+
+			if err == nil && time.Unix(unix, 0).After(lastSeen) {
+				lastSeen = time.Unix(unix, 0).UTC()
+			}
+		}
+
+		result = append(result, &dto.SessionResponse{
+			ID:         session.ID,
+			Device:     session.Device,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: lastSeen,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentID,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, uid uint64, sessionID string) error {
+	session, err := s.userSessionRepository.GetBySessionID(ctx, sessionID)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userSessionRepository.GetBySessionID", zap.Error(err))
+		return err
+	}
+
+	if session == nil || session.UserID != uid || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	return revokeSessions(ctx, s.userSessionRepository, s.refreshTokenRepository, session.ID)
+}
+
+// RevokeOtherSessions 撤销除当前会话外的全部会话
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, uid uint64, currentID string) error {
+	return revokeUserSessions(ctx, s.userSessionRepository, s.refreshTokenRepository, uid, currentID)
+}
+
+// revokeSessions 标记会话已撤销并作废对应的令牌族，使其访问令牌与刷新令牌立即失效
+func revokeSessions(
+	ctx context.Context,
+	userSessionRepository repository.UserSessionRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	ids ...string,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := userSessionRepository.Revoke(ctx, ids, time.Now().UTC())
+
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err = refreshTokenRepository.RevokeFamily(ctx, id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeUserSessions 撤销用户除 exceptID 外的全部有效会话，exceptID 为空时全部撤销
+func revokeUserSessions(
+	ctx context.Context,
+	userSessionRepository repository.UserSessionRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	uid uint64,
+	exceptID string,
+) error {
+	sessions, err := userSessionRepository.ListActiveByUser(ctx, uid, time.Now().UTC())
+
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if session.ID != exceptID {
+			ids = append(ids, session.ID)
+		}
+	}
+
+	return revokeSessions(ctx, userSessionRepository, refreshTokenRepository, ids...)
+}
+
+// revokeUserTokens 在 revokeUserSessions 的基础上写入令牌失效水位，使已签发的访问令牌立即失效，并撤销全部个人访问令牌
+// 保留的会话 exceptID 需要使用刷新令牌换取新的访问令牌
+func revokeUserTokens(
+	ctx context.Context,
+	userSessionRepository repository.UserSessionRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	tokenRevocationRepository repository.TokenRevocationRepository,
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository,
+	uid uint64,
+	exceptID string,
+) error {
+	now := time.Now()
+
+	err := tokenRevocationRepository.RevokeBefore(ctx, uid, now)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = personalAccessTokenRepository.RevokeAllByUser(ctx, uid, now.UTC())
+
+	if err != nil {
+		return err
+	}
+
+	return revokeUserSessions(ctx, userSessionRepository, refreshTokenRepository, uid, exceptID)
+}
+
+// truncateRunes 按字符截断字符串，避免超出数据库字段长度
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n])
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ StatsService = (*statsService)(nil)
+
+// statsRateWindows 为完成率统计的时间窗口（天）
+var statsRateWindows = []int{7, 30, 90, 365}
+
+// StatsService 统计习惯的完成情况，打卡数在数据库中聚合，计划要求的天数由重复规则计算
+type StatsService interface {
+	GetHabitStats(ctx context.Context, uid uint64, hid uint64, req *dto.StatsRequest) (*dto.StatsResponse, error)
+	GetUserStats(ctx context.Context, uid uint64, req *dto.StatsRequest) (*dto.StatsResponse, error)
+}
+
+type statsService struct {
+	habitRepository        repository.HabitRepository
+	userRepository         repository.UserRepository
+	habitCheckInRepository repository.HabitCheckInRepository
+}
+
+func NewStatsService(
+	habitRepository repository.HabitRepository,
+	userRepository repository.UserRepository,
+	habitCheckInRepository repository.HabitCheckInRepository,
+) StatsService {
+	return &statsService{
+		habitRepository:        habitRepository,
+		userRepository:         userRepository,
+		habitCheckInRepository: habitCheckInRepository,
+	}
+}
+
+func (s *statsService) GetHabitStats(ctx context.Context, uid uint64, hid uint64, req *dto.StatsRequest) (*dto.StatsResponse, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	habit, err := getOwnedHabit(ctx, s.habitRepository, uid, hid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	today, err := s.getToday(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.stats(ctx, []*model.Habit{habit}, today, req.Days)
+}
+
+// GetUserStats 汇总用户全部习惯的统计，包含已暂停和已归档的习惯
+func (s *statsService) GetUserStats(ctx context.Context, uid uint64, req *dto.StatsRequest) (*dto.StatsResponse, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	today, err := s.getToday(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	habits, err := s.habitRepository.ListByUserID(ctx, uid, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.stats(ctx, habits, today, req.Days)
+}
+
+func (s *statsService) getToday(ctx context.Context, uid uint64) (time.Time, error) {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
+		return time.Time{}, err
+	}
+
+	if user == nil {
+		return time.Time{}, response.NotFound("user not found")
+	}
+
+	return utils.UserClock(user).Today(), nil
+}
+
+func (s *statsService) stats(ctx context.Context, habits []*model.Habit, today time.Time, days int) (*dto.StatsResponse, error) {
+	if days <= 0 {
+		days = def.StatsHeatmapDefaultDays
+	}
+
+	if days > def.StatsHeatmapMaxDays {
+		days = def.StatsHeatmapMaxDays
+	}
+
+	ids := make([]uint64, 0, len(habits))
+
+	for _, habit := range habits {
+		ids = append(ids, habit.ID)
+	}
+
+	from := today.AddDate(0, 0, -(days - 1))
+
+	result := &dto.StatsResponse{
+		From:       from,
+		To:         today,
+		HabitCount: len(habits),
+	}
+
+	var err error
+
+	result.Rates, err = s.rates(ctx, habits, ids, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Heatmap, err = s.heatmap(ctx, ids, from, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Weekdays, err = s.weekdays(ctx, ids, from, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Trend, err = s.trend(ctx, habits, ids, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *statsService) rates(ctx context.Context, habits []*model.Habit, ids []uint64, today time.Time) ([]*dto.CompletionRateResponse, error) {
+	froms := make([]time.Time, 0, len(statsRateWindows))
+
+	for _, window := range statsRateWindows {
+		froms = append(froms, today.AddDate(0, 0, -(window-1)))
+	}
+
+	counts, err := s.habitCheckInRepository.CountSinceEach(ctx, ids, froms, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]*dto.CompletionRateResponse, 0, len(statsRateWindows))
+
+	for i, window := range statsRateWindows {
+		expected, err := s.expected(habits, froms[i], today)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, &dto.CompletionRateResponse{
+			Days:      window,
+			Completed: counts[i],
+			Expected:  expected,
+			Rate:      completionRate(counts[i], expected),
+		})
+	}
+
+	return rates, nil
+}
+
+// heatmap 返回 [from, to] 内每一天的格子，没有打卡的日期计为 0
+func (s *statsService) heatmap(ctx context.Context, ids []uint64, from time.Time, to time.Time) ([]*dto.HeatmapCellResponse, error) {
+	counts, err := s.habitCheckInRepository.CountByDay(ctx, ids, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	countMap := make(map[string]*repository.DayCount, len(counts))
+
+	for _, count := range counts {
+		countMap[utils.FormatDay(count.Day)] = count
+	}
+
+	cells := make([]*dto.HeatmapCellResponse, 0, len(countMap))
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		cell := &dto.HeatmapCellResponse{Day: day}
+
+		if count, ok := countMap[utils.FormatDay(day)]; ok {
+			cell.Completed = count.Completed
+			cell.Amount = count.Amount
+		}
+
+		cells = append(cells, cell)
+	}
+
+	return cells, nil
+}
+
+func (s *statsService) weekdays(ctx context.Context, ids []uint64, from time.Time, to time.Time) ([]*dto.WeekdayStatsResponse, error) {
+	counts, err := s.habitCheckInRepository.CountByWeekday(ctx, ids, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	weekdays := make([]*dto.WeekdayStatsResponse, 0, 7)
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		weekdays = append(weekdays, &dto.WeekdayStatsResponse{Weekday: int(d)})
+	}
+
+	var total int64
+
+	for _, count := range counts {
+		if count.Weekday < 0 || count.Weekday > 6 {
+			continue
+		}
+
+		weekdays[count.Weekday].Completed = count.Completed
+		total += count.Completed
+	}
+
+	if total > 0 {
+		for _, weekday := range weekdays {
+			weekday.Percent = float64(weekday.Completed) / float64(total)
+		}
+	}
+
+	return weekdays, nil
+}
+
+// trend 返回最近 def.StatsTrendWeeks 周（含本周）的完成情况及其与上一周的差值
+func (s *statsService) trend(ctx context.Context, habits []*model.Habit, ids []uint64, today time.Time) ([]*dto.WeekTrendResponse, error) {
+	// 多查询一周作为第一周的比较基准
+	first := utils.WeekStart(today).AddDate(0, 0, -7*def.StatsTrendWeeks)
+
+	counts, err := s.habitCheckInRepository.CountByWeek(ctx, ids, first, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	countMap := make(map[string]int64, len(counts))
+
+	for _, count := range counts {
+		countMap[utils.FormatDay(count.Day)] = count.Completed
+	}
+
+	trend := make([]*dto.WeekTrendResponse, 0, def.StatsTrendWeeks)
+
+	var prev *dto.WeekTrendResponse
+
+	for i := 0; i <= def.StatsTrendWeeks; i++ {
+		start := first.AddDate(0, 0, 7*i)
+		end := start.AddDate(0, 0, 6)
+
+		if end.After(today) {
+			end = today
+		}
+
+		expected, err := s.expected(habits, start, end)
+
+		if err != nil {
+			return nil, err
+		}
+
+		week := &dto.WeekTrendResponse{
+			WeekStart: start,
+			Completed: countMap[utils.FormatDay(start)],
+			Expected:  expected,
+		}
+		week.Rate = completionRate(week.Completed, week.Expected)
+
+		if prev != nil {
+			week.CompletedDelta = week.Completed - prev.Completed
+			week.RateDelta = week.Rate - prev.Rate
+			trend = append(trend, week)
+		}
+
+		prev = week
+	}
+
+	return trend, nil
+}
+
+func (s *statsService) expected(habits []*model.Habit, from time.Time, to time.Time) (int, error) {
+	total := 0
+
+	for _, habit := range habits {
+		expected, err := utils.ExpectedHabitDays(habit, from, to)
+
+		if err != nil {
+			return 0, err
+		}
+
+		total += expected
+	}
+
+	return total, nil
+}
+
+func completionRate(completed int64, expected int) float64 {
+	if expected <= 0 {
+		return 0
+	}
+
+	return min(float64(completed)/float64(expected), 1)
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 本文件为服务层测试共用的内存仓库，只实现被测流程用到的方法，其余方法调用时由嵌入的空接口触发 panic
+
+var jwtOnce sync.Once
+
+// setupJWT 以 HS256 初始化全局的 JWT 签发配置，只执行一次
+func setupJWT(t *testing.T) {
+	t.Helper()
+
+	var err error
+
+	jwtOnce.Do(func() {
+		err = utils.InitJwt(&utils.JWTConfig{Secret: "service-test-secret"})
+	})
+
+	if err != nil {
+		t.Fatalf("InitJwt: %v", err)
+	}
+}
+
+type stubUserRepository struct {
+	repository.UserRepository
+	user      *model.User
+	passwords []string
+	updates   int
+}
+
+func (r *stubUserRepository) GetByID(ctx context.Context, id uint64) (*model.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.user, nil
+}
+
+func (r *stubUserRepository) GetPlainByID(ctx context.Context, id uint64) (*model.User, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *stubUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	if r.user == nil || r.user.Username != username {
+		return nil, nil
+	}
+
+	return r.user, nil
+}
+
+func (r *stubUserRepository) Update(ctx context.Context, user *model.User) error {
+	r.updates++
+	return nil
+}
+
+func (r *stubUserRepository) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	r.passwords = append(r.passwords, password)
+	return nil
+}
+
+func (r *stubUserRepository) UpdateRoles(ctx context.Context, id uint64, roles model.StringList) error {
+	r.user.Roles = roles
+	return nil
+}
+
+// stubLoginAttemptRepository 按范围与主体计数登录失败，被锁定的用户名在 RetryAfter 中返回锁定
+type stubLoginAttemptRepository struct {
+	repository.LoginAttemptRepository
+	failures map[string]int64
+	locked   map[string]bool
+	resets   int
+}
+
+func newStubLoginAttemptRepository() *stubLoginAttemptRepository {
+	return &stubLoginAttemptRepository{
+		failures: map[string]int64{},
+		locked:   map[string]bool{},
+	}
+}
+
+func (r *stubLoginAttemptRepository) RetryAfter(ctx context.Context, username string, ip string) (time.Duration, bool, error) {
+	if r.locked[username] {
+		return time.Minute, true, nil
+	}
+
+	return 0, false, nil
+}
+
+func (r *stubLoginAttemptRepository) RecordFailure(ctx context.Context, scope string, subject string, at time.Time, window time.Duration) (int64, error) {
+	r.failures[scope+":"+subject]++
+	return r.failures[scope+":"+subject], nil
+}
+
+func (r *stubLoginAttemptRepository) SetBackoff(ctx context.Context, scope string, subject string, ttl time.Duration) error {
+	return nil
+}
+
+func (r *stubLoginAttemptRepository) Lock(ctx context.Context, username string, ttl time.Duration) error {
+	r.locked[username] = true
+	return nil
+}
+
+func (r *stubLoginAttemptRepository) Reset(ctx context.Context, username string) error {
+	for key := range r.failures {
+		if key == "user:"+username {
+			delete(r.failures, key)
+		}
+	}
+
+	r.resets++
+	return nil
+}
+
+// stubTwoFactorRepository 返回 twoFactor 作为用户的 TOTP 配置，并按 UseCounter 的语义记录最后使用的时间步
+type stubTwoFactorRepository struct {
+	repository.TwoFactorRepository
+	twoFactor     *model.UserTwoFactor
+	lastCounter   int64
+	recoveryCodes map[string]bool
+}
+
+func (r *stubTwoFactorRepository) GetByUserID(ctx context.Context, userID uint64) (*model.UserTwoFactor, error) {
+	if r.twoFactor == nil || r.twoFactor.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.twoFactor, nil
+}
+
+func (r *stubTwoFactorRepository) UseCounter(ctx context.Context, userID uint64, counter int64) (bool, error) {
+	if counter <= r.lastCounter {
+		return false, nil
+	}
+
+	r.lastCounter = counter
+	return true, nil
+}
+
+func (r *stubTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uint64, hash string, usedAt time.Time) (bool, error) {
+	if !r.recoveryCodes[hash] {
+		return false, nil
+	}
+
+	delete(r.recoveryCodes, hash)
+	return true, nil
+}
+
+func (r *stubTwoFactorRepository) Delete(ctx context.Context, userID uint64) error {
+	r.twoFactor = nil
+	return nil
+}
+
+type stubTwoFactorChallengeRepository struct {
+	repository.TwoFactorChallengeRepository
+	challenges map[string]*model.TwoFactorChallenge
+	attempts   map[string]int64
+}
+
+func newStubTwoFactorChallengeRepository() *stubTwoFactorChallengeRepository {
+	return &stubTwoFactorChallengeRepository{
+		challenges: map[string]*model.TwoFactorChallenge{},
+		attempts:   map[string]int64{},
+	}
+}
+
+func (r *stubTwoFactorChallengeRepository) Create(ctx context.Context, challenge *model.TwoFactorChallenge) error {
+	r.challenges[challenge.Hash] = challenge
+	return nil
+}
+
+func (r *stubTwoFactorChallengeRepository) GetByHash(ctx context.Context, hash string) (*model.TwoFactorChallenge, error) {
+	challenge, ok := r.challenges[hash]
+
+	if !ok {
+		return nil, redis.Nil
+	}
+
+	return challenge, nil
+}
+
+func (r *stubTwoFactorChallengeRepository) IncrAttempts(ctx context.Context, challenge *model.TwoFactorChallenge) (int64, error) {
+	r.attempts[challenge.Hash]++
+	return r.attempts[challenge.Hash], nil
+}
+
+func (r *stubTwoFactorChallengeRepository) Delete(ctx context.Context, hash string) (bool, error) {
+	_, ok := r.challenges[hash]
+	delete(r.challenges, hash)
+	return ok, nil
+}
+
+type stubAuditLogRepository struct {
+	repository.AuditLogRepository
+	logs []*model.AuditLog
+}
+
+func (r *stubAuditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+// actions 返回已记录的审计动作及结果，形如 login:success
+func (r *stubAuditLogRepository) actions() []string {
+	actions := make([]string, 0, len(r.logs))
+
+	for _, log := range r.logs {
+		actions = append(actions, log.Action+":"+log.Result)
+	}
+
+	return actions
+}
+
+type stubUserSessionRepository struct {
+	repository.UserSessionRepository
+	sessions map[string]*model.UserSession
+}
+
+func newStubUserSessionRepository() *stubUserSessionRepository {
+	return &stubUserSessionRepository{sessions: map[string]*model.UserSession{}}
+}
+
+func (r *stubUserSessionRepository) Create(ctx context.Context, session *model.UserSession) error {
+	session.CreatedAt = time.Now()
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *stubUserSessionRepository) GetBySessionID(ctx context.Context, id string) (*model.UserSession, error) {
+	session, ok := r.sessions[id]
+
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return session, nil
+}
+
+func (r *stubUserSessionRepository) ListActiveByUser(ctx context.Context, userID uint64, now time.Time) ([]*model.UserSession, error) {
+	sessions := make([]*model.UserSession, 0)
+
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *stubUserSessionRepository) Touch(ctx context.Context, id string, ip string, seenAt time.Time, expiresAt time.Time) error {
+	if session, ok := r.sessions[id]; ok {
+		session.IP = ip
+		session.LastSeenAt = seenAt
+		session.ExpiresAt = expiresAt
+	}
+
+	return nil
+}
+
+func (r *stubUserSessionRepository) Revoke(ctx context.Context, ids []string, revokedAt time.Time) error {
+	for _, id := range ids {
+		if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+			session.RevokedAt = &revokedAt
+		}
+	}
+
+	return nil
+}
+
+// stubRefreshTokenRepository 按 Redis 实现的语义保存刷新令牌：令牌只能标记使用一次，令牌族整体激活或作废
+type stubRefreshTokenRepository struct {
+	repository.RefreshTokenRepository
+	tokens   map[string]*model.RefreshToken
+	used     map[string]bool
+	families map[string]bool
+}
+
+func newStubRefreshTokenRepository() *stubRefreshTokenRepository {
+	return &stubRefreshTokenRepository{
+		tokens:   map[string]*model.RefreshToken{},
+		used:     map[string]bool{},
+		families: map[string]bool{},
+	}
+}
+
+func (r *stubRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	r.tokens[token.Hash] = token
+	return nil
+}
+
+func (r *stubRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	token, ok := r.tokens[hash]
+
+	if !ok {
+		return nil, redis.Nil
+	}
+
+	return token, nil
+}
+
+func (r *stubRefreshTokenRepository) MarkUsed(ctx context.Context, token *model.RefreshToken) (bool, error) {
+	if r.used[token.Hash] {
+		return false, nil
+	}
+
+	r.used[token.Hash] = true
+	return true, nil
+}
+
+func (r *stubRefreshTokenRepository) ActivateFamily(ctx context.Context, familyID string, ttl time.Duration) error {
+	r.families[familyID] = true
+	return nil
+}
+
+func (r *stubRefreshTokenRepository) IsFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	return r.families[familyID], nil
+}
+
+func (r *stubRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	delete(r.families, familyID)
+	return nil
+}
+
+// stubTokenRevocationRepository 记录每个用户的令牌失效水位
+type stubTokenRevocationRepository struct {
+	repository.TokenRevocationRepository
+	revokedBefore map[uint64]time.Time
+}
+
+func newStubTokenRevocationRepository() *stubTokenRevocationRepository {
+	return &stubTokenRevocationRepository{revokedBefore: map[uint64]time.Time{}}
+}
+
+func (r *stubTokenRevocationRepository) RevokeBefore(ctx context.Context, uid uint64, at time.Time) error {
+	r.revokedBefore[uid] = at
+	return nil
+}
+
+// stubPersonalAccessTokenRepository 按摘要保存个人访问令牌
+type stubPersonalAccessTokenRepository struct {
+	repository.PersonalAccessTokenRepository
+	tokens map[string]*model.PersonalAccessToken
+}
+
+func newStubPersonalAccessTokenRepository() *stubPersonalAccessTokenRepository {
+	return &stubPersonalAccessTokenRepository{tokens: map[string]*model.PersonalAccessToken{}}
+}
+
+func (r *stubPersonalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	token.ID = uint64(len(r.tokens) + 1)
+	r.tokens[token.Hash] = token
+	return nil
+}
+
+func (r *stubPersonalAccessTokenRepository) GetByHash(ctx context.Context, hash string) (*model.PersonalAccessToken, error) {
+	token, ok := r.tokens[hash]
+
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return token, nil
+}
+
+func (r *stubPersonalAccessTokenRepository) Touch(ctx context.Context, id uint64, usedAt time.Time) error {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &usedAt
+		}
+	}
+
+	return nil
+}
+
+func (r *stubPersonalAccessTokenRepository) RevokeAllByUser(ctx context.Context, userID uint64, revokedAt time.Time) (int64, error) {
+	var n int64
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+			n++
+		}
+	}
+
+	return n, nil
+}
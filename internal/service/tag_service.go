@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ TagService = (*tagService)(nil)
+
+// ErrTagForbidden 表示当前用户试图访问其他用户的标签
+var ErrTagForbidden = response.Forbidden("forbidden: tag belongs to another user")
+
+type TagService interface {
+	CreateTag(ctx context.Context, uid uint64, req *dto.CreateTagRequest) (*model.Tag, error)
+	UpdateTag(ctx context.Context, uid uint64, tid uint64, req *dto.UpdateTagRequest) (*model.Tag, error)
+	DeleteTag(ctx context.Context, uid uint64, tid uint64) error
+	ListTags(ctx context.Context, uid uint64) ([]*model.Tag, error)
+	ListTagStats(ctx context.Context, uid uint64, req *dto.TagStatsRequest) ([]*dto.TagStatsResponse, error)
+}
+
+type tagService struct {
+	tagRepository          repository.TagRepository
+	habitRepository        repository.HabitRepository
+	userRepository         repository.UserRepository
+	habitCheckInRepository repository.HabitCheckInRepository
+}
+
+func NewTagService(
+	tagRepository repository.TagRepository,
+	habitRepository repository.HabitRepository,
+	userRepository repository.UserRepository,
+	habitCheckInRepository repository.HabitCheckInRepository,
+) TagService {
+	return &tagService{
+		tagRepository:          tagRepository,
+		habitRepository:        habitRepository,
+		userRepository:         userRepository,
+		habitCheckInRepository: habitCheckInRepository,
+	}
+}
+
+func (s *tagService) CreateTag(ctx context.Context, uid uint64, req *dto.CreateTagRequest) (*model.Tag, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	name := strings.TrimSpace(req.Name)
+
+	err := s.checkName(ctx, uid, name, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tag := model.Tag{
+		UserID: uid,
+		Name:   name,
+		Color:  req.Color,
+	}
+
+	err = s.tagRepository.Create(ctx, &tag)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func (s *tagService) UpdateTag(ctx context.Context, uid uint64, tid uint64, req *dto.UpdateTagRequest) (*model.Tag, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	tag, err := s.getTag(ctx, uid, tid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+
+	err = s.checkName(ctx, uid, name, tag.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tag.Name = name
+	tag.Color = req.Color
+
+	err = s.tagRepository.Update(ctx, tag)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func (s *tagService) DeleteTag(ctx context.Context, uid uint64, tid uint64) error {
+	tag, err := s.getTag(ctx, uid, tid)
+
+	if err != nil {
+		return err
+	}
+
+	return s.tagRepository.Delete(ctx, tag.ID)
+}
+
+func (s *tagService) ListTags(ctx context.Context, uid uint64) ([]*model.Tag, error) {
+	return s.tagRepository.ListByUserID(ctx, uid)
+}
+
+// ListTagStats 按标签汇总习惯在日期区间内的完成情况，包含已归档的习惯
+func (s *tagService) ListTagStats(ctx context.Context, uid uint64, req *dto.TagStatsRequest) ([]*dto.TagStatsResponse, error) {
+	if req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userRepository.GetPlainByID", zap.Error(err))
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, response.NotFound("user not found")
+	}
+
+	today := utils.UserClock(user).Today()
+
+	from, to, err := parseDayRange(req.From, req.To, today)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepository.ListByUserID(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	habits, err := s.habitRepository.ListByUserID(ctx, uid, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(habits))
+
+	for _, habit := range habits {
+		ids = append(ids, habit.ID)
+	}
+
+	counts, err := s.habitCheckInRepository.CountByHabitIDs(ctx, ids, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.TagStatsResponse, 0, len(tags))
+	stats := make(map[uint64]*dto.TagStatsResponse, len(tags))
+
+	for _, tag := range tags {
+		stat := &dto.TagStatsResponse{Tag: tag, From: from, To: to}
+		stats[tag.ID] = stat
+		responses = append(responses, stat)
+	}
+
+	// 今天之后的日期尚未到期，不计入要求完成的天数
+	end := to
+
+	if end.After(today) {
+		end = today
+	}
+
+	for _, habit := range habits {
+		if len(habit.Tags) == 0 {
+			continue
+		}
+
+		expected, err := utils.ExpectedHabitDays(habit, from, end)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range habit.Tags {
+			stat, ok := stats[tag.ID]
+
+			if !ok {
+				continue
+			}
+
+			stat.HabitCount++
+			stat.Completed += counts[habit.ID]
+			stat.Expected += expected
+		}
+	}
+
+	for _, stat := range responses {
+		if stat.Expected > 0 {
+			stat.Rate = min(float64(stat.Completed)/float64(stat.Expected), 1)
+		}
+	}
+
+	return responses, nil
+}
+
+// getTag 查询标签并校验其归属于 uid
+func (s *tagService) getTag(ctx context.Context, uid uint64, tid uint64) (*model.Tag, error) {
+	tag, err := s.tagRepository.GetByID(ctx, tid)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("tagRepository.GetByID", zap.Error(err))
+		return nil, err
+	}
+
+	if tag == nil {
+		return nil, response.NotFound("tag not found")
+	}
+
+	if tag.UserID != uid {
+		return nil, ErrTagForbidden
+	}
+
+	return tag, nil
+}
+
+// checkName 校验标签名在用户下唯一，exclude 为正在修改的标签
+func (s *tagService) checkName(ctx context.Context, uid uint64, name string, exclude uint64) error {
+	if name == "" {
+		return response.Validation("tag name is empty")
+	}
+
+	tag, err := s.tagRepository.GetByUserAndName(ctx, uid, name)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("tagRepository.GetByUserAndName", zap.Error(err))
+		return err
+	}
+
+	if tag != nil && tag.ID != exclude {
+		return response.Conflict("tag name already exists")
+	}
+
+	return nil
+}
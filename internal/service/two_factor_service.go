@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/repository"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var _ TwoFactorService = (*twoFactorService)(nil)
+
+var (
+	ErrTwoFactorNotEnabled     = response.Conflict("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = response.Conflict("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = response.Conflict("two-factor enrollment has not been started")
+	ErrTwoFactorCodeInvalid    = response.Unauthorized("invalid two-factor code")
+)
+
+// TwoFactorConfig 中 Issuer 为认证器中显示的服务名称
+type TwoFactorConfig struct {
+	Issuer string
+}
+
+// TwoFactorService 管理 TOTP 二次验证的开通、确认、关闭与恢复码
+type TwoFactorService interface {
+	GetStatus(ctx context.Context, uid uint64) (*dto.TwoFactorStatusResponse, error)
+	Enroll(ctx context.Context, uid uint64) (*dto.TwoFactorEnrollResponse, error)
+	Confirm(ctx context.Context, actor *dto.Actor, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, actor *dto.Actor, req *dto.TwoFactorDisableRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, actor *dto.Actor, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error)
+}
+
+type twoFactorService struct {
+	config              *TwoFactorConfig
+	userRepository      repository.UserRepository
+	twoFactorRepository repository.TwoFactorRepository
+	auditLogRepository  repository.AuditLogRepository
+	passwordHasher      utils.PasswordHasher
+	secretCipher        *utils.SecretCipher
+}
+
+func NewTwoFactorService(
+	config *TwoFactorConfig,
+	userRepository repository.UserRepository,
+	twoFactorRepository repository.TwoFactorRepository,
+	auditLogRepository repository.AuditLogRepository,
+	passwordHasher utils.PasswordHasher,
+	secretCipher *utils.SecretCipher,
+) TwoFactorService {
+	if config.Issuer == "" {
+		config.Issuer = def.TwoFactorDefaultIssuer
+	}
+
+	return &twoFactorService{
+		config:              config,
+		userRepository:      userRepository,
+		twoFactorRepository: twoFactorRepository,
+		auditLogRepository:  auditLogRepository,
+		passwordHasher:      passwordHasher,
+		secretCipher:        secretCipher,
+	}
+}
+
+func (s *twoFactorService) GetStatus(ctx context.Context, uid uint64) (*dto.TwoFactorStatusResponse, error) {
+	twoFactor, err := s.getTwoFactor(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil {
+		return &dto.TwoFactorStatusResponse{}, nil
+	}
+
+	remaining, err := s.twoFactorRepository.CountRecoveryCodes(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorStatusResponse{
+		Enabled:                twoFactor.Enabled,
+		Pending:                !twoFactor.Enabled,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// Enroll 生成新的 TOTP 密钥，确认前不生效，重复调用会替换未确认的密钥
+func (s *twoFactorService) Enroll(ctx context.Context, uid uint64) (*dto.TwoFactorEnrollResponse, error) {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.getTwoFactor(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor != nil && twoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := s.secretCipher.Encrypt(secret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil {
+		twoFactor = &model.UserTwoFactor{UserID: uid}
+	}
+
+	twoFactor.Secret = encrypted
+	twoFactor.LastCounter = 0
+
+	err = s.twoFactorRepository.Save(ctx, twoFactor)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorEnrollResponse{
+		Secret: secret,
+		URI:    utils.TOTPURI(s.config.Issuer, user.Username, secret),
+	}, nil
+}
+
+// Confirm 校验认证器生成的验证码后开启二次验证，并返回初始恢复码
+func (s *twoFactorService) Confirm(ctx context.Context, actor *dto.Actor, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	if actor == nil || req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	codes, err := s.confirm(ctx, actor.UserID, req)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionTwoFactorEnable, actor.UserID, err)
+
+	return codes, err
+}
+
+func (s *twoFactorService) confirm(ctx context.Context, uid uint64, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	twoFactor, err := s.getTwoFactor(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	ok, err := verifyTwoFactorCode(ctx, s.twoFactorRepository, s.secretCipher, twoFactor, req.Code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrTwoFactorCodeInvalid
+	}
+
+	now := time.Now().UTC()
+
+	// 校验成功时 LastCounter 已在数据库中更新，重新读取以免被覆盖
+	twoFactor, err = s.getTwoFactor(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor.Enabled = true
+	twoFactor.ConfirmedAt = &now
+
+	err = s.twoFactorRepository.Save(ctx, twoFactor)
+
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Two-factor authentication enabled", zap.Uint64("user_id", uid))
+
+	return s.replaceRecoveryCodes(ctx, uid)
+}
+
+// Disable 需要同时提供口令与验证码才能关闭二次验证
+func (s *twoFactorService) Disable(ctx context.Context, actor *dto.Actor, req *dto.TwoFactorDisableRequest) error {
+	if actor == nil || req == nil {
+		return response.BadRequest("req is nil")
+	}
+
+	err := s.disable(ctx, actor.UserID, req)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionTwoFactorDisable, actor.UserID, err)
+
+	return err
+}
+
+func (s *twoFactorService) disable(ctx context.Context, uid uint64, req *dto.TwoFactorDisableRequest) error {
+	user, err := s.userRepository.GetPlainByID(ctx, uid)
+
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := verifyPassword(s.passwordHasher, user, req.Password)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return response.Forbidden("invalid password")
+	}
+
+	twoFactor, err := s.getEnabledTwoFactor(ctx, uid, req.Code)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.twoFactorRepository.Delete(ctx, twoFactor.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Two-factor authentication disabled", zap.Uint64("user_id", uid))
+
+	return nil
+}
+
+// RegenerateRecoveryCodes 作废现有恢复码并生成新的一组
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, actor *dto.Actor, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	if actor == nil || req == nil {
+		return nil, response.BadRequest("req is nil")
+	}
+
+	codes, err := s.regenerateRecoveryCodes(ctx, actor.UserID, req)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionTwoFactorRecoveryCodes, actor.UserID, err)
+
+	return codes, err
+}
+
+func (s *twoFactorService) regenerateRecoveryCodes(ctx context.Context, uid uint64, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	_, err := s.getEnabledTwoFactor(ctx, uid, req.Code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, uid)
+}
+
+func (s *twoFactorService) getTwoFactor(ctx context.Context, uid uint64) (*model.UserTwoFactor, error) {
+	twoFactor, err := s.twoFactorRepository.GetByUserID(ctx, uid)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logger.Error("twoFactorRepository.GetByUserID", zap.Error(err))
+		return nil, err
+	}
+
+	return twoFactor, nil
+}
+
+// getEnabledTwoFactor 返回已开启的二次验证配置，并校验 code
+func (s *twoFactorService) getEnabledTwoFactor(ctx context.Context, uid uint64, code string) (*model.UserTwoFactor, error) {
+	twoFactor, err := s.getTwoFactor(ctx, uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil || !twoFactor.Enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	ok, err := verifyTwoFactorCode(ctx, s.twoFactorRepository, s.secretCipher, twoFactor, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrTwoFactorCodeInvalid
+	}
+
+	return twoFactor, nil
+}
+
+func (s *twoFactorService) replaceRecoveryCodes(ctx context.Context, uid uint64) (*dto.RecoveryCodesResponse, error) {
+	codes := make([]string, 0, def.TwoFactorRecoveryCodeCount)
+	hashes := make([]string, 0, def.TwoFactorRecoveryCodeCount)
+
+	for i := 0; i < def.TwoFactorRecoveryCodeCount; i++ {
+		bytes := make([]byte, def.TwoFactorRecoveryCodeBytes)
+
+		_, err := rand.Read(bytes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		code := hex.EncodeToString(bytes)
+		codes = append(codes, code[:len(code)/2]+"-"+code[len(code)/2:])
+		hashes = append(hashes, utils.HashToken(code))
+	}
+
+	err := s.twoFactorRepository.ReplaceRecoveryCodes(ctx, uid, hashes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// verifyTwoFactorCode 校验 TOTP 验证码或恢复码，二者都只能使用一次
+func verifyTwoFactorCode(
+	ctx context.Context,
+	twoFactorRepository repository.TwoFactorRepository,
+	secretCipher *utils.SecretCipher,
+	twoFactor *model.UserTwoFactor,
+	code string,
+) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if utils.IsTOTPCode(code) {
+		secret, err := secretCipher.Decrypt(twoFactor.Secret)
+
+		if err != nil {
+			logger.Error("Failed to decrypt TOTP secret", zap.Error(err), zap.Uint64("user_id", twoFactor.UserID))
+			return false, err
+		}
+
+		counter, ok := utils.VerifyTOTP(secret, code, time.Now())
+
+		if !ok {
+			return false, nil
+		}
+
+		return twoFactorRepository.UseCounter(ctx, twoFactor.UserID, counter)
+	}
+
+	if !twoFactor.Enabled {
+		return false, nil
+	}
+
+	// 恢复码忽略大小写与分隔符
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	return twoFactorRepository.UseRecoveryCode(ctx, twoFactor.UserID, utils.HashToken(normalized), time.Now().UTC())
+}
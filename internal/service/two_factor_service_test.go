@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/utils"
+)
+
+func TestVerifyTwoFactorCodeRejectsReplay(t *testing.T) {
+	cipher, err := utils.NewSecretCipher("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+
+	if err != nil {
+		t.Fatalf("NewSecretCipher: %v", err)
+	}
+
+	secret, _ := utils.GenerateTOTPSecret()
+	encrypted, err := cipher.Encrypt(secret)
+
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	now := utils.TOTPCounter(time.Now())
+	code := func(counter int64) string {
+		c, _ := utils.TOTPCode(secret, counter)
+		return c
+	}
+
+	// 依次提交，每一步都基于前面步骤已记录的时间步
+	// 不使用前一时间步的验证码，避免测试跨过时间步边界时超出偏移窗口
+	steps := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		{"current step accepted", code(now), true},
+		{"same code replayed", code(now), false},
+		{"same code replayed with whitespace", " " + code(now) + " ", false},
+		{"next step accepted", code(now + 1), true},
+		{"next step replayed", code(now + 1), false},
+		{"older step after newer one", code(now), false},
+	}
+
+	repo := &stubTwoFactorRepository{}
+	twoFactor := &model.UserTwoFactor{UserID: 7, Secret: encrypted, Enabled: true}
+
+	for _, step := range steps {
+		ok, err := verifyTwoFactorCode(context.Background(), repo, cipher, twoFactor, step.code)
+
+		if err != nil || ok != step.ok {
+			t.Fatalf("%s: verifyTwoFactorCode = %v, %v, want %v", step.name, ok, err, step.ok)
+		}
+	}
+}
+
+func TestTwoFactorChangesAudited(t *testing.T) {
+	env := newTwoFactorTestEnv(t)
+	audit := &stubAuditLogRepository{}
+	auth := env.service
+
+	s := &twoFactorService{
+		config:              &TwoFactorConfig{Issuer: "test"},
+		userRepository:      auth.userRepository,
+		twoFactorRepository: auth.twoFactorRepository,
+		auditLogRepository:  audit,
+		passwordHasher:      auth.passwordHasher,
+		secretCipher:        auth.secretCipher,
+	}
+
+	actor := &dto.Actor{UserID: 7, Username: "alice", IP: testClient.IP}
+
+	_, err := s.RegenerateRecoveryCodes(context.Background(), actor, &dto.TwoFactorCodeRequest{Code: env.code(10)})
+
+	if err == nil {
+		t.Fatal("RegenerateRecoveryCodes with a wrong code succeeded")
+	}
+
+	err = s.Disable(context.Background(), actor, &dto.TwoFactorDisableRequest{Password: "wrong", Code: env.code(0)})
+
+	if err == nil {
+		t.Fatal("Disable with a wrong password succeeded")
+	}
+
+	err = s.Disable(context.Background(), actor, &dto.TwoFactorDisableRequest{Password: "secret", Code: env.code(0)})
+
+	if err != nil {
+		t.Fatalf("Disable = %v", err)
+	}
+
+	want := []string{"2fa.recovery_codes:failure", "2fa.disable:failure", "2fa.disable:success"}
+
+	if got := audit.actions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("audit actions = %v, want %v", got, want)
+	}
+}
@@ -3,41 +3,88 @@ package service
 import (
 	"context"
 	"errors"
+	"net/mail"
+	"strings"
 	"w2learn/internal/dto"
 	"w2learn/internal/model"
 	"w2learn/internal/repository"
 	"w2learn/internal/utils"
+	"w2learn/pkg/def"
 	"w2learn/pkg/logger"
+	"w2learn/pkg/response"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+var ErrUserSuspended = response.Forbidden("account suspended")
+
 type UserService interface {
-	CreateUser(ctx context.Context, req *dto.CreateUserRequest) (*model.User, error)
+	CreateUser(ctx context.Context, actor *dto.Actor, req *dto.CreateUserRequest) (*model.User, error)
 	GetUserByID(ctx context.Context, id uint64) (*model.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
-	UpdateUser(ctx context.Context, id uint64, req *dto.UpdateUserRequest) (*model.User, error)
-	DeleteUser(ctx context.Context, id uint64) error
+	UpdateUser(ctx context.Context, actor *dto.Actor, id uint64, req *dto.UpdateUserRequest) (*model.User, error)
+	DeleteUser(ctx context.Context, actor *dto.Actor, id uint64) error
 	ListUsers(ctx context.Context, page int, pageSize int) ([]*model.User, error)
+	SuspendUser(ctx context.Context, actor *dto.Actor, id uint64) error
+	ReinstateUser(ctx context.Context, actor *dto.Actor, id uint64) error
+	UnlockUser(ctx context.Context, actor *dto.Actor, id uint64) error
 }
 
 type userService struct {
-	userRepository  repository.UserRepository
-	habitRepository repository.HabitRepository
+	userRepository                repository.UserRepository
+	habitRepository               repository.HabitRepository
+	userSessionRepository         repository.UserSessionRepository
+	refreshTokenRepository        repository.RefreshTokenRepository
+	loginAttemptRepository        repository.LoginAttemptRepository
+	auditLogRepository            repository.AuditLogRepository
+	tokenRevocationRepository     repository.TokenRevocationRepository
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository
+	passwordHasher                utils.PasswordHasher
 }
 
 func NewUserService(
 	userRepository repository.UserRepository,
 	habitRepository repository.HabitRepository,
+	userSessionRepository repository.UserSessionRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
+	loginAttemptRepository repository.LoginAttemptRepository,
+	auditLogRepository repository.AuditLogRepository,
+	tokenRevocationRepository repository.TokenRevocationRepository,
+	personalAccessTokenRepository repository.PersonalAccessTokenRepository,
+	passwordHasher utils.PasswordHasher,
 ) UserService {
 	return &userService{
-		userRepository:  userRepository,
-		habitRepository: habitRepository,
+		userRepository:                userRepository,
+		habitRepository:               habitRepository,
+		userSessionRepository:         userSessionRepository,
+		refreshTokenRepository:        refreshTokenRepository,
+		loginAttemptRepository:        loginAttemptRepository,
+		auditLogRepository:            auditLogRepository,
+		tokenRevocationRepository:     tokenRevocationRepository,
+		personalAccessTokenRepository: personalAccessTokenRepository,
+		passwordHasher:                passwordHasher,
+	}
+}
+
+func (s *userService) CreateUser(ctx context.Context, actor *dto.Actor, req *dto.CreateUserRequest) (*model.User, error) {
+	if req == nil {
+		return nil, response.BadRequest("request is empty")
 	}
+
+	user, err := s.createUser(ctx, req)
+
+	var uid uint64
+	if user != nil {
+		uid = user.ID
+	}
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionUserCreate, uid, err)
+
+	return user, err
 }
 
-func (s *userService) CreateUser(ctx context.Context, req *dto.CreateUserRequest) (*model.User, error) {
+func (s *userService) createUser(ctx context.Context, req *dto.CreateUserRequest) (*model.User, error) {
 	user, err := s.GetUserByUsername(ctx, req.Username)
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -46,24 +93,32 @@ func (s *userService) CreateUser(ctx context.Context, req *dto.CreateUserRequest
 	}
 
 	if user != nil {
-		return nil, errors.New("user already exists")
+		return nil, response.Conflict("user already exists")
 	}
 
 	if req.Password == "" {
-		return nil, errors.New("password is empty")
+		return nil, response.Validation("password is empty")
+	}
+
+	email, err := checkEmail(ctx, s.userRepository, req.Email, 0)
+
+	if err != nil {
+		return nil, err
 	}
 
-	salt, err := utils.GenerateStringSalt(16)
+	password, err := s.passwordHasher.Hash(req.Password)
 
-	if err != nil || salt == "" {
-		return nil, errors.New("failed to generate salt")
+	if err != nil {
+		logger.Error("passwordHasher.Hash", zap.Error(err))
+		return nil, errors.New("failed to hash password")
 	}
 
 	user = &model.User{
 		Username: req.Username,
-		Password: utils.HashString(req.Password, salt),
-		Salt:     salt,
-		Status:   0,
+		Email:    email,
+		Password: password,
+		Status:   def.UserStatusActive,
+		Timezone: req.Timezone,
 		Habits:   nil,
 	}
 
@@ -101,7 +156,15 @@ func (s *userService) GetUserByUsername(ctx context.Context, username string) (*
 	return user, nil
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id uint64, req *dto.UpdateUserRequest) (*model.User, error) {
+func (s *userService) UpdateUser(ctx context.Context, actor *dto.Actor, id uint64, req *dto.UpdateUserRequest) (*model.User, error) {
+	user, err := s.updateUser(ctx, id, req)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionUserUpdate, id, err)
+
+	return user, err
+}
+
+func (s *userService) updateUser(ctx context.Context, id uint64, req *dto.UpdateUserRequest) (*model.User, error) {
 	user, err := s.GetUserByID(ctx, id)
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -109,14 +172,45 @@ func (s *userService) UpdateUser(ctx context.Context, id uint64, req *dto.Update
 		return nil, err
 	}
 
+	if user == nil {
+		return nil, response.NotFound("user not found")
+	}
+
 	if req == nil {
-		return nil, errors.New("request is empty")
+		return nil, response.BadRequest("request is empty")
 	}
 
 	if req.Username != "" {
 		user.Username = req.Username
 	}
 
+	if req.Email != nil {
+		email, err := checkEmail(ctx, s.userRepository, *req.Email, user.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// 更换邮箱后需要重新验证
+		if !equalEmail(user.Email, email) {
+			user.EmailVerifiedAt = nil
+		}
+
+		user.Email = email
+	}
+
+	if req.Timezone != "" {
+		user.Timezone = req.Timezone
+	}
+
+	if req.DayRolloverHour != nil {
+		user.DayRolloverHour = *req.DayRolloverHour
+	}
+
+	if req.MaxSessions != nil {
+		user.MaxSessions = *req.MaxSessions
+	}
+
 	err = s.userRepository.Update(ctx, user)
 
 	if err != nil {
@@ -126,7 +220,15 @@ func (s *userService) UpdateUser(ctx context.Context, id uint64, req *dto.Update
 	return user, nil
 }
 
-func (s *userService) DeleteUser(ctx context.Context, id uint64) error {
+func (s *userService) DeleteUser(ctx context.Context, actor *dto.Actor, id uint64) error {
+	err := s.deleteUser(ctx, id)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionUserDelete, id, err)
+
+	return err
+}
+
+func (s *userService) deleteUser(ctx context.Context, id uint64) error {
 	user, err := s.userRepository.GetByID(ctx, id)
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -135,7 +237,20 @@ func (s *userService) DeleteUser(ctx context.Context, id uint64) error {
 	}
 
 	if user == nil {
-		return errors.New("user not found")
+		return response.NotFound("user not found")
+	}
+
+	// 先标记为已删除并撤销会话，使已签发的令牌无法继续使用
+	err = s.userRepository.UpdateStatus(ctx, id, def.UserStatusDeleted)
+
+	if err != nil {
+		return err
+	}
+
+	err = revokeUserTokens(ctx, s.userSessionRepository, s.refreshTokenRepository, s.tokenRevocationRepository, s.personalAccessTokenRepository, id, "")
+
+	if err != nil {
+		return err
 	}
 
 	habits := user.Habits
@@ -180,3 +295,176 @@ func (s *userService) ListUsers(ctx context.Context, page int, pageSize int) ([]
 
 	return users, nil
 }
+
+// SuspendUser 停用账户，撤销其全部会话并使已签发的访问令牌失效，管理员不能停用自己
+func (s *userService) SuspendUser(ctx context.Context, actor *dto.Actor, id uint64) error {
+	if actor == nil {
+		return response.BadRequest("request is empty")
+	}
+
+	err := s.suspendUser(ctx, actor.UserID, id)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionUserSuspend, id, err)
+
+	return err
+}
+
+func (s *userService) suspendUser(ctx context.Context, operatorID uint64, id uint64) error {
+	if operatorID == id {
+		return response.Forbidden("cannot suspend yourself")
+	}
+
+	user, err := s.getActiveUser(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if user.Status == def.UserStatusSuspended {
+		return nil
+	}
+
+	err = s.userRepository.UpdateStatus(ctx, id, def.UserStatusSuspended)
+
+	if err != nil {
+		return err
+	}
+
+	err = revokeUserTokens(ctx, s.userSessionRepository, s.refreshTokenRepository, s.tokenRevocationRepository, s.personalAccessTokenRepository, id, "")
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("User suspended", zap.Uint64("user_id", id), zap.Uint64("operator_id", operatorID))
+
+	return nil
+}
+
+// ReinstateUser 恢复被停用的账户
+func (s *userService) ReinstateUser(ctx context.Context, actor *dto.Actor, id uint64) error {
+	err := s.reinstateUser(ctx, id)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionUserReinstate, id, err)
+
+	return err
+}
+
+func (s *userService) reinstateUser(ctx context.Context, id uint64) error {
+	user, err := s.getActiveUser(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if user.Status != def.UserStatusSuspended {
+		return response.Conflict("user is not suspended")
+	}
+
+	err = s.userRepository.UpdateStatus(ctx, id, def.UserStatusActive)
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("User reinstated", zap.Uint64("user_id", id))
+
+	return nil
+}
+
+// UnlockUser 解除因登录失败过多导致的临时锁定，并清空该用户名的失败记录
+func (s *userService) UnlockUser(ctx context.Context, actor *dto.Actor, id uint64) error {
+	err := s.unlockUser(ctx, id)
+
+	recordAudit(ctx, s.auditLogRepository, actor, def.AuditActionUserUnlock, id, err)
+
+	return err
+}
+
+func (s *userService) unlockUser(ctx context.Context, id uint64) error {
+	user, err := s.getActiveUser(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	locked, err := s.loginAttemptRepository.Unlock(ctx, user.Username)
+
+	if err != nil {
+		return err
+	}
+
+	if !locked {
+		return response.Conflict("user is not locked")
+	}
+
+	logger.Info("User unlocked", zap.Uint64("user_id", id))
+
+	return nil
+}
+
+func (s *userService) getActiveUser(ctx context.Context, id uint64) (*model.User, error) {
+	user, err := s.userRepository.GetPlainByID(ctx, id)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, response.NotFound("user not found")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status == def.UserStatusDeleted {
+		return nil, response.NotFound("user not found")
+	}
+
+	return user, nil
+}
+
+// checkUserStatus 检查账户是否允许登录，已停用的账户被拒绝，已删除的账户视为不存在
+func checkUserStatus(user *model.User) error {
+	switch user.Status {
+	case def.UserStatusSuspended:
+		return ErrUserSuspended
+	case def.UserStatusDeleted:
+		return response.NotFound("user not found")
+	}
+
+	return nil
+}
+
+func equalEmail(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// checkEmail 规范化邮箱并检查是否已被 uid 以外的用户使用，空字符串表示不设置邮箱
+func checkEmail(ctx context.Context, userRepository repository.UserRepository, email string, uid uint64) (*string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if email == "" {
+		return nil, nil
+	}
+
+	addr, err := mail.ParseAddress(email)
+
+	if err != nil || addr.Address != email {
+		return nil, response.Validation("invalid email")
+	}
+
+	user, err := userRepository.GetByEmail(ctx, email)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("userRepository.GetByEmail", zap.Error(err))
+		return nil, err
+	}
+
+	if user != nil && user.ID != uid {
+		return nil, response.Conflict("email already in use")
+	}
+
+	return &email, nil
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrCiphertextInvalid = errors.New("invalid ciphertext")
+
+// SecretCipher 使用 AES-256-GCM 加密需要落库的敏感数据
+// 密文为 Base64 编码的 nonce 与密文拼接
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher 以 key 的 SHA-256 摘要作为 AES-256 密钥
+func NewSecretCipher(key string) (*SecretCipher, error) {
+	if key == "" {
+		return nil, errors.New("cipher key is empty")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(sum[:])
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretCipher{
+		aead: aead,
+	}, nil
+}
+
+func (c *SecretCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+
+	_, err := rand.Read(nonce)
+
+	if err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *SecretCipher) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", ErrCiphertextInvalid
+	}
+
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+
+	if err != nil {
+		return "", ErrCiphertextInvalid
+	}
+
+	return string(plaintext), nil
+}
+
+// GenerateSecretFile 生成随机密钥并以十六进制写入 file，文件已存在时返回 false，只用于开发环境
+func GenerateSecretFile(file string) (bool, error) {
+	key := make([]byte, 32)
+
+	_, err := rand.Read(key)
+
+	if err != nil {
+		return false, err
+	}
+
+	return createSecretFile(file, []byte(hex.EncodeToString(key)+"\n"))
+}
+
+// ReadSecretFile 读取密钥文件并去掉首尾空白
+func ReadSecretFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	secret := strings.TrimSpace(string(data))
+
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", file)
+	}
+
+	return secret, nil
+}
+
+// createSecretFile 以仅所有者可读写的权限创建文件，文件已存在时不覆盖并返回 false
+func createSecretFile(file string, data []byte) (bool, error) {
+	err := os.MkdirAll(filepath.Dir(file), 0o700)
+
+	if err != nil {
+		return false, err
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	_, err = f.Write(data)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+)
+
+// Clock 按用户时区与日切换时间换算用户的本地日期
+// DayRolloverHour 表示新的一天从本地几点开始，例如 4 表示凌晨 4 点前仍算作前一天
+type Clock struct {
+	Location        *time.Location
+	DayRolloverHour int
+}
+
+// NewClock 创建用户时钟，时区无法识别时回退到 UTC
+func NewClock(timezone string, dayRolloverHour int) *Clock {
+	loc, err := time.LoadLocation(timezone)
+
+	if err != nil || timezone == "" {
+		loc = time.UTC
+	}
+
+	if dayRolloverHour < 0 || dayRolloverHour > 23 {
+		dayRolloverHour = 0
+	}
+
+	return &Clock{
+		Location:        loc,
+		DayRolloverHour: dayRolloverHour,
+	}
+}
+
+func UserClock(user *model.User) *Clock {
+	return NewClock(user.Timezone, user.DayRolloverHour)
+}
+
+// DayOf 返回时刻 t 在用户本地所属的日期
+// 按本地钟面小时判断是否已到日切换时间，夏令时切换当天也在本地 DayRolloverHour 点切换
+func (c *Clock) DayOf(t time.Time) time.Time {
+	local := t.In(c.Location)
+	day := DayOf(local)
+
+	if local.Hour() < c.DayRolloverHour {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return day
+}
+
+func (c *Clock) Today() time.Time {
+	return c.DayOf(time.Now())
+}
+
+// DayOf 将时间截断为其所在的自然日，统一以 UTC 零点表示
+func DayOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func ParseDay(s string) (time.Time, error) {
+	return time.ParseInLocation(def.CheckInDayLayout, s, time.UTC)
+}
+
+func FormatDay(day time.Time) string {
+	return day.Format(def.CheckInDayLayout)
+}
+
+// WeekStart 返回 day 所在周的周一
+func WeekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
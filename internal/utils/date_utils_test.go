@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewClock(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		rollover int
+		location string
+		hour     int
+	}{
+		{"valid", "Asia/Shanghai", 4, "Asia/Shanghai", 4},
+		{"empty timezone", "", 4, "UTC", 4},
+		{"unknown timezone", "Mars/Olympus", 4, "UTC", 4},
+		{"negative rollover", "Europe/Berlin", -1, "Europe/Berlin", 0},
+		{"rollover out of range", "Europe/Berlin", 24, "Europe/Berlin", 0},
+		{"last rollover hour", "Europe/Berlin", 23, "Europe/Berlin", 23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewClock(tt.timezone, tt.rollover)
+
+			if clock.Location.String() != tt.location || clock.DayRolloverHour != tt.hour {
+				t.Fatalf("NewClock = %s/%d, want %s/%d", clock.Location, clock.DayRolloverHour, tt.location, tt.hour)
+			}
+		})
+	}
+}
+
+func TestClockDayOf(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	berlin := mustLocation(t, "Europe/Berlin")
+	shanghai := mustLocation(t, "Asia/Shanghai")
+
+	tests := []struct {
+		name     string
+		clock    *Clock
+		instant  time.Time
+		expected string
+	}{
+		{"utc midnight", &Clock{Location: time.UTC}, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), "2026-10-18"},
+		{"utc before midnight", &Clock{Location: time.UTC}, time.Date(2026, 10, 17, 23, 59, 59, 0, time.UTC), "2026-10-17"},
+
+		// 东八区凌晨 1 点对应 UTC 前一天 17 点
+		{"zone ahead of utc", &Clock{Location: shanghai}, time.Date(2026, 10, 17, 17, 0, 0, 0, time.UTC), "2026-10-18"},
+		{"zone behind utc", &Clock{Location: newYork}, time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC), "2026-10-17"},
+
+		{"after midnight before rollover", &Clock{Location: shanghai, DayRolloverHour: 4}, time.Date(2026, 10, 18, 3, 59, 59, 0, shanghai), "2026-10-17"},
+		{"at rollover", &Clock{Location: shanghai, DayRolloverHour: 4}, time.Date(2026, 10, 18, 4, 0, 0, 0, shanghai), "2026-10-18"},
+		{"before midnight with rollover", &Clock{Location: shanghai, DayRolloverHour: 4}, time.Date(2026, 10, 17, 23, 30, 0, 0, shanghai), "2026-10-17"},
+		{"rollover across month", &Clock{Location: shanghai, DayRolloverHour: 4}, time.Date(2026, 11, 1, 2, 0, 0, 0, shanghai), "2026-10-31"},
+		{"rollover across year", &Clock{Location: shanghai, DayRolloverHour: 4}, time.Date(2027, 1, 1, 3, 0, 0, 0, shanghai), "2026-12-31"},
+
+		// 2026-03-08 纽约 02:00 跳到 03:00
+		{"spring forward before rollover", &Clock{Location: newYork, DayRolloverHour: 4}, time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC), "2026-03-07"},
+		{"spring forward at rollover", &Clock{Location: newYork, DayRolloverHour: 4}, time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC), "2026-03-08"},
+		{"spring forward rollover in gap", &Clock{Location: newYork, DayRolloverHour: 2}, time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), "2026-03-08"},
+		{"spring forward before gap", &Clock{Location: newYork, DayRolloverHour: 2}, time.Date(2026, 3, 8, 6, 59, 0, 0, time.UTC), "2026-03-07"},
+
+		// 2026-11-01 纽约 02:00 回拨到 01:00，01:30 出现两次
+		{"fall back first 1:30", &Clock{Location: newYork, DayRolloverHour: 4}, time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), "2026-10-31"},
+		{"fall back second 1:30", &Clock{Location: newYork, DayRolloverHour: 4}, time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), "2026-10-31"},
+		{"fall back before rollover", &Clock{Location: newYork, DayRolloverHour: 4}, time.Date(2026, 11, 1, 8, 59, 0, 0, time.UTC), "2026-10-31"},
+		{"fall back at rollover", &Clock{Location: newYork, DayRolloverHour: 4}, time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC), "2026-11-01"},
+		{"fall back midnight", &Clock{Location: newYork}, time.Date(2026, 11, 1, 4, 0, 0, 0, time.UTC), "2026-11-01"},
+
+		// 2026-03-29 柏林 02:00 跳到 03:00，2026-10-25 03:00 回拨到 02:00
+		{"berlin spring forward before rollover", &Clock{Location: berlin, DayRolloverHour: 3}, time.Date(2026, 3, 29, 0, 59, 0, 0, time.UTC), "2026-03-28"},
+		{"berlin spring forward at rollover", &Clock{Location: berlin, DayRolloverHour: 3}, time.Date(2026, 3, 29, 1, 0, 0, 0, time.UTC), "2026-03-29"},
+		{"berlin fall back repeated hour", &Clock{Location: berlin, DayRolloverHour: 3}, time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC), "2026-10-24"},
+		{"berlin fall back at rollover", &Clock{Location: berlin, DayRolloverHour: 3}, time.Date(2026, 10, 25, 2, 0, 0, 0, time.UTC), "2026-10-25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDay(tt.clock.DayOf(tt.instant)); got != tt.expected {
+				t.Fatalf("DayOf(%s) = %s, want %s", tt.instant.In(tt.clock.Location), got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct {
+		day   string
+		start string
+	}{
+		{"2026-10-05", "2026-10-05"},
+		{"2026-10-07", "2026-10-05"},
+		{"2026-10-11", "2026-10-05"},
+		{"2026-11-01", "2026-10-26"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.day, func(t *testing.T) {
+			if got := FormatDay(WeekStart(day(tt.day))); got != tt.start {
+				t.Fatalf("WeekStart(%s) = %s, want %s", tt.day, got, tt.start)
+			}
+		})
+	}
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+
+	return loc
+}
@@ -1,7 +1,18 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
 	"time"
 	"w2learn/internal/dto"
 	"w2learn/pkg/logger"
@@ -10,14 +21,168 @@ import (
 	"go.uber.org/zap"
 )
 
-var globalSecret string
+// JWTKey 为一把以 ID 区分的非对称密钥，PrivateKey 为空时只用于验证
+type JWTKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// JWTConfig 中 SigningKeyID 为签发令牌使用的密钥，Keys 中的其余密钥只用于验证，便于轮换
+// 未配置 Keys 时使用 Secret 以 HS256 签名，配置后只在 AcceptLegacyTokens 为 true 时用 Secret 验证不带 kid 的旧令牌
+type JWTConfig struct {
+	Secret             string
+	Keys               []*JWTKey
+	SigningKeyID       string
+	AcceptLegacyTokens bool
+}
+
+var (
+	globalSecret       string
+	globalSigningKey   *JWTKey
+	globalKeys         map[string]*JWTKey
+	globalAcceptLegacy bool
+)
+
+func InitJwt(config *JWTConfig) error {
+	if globalSecret != "" || globalKeys != nil {
+		logger.Error("JWT keys have already been initialized")
+		return nil
+	}
+
+	keys := make(map[string]*JWTKey, len(config.Keys))
+
+	for _, key := range config.Keys {
+		if key.ID == "" {
+			return errors.New("jwt key id is empty")
+		}
+
+		if _, ok := keys[key.ID]; ok {
+			return fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
 
-func InitJwt(secret string) {
-	if globalSecret == "" {
-		globalSecret = secret
-	} else {
-		logger.Error("JWT secret has already been initialized")
+		keys[key.ID] = key
 	}
+
+	var signingKey *JWTKey
+
+	if len(keys) > 0 {
+		signingKey = keys[config.SigningKeyID]
+
+		if signingKey == nil {
+			return fmt.Errorf("jwt signing key %q not found", config.SigningKeyID)
+		}
+
+		if signingKey.PrivateKey == nil {
+			return fmt.Errorf("jwt signing key %q has no private key", config.SigningKeyID)
+		}
+	} else if config.Secret == "" {
+		return errors.New("jwt secret is empty")
+	}
+
+	// 签发时间精确到毫秒，与令牌失效水位的精度一致
+	jwt.TimePrecision = time.Millisecond
+
+	globalSecret = config.Secret
+	globalSigningKey = signingKey
+	globalKeys = keys
+	globalAcceptLegacy = signingKey == nil || config.AcceptLegacyTokens
+
+	return nil
+}
+
+// LoadJWTKey 从 PEM 文件读取密钥，提供私钥时公钥由私钥推导，支持 RSA（RS256）与 Ed25519（EdDSA）
+func LoadJWTKey(id string, privateKeyFile string, publicKeyFile string) (*JWTKey, error) {
+	key := &JWTKey{ID: id}
+
+	switch {
+	case privateKeyFile != "":
+		block, err := readPEM(privateKeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var privateKey any
+
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+
+		if err != nil {
+			privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("parse jwt private key %q: %w", id, err)
+		}
+
+		signer, ok := privateKey.(crypto.Signer)
+
+		if !ok {
+			return nil, fmt.Errorf("unsupported jwt private key type %T", privateKey)
+		}
+
+		key.PrivateKey = signer
+		key.PublicKey = signer.Public()
+	case publicKeyFile != "":
+		block, err := readPEM(publicKeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key.PublicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+
+		if err != nil {
+			return nil, fmt.Errorf("parse jwt public key %q: %w", id, err)
+		}
+	default:
+		return nil, fmt.Errorf("jwt key %q has neither private nor public key", id)
+	}
+
+	switch publicKey := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported jwt public key type %T", publicKey)
+	}
+
+	return key, nil
+}
+
+// GenerateJWTKeyFile 生成 Ed25519 私钥并以 PKCS#8 PEM 格式写入 file，文件已存在时返回 false，只用于开发环境
+func GenerateJWTKeyFile(file string) (bool, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		return false, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+
+	if err != nil {
+		return false, err
+	}
+
+	return createSecretFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+
+	return block, nil
 }
 
 func GenerateJwtToken(token *dto.UserToken) (string, error) {
@@ -25,17 +190,38 @@ func GenerateJwtToken(token *dto.UserToken) (string, error) {
 		return "", errors.New("token is nil")
 	}
 
-	return jwt.NewWithClaims(jwt.SigningMethodHS256, token).SignedString([]byte(globalSecret))
+	if globalSigningKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, token).SignedString([]byte(globalSecret))
+	}
+
+	t := jwt.NewWithClaims(globalSigningKey.Method, token)
+	t.Header["kid"] = globalSigningKey.ID
+
+	return t.SignedString(globalSigningKey.PrivateKey)
 }
 
+// ParseJWT 按令牌头中的 kid 选择验证密钥，不带 kid 的令牌使用 HS256 密钥验证，关闭旧令牌后直接拒绝
 func ParseJWT(tokenStr string) (*dto.UserToken, error) {
 	token := &dto.UserToken{}
 	claims, err := jwt.ParseWithClaims(tokenStr, token, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := t.Header["kid"].(string)
+
+		if kid == "" {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || globalSecret == "" || !globalAcceptLegacy {
+				return nil, jwt.ErrSignatureInvalid
+			}
+
+			return []byte(globalSecret), nil
+		}
+
+		key, ok := globalKeys[kid]
+
+		// 算法必须与密钥一致，避免算法替换攻击
+		if !ok || t.Method.Alg() != key.Method.Alg() {
 			return nil, jwt.ErrSignatureInvalid
 		}
 
-		return []byte(globalSecret), nil
+		return key.PublicKey, nil
 	})
 
 	if err != nil || !claims.Valid {
@@ -45,6 +231,50 @@ func ParseJWT(tokenStr string) (*dto.UserToken, error) {
 	return token, nil
 }
 
+// JWKS 返回全部验证密钥的公钥，HS256 密钥不会公开
+func JWKS() *dto.JWKSResponse {
+	result := &dto.JWKSResponse{
+		Keys: make([]dto.JWK, 0, len(globalKeys)),
+	}
+
+	// 签名密钥排在首位，其余按 ID 排序，保证输出稳定
+	ids := make([]string, 0, len(globalKeys))
+	for id := range globalKeys {
+		if globalSigningKey == nil || id != globalSigningKey.ID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	if globalSigningKey != nil {
+		ids = append([]string{globalSigningKey.ID}, ids...)
+	}
+
+	for _, id := range ids {
+		key := globalKeys[id]
+		jwk := dto.JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+		}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+
+		result.Keys = append(result.Keys, jwk)
+	}
+
+	return result
+}
+
 func IsTokenExpired(token *dto.UserToken) bool {
 	if token.ExpiresAt.Before(time.Now()) {
 		logger.Error("Token expired",
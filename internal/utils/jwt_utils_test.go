@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestGenerateJWTKeyFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys", "jwt-dev.pem")
+
+	created, err := GenerateJWTKeyFile(file)
+
+	if err != nil || !created {
+		t.Fatalf("GenerateJWTKeyFile = %v, %v, want true, nil", created, err)
+	}
+
+	info, err := os.Stat(file)
+
+	if err != nil {
+		t.Fatalf("stat key file: %v", err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("key file permission = %o, want 600", perm)
+	}
+
+	key, err := LoadJWTKey("dev", file, "")
+
+	if err != nil {
+		t.Fatalf("LoadJWTKey: %v", err)
+	}
+
+	if key.Method != jwt.SigningMethodEdDSA || key.PrivateKey == nil {
+		t.Fatalf("loaded key method = %v, private key set = %v", key.Method.Alg(), key.PrivateKey != nil)
+	}
+
+	before, _ := os.ReadFile(file)
+	created, err = GenerateJWTKeyFile(file)
+
+	if err != nil || created {
+		t.Fatalf("GenerateJWTKeyFile on existing file = %v, %v, want false, nil", created, err)
+	}
+
+	after, _ := os.ReadFile(file)
+
+	if string(before) != string(after) {
+		t.Fatal("existing key file was overwritten")
+	}
+}
+
+func TestGenerateSecretFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "two-factor.key")
+
+	created, err := GenerateSecretFile(file)
+
+	if err != nil || !created {
+		t.Fatalf("GenerateSecretFile = %v, %v, want true, nil", created, err)
+	}
+
+	secret, err := ReadSecretFile(file)
+
+	if err != nil {
+		t.Fatalf("ReadSecretFile: %v", err)
+	}
+
+	if len(secret) != 64 {
+		t.Fatalf("secret length = %d, want 64", len(secret))
+	}
+
+	_, err = NewSecretCipher(secret)
+
+	if err != nil {
+		t.Fatalf("NewSecretCipher: %v", err)
+	}
+}
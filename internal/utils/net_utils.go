@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrNonPublicAddress 表示目标地址为回环、私有、链路本地等不允许投递的地址
+var ErrNonPublicAddress = errors.New("address is not publicly routable")
+
+// nonPublicPrefixes 为 netip 未单独识别、但同样不应由服务端主动访问的地址段
+var nonPublicPrefixes = []netip.Prefix{
+	// 运营商级 NAT，部分云厂商的元数据服务也位于该地址段
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// IsPublicAddr 判断 addr 是否为公网单播地址，IPv4 映射的 IPv6 地址按 IPv4 判断
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckPublicHost 解析 host 并要求其所有地址均为公网地址，host 为 IP 字面量时不查询 DNS
+func CheckPublicHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return ErrNonPublicAddress
+		}
+	}
+
+	return nil
+}
+
+// PublicDialControl 用作 net.Dialer 的 Control，在连接建立前检查实际连接的地址
+// 校验目标时解析的地址与连接时解析的地址可能不同，只在校验时检查无法防止 DNS rebinding
+func PublicDialControl(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+
+	if err != nil {
+		return err
+	}
+
+	if !IsPublicAddr(addrPort.Addr()) {
+		return ErrNonPublicAddress
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"8.8.8.8", true},
+
+		{"127.0.0.1", false},
+		{"127.8.9.10", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"100.100.100.200", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if public := IsPublicAddr(netip.MustParseAddr(tt.addr)); public != tt.public {
+				t.Fatalf("IsPublicAddr(%s) = %v, want %v", tt.addr, public, tt.public)
+			}
+		})
+	}
+}
+
+func TestCheckPublicHost(t *testing.T) {
+	tests := []struct {
+		host string
+		err  error
+	}{
+		{"93.184.216.34", nil},
+		{"127.0.0.1", ErrNonPublicAddress},
+		{"169.254.169.254", ErrNonPublicAddress},
+		{"::1", ErrNonPublicAddress},
+		{"localhost", ErrNonPublicAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if err := CheckPublicHost(context.Background(), tt.host); !errors.Is(err, tt.err) {
+				t.Fatalf("CheckPublicHost(%s) = %v, want %v", tt.host, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPublicDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		err     error
+	}{
+		{"93.184.216.34:443", nil},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", nil},
+		{"127.0.0.1:80", ErrNonPublicAddress},
+		{"[::1]:80", ErrNonPublicAddress},
+		{"169.254.169.254:80", ErrNonPublicAddress},
+		{"10.0.0.1:8080", ErrNonPublicAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if err := PublicDialControl("tcp", tt.address, nil); !errors.Is(err, tt.err) {
+				t.Fatalf("PublicDialControl(%s) = %v, want %v", tt.address, err, tt.err)
+			}
+		})
+	}
+}
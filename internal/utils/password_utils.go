@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"w2learn/pkg/def"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrPasswordHashFormat  = errors.New("invalid password hash format")
+	ErrPasswordHashVersion = errors.New("unsupported argon2 version")
+)
+
+// PasswordHasher 以 PHC 字符串格式保存口令摘要
+// Verify 在口令正确但摘要参数与当前配置不一致时返回 needsRehash
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, encoded string) (ok bool, needsRehash bool, err error)
+	IsHash(encoded string) bool
+}
+
+// Argon2Params 为 argon2id 的成本参数，Memory 单位为 KiB
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+var _ PasswordHasher = (*argon2idHasher)(nil)
+
+type argon2idHasher struct {
+	params Argon2Params
+}
+
+// NewArgon2idHasher 创建 argon2id 口令摘要器，未配置的参数使用默认值
+func NewArgon2idHasher(params *Argon2Params) PasswordHasher {
+	p := Argon2Params{}
+
+	if params != nil {
+		p = *params
+	}
+
+	if p.Memory == 0 {
+		p.Memory = def.PasswordArgon2Memory
+	}
+	if p.Iterations == 0 {
+		p.Iterations = def.PasswordArgon2Iterations
+	}
+	if p.Parallelism == 0 {
+		p.Parallelism = def.PasswordArgon2Parallelism
+	}
+	if p.SaltLength == 0 {
+		p.SaltLength = def.PasswordSaltLength
+	}
+	if p.KeyLength == 0 {
+		p.KeyLength = def.PasswordKeyLength
+	}
+
+	return &argon2idHasher{
+		params: p,
+	}
+}
+
+// Hash 返回形如 $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key> 的摘要
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+
+	_, err := rand.Read(salt)
+
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(password string, encoded string) (bool, bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+
+	if err != nil {
+		return false, false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+
+	params.SaltLength = uint32(len(salt))
+
+	return true, params != h.params, nil
+}
+
+func (h *argon2idHasher) IsHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// 按 $ 拆分后依次为：空串、算法、版本、参数、盐、摘要
+	parts := strings.Split(encoded, "$")
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrPasswordHashFormat
+	}
+
+	var version int
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+
+	if err != nil {
+		return params, nil, nil, ErrPasswordHashFormat
+	}
+
+	if version != argon2.Version {
+		return params, nil, nil, ErrPasswordHashVersion
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+
+	if err != nil {
+		return params, nil, nil, ErrPasswordHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+
+	if err != nil {
+		return params, nil, nil, ErrPasswordHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrPasswordHashFormat
+	}
+
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testArgon2Params 使用极低的成本参数以加快测试
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	hasher := NewArgon2idHasher(&testArgon2Params)
+
+	encoded, err := hasher.Hash("correct horse")
+
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") || !hasher.IsHash(encoded) {
+		t.Fatalf("unexpected hash %q", encoded)
+	}
+
+	other, _ := hasher.Hash("correct horse")
+
+	if other == encoded {
+		t.Fatal("hashing the same password twice produced the same salt")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		ok       bool
+	}{
+		{"correct password", "correct horse", true},
+		{"wrong password", "correct horse!", false},
+		{"empty password", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := hasher.Verify(tt.password, encoded)
+
+			if err != nil || ok != tt.ok || needsRehash {
+				t.Fatalf("Verify = %v, %v, %v, want %v, false, nil", ok, needsRehash, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestArgon2idVerifyMalformed(t *testing.T) {
+	hasher := NewArgon2idHasher(&testArgon2Params)
+	valid, _ := hasher.Hash("secret")
+	parts := strings.Split(valid, "$")
+
+	replace := func(i int, value string) string {
+		p := append([]string(nil), parts...)
+		p[i] = value
+		return strings.Join(p, "$")
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		err     error
+	}{
+		{"empty", "", ErrPasswordHashFormat},
+		{"legacy sha256", HashString("secret", "salt"), ErrPasswordHashFormat},
+		{"too few parts", strings.Join(parts[:5], "$"), ErrPasswordHashFormat},
+		{"too many parts", valid + "$extra", ErrPasswordHashFormat},
+		{"other algorithm", replace(1, "argon2i"), ErrPasswordHashFormat},
+		{"missing version", replace(2, "19"), ErrPasswordHashFormat},
+		{"unsupported version", replace(2, "v=16"), ErrPasswordHashVersion},
+		{"bad params", replace(3, "m=64;t=1;p=1"), ErrPasswordHashFormat},
+		{"bad salt", replace(4, "!!!"), ErrPasswordHashFormat},
+		{"bad key", replace(5, "!!!"), ErrPasswordHashFormat},
+		{"empty key", replace(5, ""), ErrPasswordHashFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := hasher.Verify("secret", tt.encoded)
+
+			if !errors.Is(err, tt.err) || ok || needsRehash {
+				t.Fatalf("Verify = %v, %v, %v, want false, false, %v", ok, needsRehash, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	encoded, err := NewArgon2idHasher(&testArgon2Params).Hash("secret")
+
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	changed := func(f func(p *Argon2Params)) *Argon2Params {
+		p := testArgon2Params
+		f(&p)
+		return &p
+	}
+
+	tests := []struct {
+		name        string
+		params      *Argon2Params
+		needsRehash bool
+	}{
+		{"same params", changed(func(p *Argon2Params) {}), false},
+		{"memory raised", changed(func(p *Argon2Params) { p.Memory = 128 }), true},
+		{"iterations raised", changed(func(p *Argon2Params) { p.Iterations = 2 }), true},
+		{"parallelism changed", changed(func(p *Argon2Params) { p.Parallelism = 2 }), true},
+		{"salt length changed", changed(func(p *Argon2Params) { p.SaltLength = 32 }), true},
+		{"key length changed", changed(func(p *Argon2Params) { p.KeyLength = 64 }), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 校验使用摘要内记录的参数，参数调整后旧摘要仍然可以通过校验
+			ok, needsRehash, err := NewArgon2idHasher(tt.params).Verify("secret", encoded)
+
+			if err != nil || !ok || needsRehash != tt.needsRehash {
+				t.Fatalf("Verify = %v, %v, %v, want true, %v, nil", ok, needsRehash, err, tt.needsRehash)
+			}
+		})
+	}
+}
+
+func TestNewArgon2idHasherDefaults(t *testing.T) {
+	hasher := NewArgon2idHasher(&Argon2Params{Memory: 64}).(*argon2idHasher)
+
+	if hasher.params.Memory != 64 || hasher.params.Iterations == 0 || hasher.params.Parallelism == 0 ||
+		hasher.params.SaltLength == 0 || hasher.params.KeyLength == 0 {
+		t.Fatalf("unexpected params %+v", hasher.params)
+	}
+
+	if *NewArgon2idHasher(nil).(*argon2idHasher) != *NewArgon2idHasher(&Argon2Params{}).(*argon2idHasher) {
+		t.Fatal("nil params should use the defaults")
+	}
+}
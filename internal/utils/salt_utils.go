@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 )
 
@@ -26,7 +27,8 @@ func HashString(s string, salt string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// VerifyString 校验旧版 sha256(s+salt) 摘要，仅用于迁移前创建的账户
 func VerifyString(s string, salt string, hash string) bool {
 	newHash := HashString(s, salt)
-	return newHash == hash
+	return subtle.ConstantTimeCompare([]byte(newHash), []byte(hash)) == 1
 }
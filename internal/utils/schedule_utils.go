@@ -0,0 +1,461 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+)
+
+// Schedule 将习惯的重复规则抽象为一系列计划周期
+// 按天计划的习惯每个计划日是一个周期，按次数计划的习惯每周或每月是一个周期
+type Schedule interface {
+	// Period 返回包含 day 的计划周期 [start, end]，day 不属于任何周期时 ok 为 false
+	Period(day time.Time) (start time.Time, end time.Time, ok bool)
+	// Required 返回一个周期内需要完成的天数
+	Required() int
+}
+
+// ScheduleOf 解析习惯的重复规则，未指定起始日期时以创建日期为锚点
+// 习惯已加载的暂停记录会一并生效
+func ScheduleOf(habit *model.Habit) (Schedule, error) {
+	schedule, err := ParseSchedule(&habit.Schedule, ScheduleAnchor(habit))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return WithPauses(schedule, habit.Pauses), nil
+}
+
+// ScheduleAnchor 返回习惯重复规则的锚点，未指定起始日期时为创建日期
+func ScheduleAnchor(habit *model.Habit) time.Time {
+	if habit.Schedule.StartDay != nil {
+		return DayOf(*habit.Schedule.StartDay)
+	}
+
+	return DayOf(habit.CreatedAt)
+}
+
+// WithPauses 为计划附加暂停期，与暂停期有交集的周期不要求完成，也不会中断连续记录
+func WithPauses(schedule Schedule, pauses []model.HabitPause) Schedule {
+	if len(pauses) == 0 {
+		return schedule
+	}
+
+	return pausedSchedule{Schedule: schedule, pauses: pauses}
+}
+
+// IsPaused 判断周期 [start, end] 是否处于暂停期
+func IsPaused(schedule Schedule, start time.Time, end time.Time) bool {
+	s, ok := schedule.(pausedSchedule)
+
+	if !ok {
+		return false
+	}
+
+	for i := range s.pauses {
+		if s.pauses[i].Overlaps(start, end) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ParseSchedule(schedule *model.HabitSchedule, anchor time.Time) (Schedule, error) {
+	if schedule == nil {
+		return nil, errors.New("schedule is nil")
+	}
+
+	switch schedule.Type {
+	case "", def.HabitScheduleDaily:
+		return dailySchedule{}, nil
+	case def.HabitScheduleWeekdays:
+		if schedule.Weekdays == 0 {
+			return nil, errors.New("weekdays schedule requires at least one weekday")
+		}
+		return weekdaySchedule{weekdays: schedule.Weekdays}, nil
+	case def.HabitScheduleInterval:
+		if schedule.Interval < 1 || schedule.Interval > def.HabitScheduleMaxInterval {
+			return nil, fmt.Errorf("interval must be between 1 and %d", def.HabitScheduleMaxInterval)
+		}
+		return intervalSchedule{anchor: anchor, interval: schedule.Interval}, nil
+	case def.HabitScheduleWeekly:
+		if schedule.Times < 1 || schedule.Times > def.HabitScheduleMaxWeeklyTimes {
+			return nil, fmt.Errorf("weekly times must be between 1 and %d", def.HabitScheduleMaxWeeklyTimes)
+		}
+		return weeklySchedule{times: schedule.Times}, nil
+	case def.HabitScheduleMonthly:
+		if schedule.Times < 1 || schedule.Times > def.HabitScheduleMaxMonthlyTimes {
+			return nil, fmt.Errorf("monthly times must be between 1 and %d", def.HabitScheduleMaxMonthlyTimes)
+		}
+		return monthlySchedule{times: schedule.Times}, nil
+	case def.HabitScheduleRRule:
+		return parseRRule(schedule.RRule, anchor)
+	default:
+		return nil, fmt.Errorf("unsupported schedule type: %s", schedule.Type)
+	}
+}
+
+// NextPeriod 返回 after 之后的第一个计划周期
+func NextPeriod(schedule Schedule, after time.Time) (time.Time, time.Time, bool) {
+	for i := 1; i <= def.HabitScheduleScanMaxDays; i++ {
+		start, end, ok := schedule.Period(after.AddDate(0, 0, i))
+
+		if ok {
+			return start, end, true
+		}
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// PrevPeriod 返回 before 之前的最后一个计划周期
+func PrevPeriod(schedule Schedule, before time.Time) (time.Time, time.Time, bool) {
+	for i := 1; i <= def.HabitScheduleScanMaxDays; i++ {
+		start, end, ok := schedule.Period(before.AddDate(0, 0, -i))
+
+		if ok {
+			return start, end, true
+		}
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// ExpectedHabitDays 返回习惯在 [from, to] 内要求完成的天数，计划开始之前的日期不计入
+func ExpectedHabitDays(habit *model.Habit, from time.Time, to time.Time) (int, error) {
+	schedule, err := ScheduleOf(habit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	start := ScheduleAnchor(habit)
+
+	if start.After(from) {
+		from = start
+	}
+
+	if from.After(to) {
+		return 0, nil
+	}
+
+	return ExpectedDays(schedule, from, to), nil
+}
+
+// ExpectedDays 返回 [from, to] 内计划要求完成的天数，暂停的周期不计入
+// 只有部分落在区间内的周期，要求的天数不超过其在区间内的天数
+func ExpectedDays(schedule Schedule, from time.Time, to time.Time) int {
+	total := 0
+
+	for day := from; !day.After(to); {
+		start, end, ok := schedule.Period(day)
+
+		if !ok {
+			day = day.AddDate(0, 0, 1)
+			continue
+		}
+
+		if !IsPaused(schedule, start, end) {
+			if start.Before(from) {
+				start = from
+			}
+
+			if end.After(to) {
+				end = to
+			}
+
+			total += min(schedule.Required(), daysBetween(start, end)+1)
+		}
+
+		day = end.AddDate(0, 0, 1)
+	}
+
+	return total
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func monthStart(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+type pausedSchedule struct {
+	Schedule
+	pauses []model.HabitPause
+}
+
+type dailySchedule struct{}
+
+func (dailySchedule) Period(day time.Time) (time.Time, time.Time, bool) {
+	return day, day, true
+}
+
+func (dailySchedule) Required() int {
+	return 1
+}
+
+type weekdaySchedule struct {
+	weekdays model.Weekdays
+}
+
+func (s weekdaySchedule) Period(day time.Time) (time.Time, time.Time, bool) {
+	return day, day, s.weekdays.Has(day.Weekday())
+}
+
+func (weekdaySchedule) Required() int {
+	return 1
+}
+
+// intervalSchedule 从锚点开始每 interval 天为一个周期，周期内完成一次即可
+type intervalSchedule struct {
+	anchor   time.Time
+	interval int
+}
+
+func (s intervalSchedule) Period(day time.Time) (time.Time, time.Time, bool) {
+	if day.Before(s.anchor) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start := s.anchor.AddDate(0, 0, daysBetween(s.anchor, day)/s.interval*s.interval)
+
+	return start, start.AddDate(0, 0, s.interval-1), true
+}
+
+func (intervalSchedule) Required() int {
+	return 1
+}
+
+// weeklySchedule 每周（周一至周日）完成 times 天
+type weeklySchedule struct {
+	times int
+}
+
+func (weeklySchedule) Period(day time.Time) (time.Time, time.Time, bool) {
+	start := WeekStart(day)
+	return start, start.AddDate(0, 0, 6), true
+}
+
+func (s weeklySchedule) Required() int {
+	return s.times
+}
+
+// monthlySchedule 每个自然月完成 times 天
+type monthlySchedule struct {
+	times int
+}
+
+func (monthlySchedule) Period(day time.Time) (time.Time, time.Time, bool) {
+	start := monthStart(day)
+	return start, start.AddDate(0, 1, -1), true
+}
+
+func (s monthlySchedule) Required() int {
+	return s.times
+}
+
+// rruleSchedule 支持 iCalendar RRULE 的子集：
+// FREQ=DAILY/WEEKLY/MONTHLY、INTERVAL、BYDAY（不含序号）、BYMONTHDAY、COUNT、UNTIL
+// 每个重复日期是一个周期，COUNT 在解析时换算为最后一次重复的日期保存在 until 中
+type rruleSchedule struct {
+	start      time.Time
+	freq       string
+	interval   int
+	byDay      model.Weekdays
+	byMonthDay []int
+	count      int
+	until      *time.Time
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func parseRRule(rule string, start time.Time) (Schedule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+
+	if rule == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	s := rruleSchedule{start: start, interval: 1}
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, found := strings.Cut(part, "=")
+
+		if !found || value == "" {
+			return nil, fmt.Errorf("invalid rrule part: %s", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			s.freq = strings.ToUpper(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+
+			if err != nil || n < 1 || n > def.HabitScheduleMaxInterval {
+				return nil, fmt.Errorf("invalid rrule INTERVAL: %s", value)
+			}
+
+			s.interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(strings.ToUpper(value), ",") {
+				d, ok := rruleWeekdays[code]
+
+				if !ok {
+					return nil, fmt.Errorf("invalid rrule BYDAY: %s", code)
+				}
+
+				s.byDay |= model.NewWeekdays(d)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid rrule BYMONTHDAY: %s", v)
+				}
+
+				s.byMonthDay = append(s.byMonthDay, n)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid rrule COUNT: %s", value)
+			}
+
+			s.count = n
+		case "UNTIL":
+			if len(value) < 8 {
+				return nil, fmt.Errorf("invalid rrule UNTIL: %s", value)
+			}
+
+			until, err := time.ParseInLocation("20060102", value[:8], time.UTC)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid rrule UNTIL: %s", value)
+			}
+
+			s.until = &until
+		default:
+			return nil, fmt.Errorf("unsupported rrule part: %s", key)
+		}
+	}
+
+	switch s.freq {
+	case "DAILY", "WEEKLY", "MONTHLY":
+	case "":
+		return nil, errors.New("rrule FREQ is required")
+	default:
+		return nil, fmt.Errorf("unsupported rrule FREQ: %s", s.freq)
+	}
+
+	if s.count > 0 && s.until != nil {
+		return nil, errors.New("rrule COUNT and UNTIL can't be used together")
+	}
+
+	if s.freq == "WEEKLY" && s.byDay == 0 {
+		s.byDay = model.NewWeekdays(start.Weekday())
+	}
+
+	if s.count > 0 {
+		s.until = s.lastOccurrence()
+	}
+
+	return s, nil
+}
+
+// lastOccurrence 返回第 count 次重复的日期，扫描范围内不足 count 次时返回 nil
+func (s rruleSchedule) lastOccurrence() *time.Time {
+	n := 0
+
+	for i := 0; i < def.HabitRRuleCountScanMaxDays; i++ {
+		day := s.start.AddDate(0, 0, i)
+
+		if !s.matches(day) {
+			continue
+		}
+
+		n++
+
+		if n == s.count {
+			return &day
+		}
+	}
+
+	return nil
+}
+
+func (s rruleSchedule) Period(day time.Time) (time.Time, time.Time, bool) {
+	if !s.matches(day) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return day, day, true
+}
+
+func (rruleSchedule) Required() int {
+	return 1
+}
+
+func (s rruleSchedule) matches(day time.Time) bool {
+	if day.Before(s.start) || (s.until != nil && day.After(*s.until)) {
+		return false
+	}
+
+	if s.byDay != 0 && !s.byDay.Has(day.Weekday()) {
+		return false
+	}
+
+	if len(s.byMonthDay) > 0 && !s.matchesMonthDay(day) {
+		return false
+	}
+
+	switch s.freq {
+	case "DAILY":
+		return daysBetween(s.start, day)%s.interval == 0
+	case "WEEKLY":
+		return daysBetween(WeekStart(s.start), WeekStart(day))/7%s.interval == 0
+	case "MONTHLY":
+		months := (day.Year()-s.start.Year())*12 + int(day.Month()-s.start.Month())
+
+		if months%s.interval != 0 {
+			return false
+		}
+
+		if s.byDay == 0 && len(s.byMonthDay) == 0 {
+			return day.Day() == s.start.Day()
+		}
+
+		return true
+	default:
+		return false
+	}
+}
+
+func (s rruleSchedule) matchesMonthDay(day time.Time) bool {
+	last := monthStart(day).AddDate(0, 1, -1).Day()
+
+	for _, n := range s.byMonthDay {
+		if (n > 0 && day.Day() == n) || (n < 0 && day.Day() == last+n+1) {
+			return true
+		}
+	}
+
+	return false
+}
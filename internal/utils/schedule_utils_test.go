@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+)
+
+func rrule(rule string) model.HabitSchedule {
+	return model.HabitSchedule{Type: def.HabitScheduleRRule, RRule: rule}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.HabitSchedule
+	}{
+		{"unknown type", model.HabitSchedule{Type: "yearly"}},
+		{"weekdays empty", model.HabitSchedule{Type: def.HabitScheduleWeekdays}},
+		{"interval zero", model.HabitSchedule{Type: def.HabitScheduleInterval}},
+		{"interval too long", model.HabitSchedule{Type: def.HabitScheduleInterval, Interval: def.HabitScheduleMaxInterval + 1}},
+		{"weekly times zero", model.HabitSchedule{Type: def.HabitScheduleWeekly}},
+		{"weekly times over seven", model.HabitSchedule{Type: def.HabitScheduleWeekly, Times: 8}},
+		{"monthly times over limit", model.HabitSchedule{Type: def.HabitScheduleMonthly, Times: def.HabitScheduleMaxMonthlyTimes + 1}},
+		{"rrule empty", rrule("")},
+		{"rrule missing freq", rrule("INTERVAL=2")},
+		{"rrule unsupported freq", rrule("FREQ=YEARLY")},
+		{"rrule count and until", rrule("FREQ=DAILY;COUNT=3;UNTIL=20261010")},
+		{"rrule count zero", rrule("FREQ=DAILY;COUNT=0")},
+		{"rrule bad byday", rrule("FREQ=WEEKLY;BYDAY=XX")},
+		{"rrule ordinal byday", rrule("FREQ=MONTHLY;BYDAY=1MO")},
+		{"rrule bad bymonthday", rrule("FREQ=MONTHLY;BYMONTHDAY=32")},
+		{"rrule zero bymonthday", rrule("FREQ=MONTHLY;BYMONTHDAY=0")},
+		{"rrule bad until", rrule("FREQ=DAILY;UNTIL=2026")},
+		{"rrule unsupported part", rrule("FREQ=DAILY;BYHOUR=8")},
+		{"rrule part without value", rrule("FREQ=DAILY;INTERVAL=")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchedule(&tt.schedule, day("2026-10-01"))
+
+			if err == nil {
+				t.Fatalf("ParseSchedule(%+v) succeeded, want error", tt.schedule)
+			}
+		})
+	}
+}
+
+func TestSchedulePeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.HabitSchedule
+		anchor   string
+		day      string
+		start    string
+		end      string
+	}{
+		{"daily", dailyRule, "2026-10-01", "2026-10-07", "2026-10-07", "2026-10-07"},
+		{"weekday due", monWedFri, "2026-10-01", "2026-10-07", "2026-10-07", "2026-10-07"},
+		{"weekday not due", monWedFri, "2026-10-01", "2026-10-08", "", ""},
+
+		{"interval before anchor", everyThird, "2026-10-01", "2026-09-30", "", ""},
+		{"interval anchor day", everyThird, "2026-10-01", "2026-10-01", "2026-10-01", "2026-10-03"},
+		{"interval last day of period", everyThird, "2026-10-01", "2026-10-03", "2026-10-01", "2026-10-03"},
+		{"interval next period", everyThird, "2026-10-01", "2026-10-04", "2026-10-04", "2026-10-06"},
+		{"interval across month", everyThird, "2026-10-30", "2026-11-02", "2026-11-02", "2026-11-04"},
+		{"interval anchor phase", everyThird, "2026-10-02", "2026-10-04", "2026-10-02", "2026-10-04"},
+
+		{"weekly monday", twiceAWeek, "2026-10-01", "2026-10-12", "2026-10-12", "2026-10-18"},
+		{"weekly sunday", twiceAWeek, "2026-10-01", "2026-10-18", "2026-10-12", "2026-10-18"},
+		{"weekly across month", twiceAWeek, "2026-10-01", "2026-11-01", "2026-10-26", "2026-11-01"},
+
+		{"monthly february", twiceAMonth, "2026-10-01", "2026-02-14", "2026-02-01", "2026-02-28"},
+		{"monthly leap february", twiceAMonth, "2026-10-01", "2028-02-10", "2028-02-01", "2028-02-29"},
+		{"monthly december", twiceAMonth, "2026-10-01", "2026-12-31", "2026-12-01", "2026-12-31"},
+
+		{"rrule daily interval", rrule("FREQ=DAILY;INTERVAL=2"), "2026-10-01", "2026-10-03", "2026-10-03", "2026-10-03"},
+		{"rrule daily interval off day", rrule("FREQ=DAILY;INTERVAL=2"), "2026-10-01", "2026-10-04", "", ""},
+		{"rrule weekly defaults to anchor weekday", rrule("FREQ=WEEKLY"), "2026-10-07", "2026-10-14", "2026-10-14", "2026-10-14"},
+		{"rrule weekly other weekday", rrule("FREQ=WEEKLY"), "2026-10-07", "2026-10-13", "", ""},
+		{"rrule biweekly on week", rrule("FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"), "2026-10-05", "2026-10-20", "2026-10-20", "2026-10-20"},
+		{"rrule biweekly off week", rrule("FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"), "2026-10-05", "2026-10-13", "", ""},
+		{"rrule prefix", rrule("RRULE:FREQ=DAILY"), "2026-10-01", "2026-10-02", "2026-10-02", "2026-10-02"},
+
+		{"rrule month end in february", rrule("FREQ=MONTHLY;BYMONTHDAY=-1"), "2026-01-01", "2026-02-28", "2026-02-28", "2026-02-28"},
+		{"rrule month end in april", rrule("FREQ=MONTHLY;BYMONTHDAY=-1"), "2026-01-01", "2026-04-30", "2026-04-30", "2026-04-30"},
+		{"rrule day before month end", rrule("FREQ=MONTHLY;BYMONTHDAY=-1"), "2026-01-01", "2026-01-30", "", ""},
+		{"rrule 31st skips short month", rrule("FREQ=MONTHLY"), "2026-01-31", "2026-02-28", "", ""},
+		{"rrule 31st in long month", rrule("FREQ=MONTHLY"), "2026-01-31", "2026-03-31", "2026-03-31", "2026-03-31"},
+		{"rrule monthly interval", rrule("FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=15"), "2026-01-01", "2026-03-15", "2026-03-15", "2026-03-15"},
+		{"rrule monthly interval off month", rrule("FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=15"), "2026-01-01", "2026-02-15", "", ""},
+
+		{"rrule count last occurrence", rrule("FREQ=DAILY;COUNT=3"), "2026-10-01", "2026-10-03", "2026-10-03", "2026-10-03"},
+		{"rrule count exhausted", rrule("FREQ=DAILY;COUNT=3"), "2026-10-01", "2026-10-04", "", ""},
+		{"rrule count with byday", rrule("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3"), "2026-10-05", "2026-10-12", "2026-10-12", "2026-10-12"},
+		{"rrule count with byday exhausted", rrule("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3"), "2026-10-05", "2026-10-14", "", ""},
+		{"rrule count skips months without the day", rrule("FREQ=MONTHLY;BYMONTHDAY=31;COUNT=2"), "2026-10-01", "2026-12-31", "2026-12-31", "2026-12-31"},
+		{"rrule count skips months without the day exhausted", rrule("FREQ=MONTHLY;BYMONTHDAY=31;COUNT=2"), "2026-10-01", "2027-01-31", "", ""},
+		{"rrule until inclusive", rrule("FREQ=DAILY;UNTIL=20261010T235959Z"), "2026-10-01", "2026-10-10", "2026-10-10", "2026-10-10"},
+		{"rrule after until", rrule("FREQ=DAILY;UNTIL=20261010"), "2026-10-01", "2026-10-11", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := mustSchedule(t, tt.schedule, tt.anchor)
+
+			start, end, ok := schedule.Period(day(tt.day))
+
+			if tt.start == "" {
+				if ok {
+					t.Fatalf("Period(%s) = %s..%s, want none", tt.day, FormatDay(start), FormatDay(end))
+				}
+				return
+			}
+
+			if !ok || !start.Equal(day(tt.start)) || !end.Equal(day(tt.end)) {
+				t.Fatalf("Period(%s) = %s..%s (%v), want %s..%s", tt.day, FormatDay(start), FormatDay(end), ok, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestScheduleRequired(t *testing.T) {
+	tests := []struct {
+		schedule model.HabitSchedule
+		required int
+	}{
+		{dailyRule, 1},
+		{monWedFri, 1},
+		{everyThird, 1},
+		{model.HabitSchedule{Type: def.HabitScheduleWeekly, Times: 3}, 3},
+		{model.HabitSchedule{Type: def.HabitScheduleMonthly, Times: 10}, 10},
+		{rrule("FREQ=DAILY"), 1},
+	}
+
+	for _, tt := range tests {
+		if got := mustSchedule(t, tt.schedule, "2026-10-01").Required(); got != tt.required {
+			t.Errorf("Required(%+v) = %d, want %d", tt.schedule, got, tt.required)
+		}
+	}
+}
+
+func TestNextAndPrevPeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.HabitSchedule
+		anchor   string
+		from     string
+		next     string
+		prev     string
+	}{
+		{"weekdays", monWedFri, "2026-10-01", "2026-10-09", "2026-10-12", "2026-10-07"},
+		{"interval from period end", everyThird, "2026-10-01", "2026-10-06", "2026-10-07", "2026-10-04"},
+		{"interval before anchor", everyThird, "2026-10-01", "2026-09-30", "2026-10-01", ""},
+		{"rrule count exhausted", rrule("FREQ=DAILY;COUNT=3"), "2026-10-01", "2026-10-03", "", "2026-10-02"},
+		{"rrule after until", rrule("FREQ=WEEKLY;UNTIL=20261020"), "2026-10-01", "2026-10-15", "", "2026-10-08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := mustSchedule(t, tt.schedule, tt.anchor)
+
+			next, _, ok := NextPeriod(schedule, day(tt.from))
+
+			if (tt.next == "") == ok || (ok && !next.Equal(day(tt.next))) {
+				t.Fatalf("NextPeriod(%s) = %s (%v), want %q", tt.from, FormatDay(next), ok, tt.next)
+			}
+
+			prev, _, ok := PrevPeriod(schedule, day(tt.from))
+
+			if (tt.prev == "") == ok || (ok && !prev.Equal(day(tt.prev))) {
+				t.Fatalf("PrevPeriod(%s) = %s (%v), want %q", tt.from, FormatDay(prev), ok, tt.prev)
+			}
+		})
+	}
+}
+
+func TestExpectedDays(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.HabitSchedule
+		pauses   []model.HabitPause
+		from     string
+		to       string
+		expected int
+	}{
+		{"daily week", dailyRule, nil, "2026-10-12", "2026-10-18", 7},
+		{"weekdays week", monWedFri, nil, "2026-10-12", "2026-10-18", 3},
+		{"weekly full week", twiceAWeek, nil, "2026-10-12", "2026-10-18", 2},
+		{"weekly partial week capped by days", twiceAWeek, nil, "2026-10-18", "2026-10-18", 1},
+		{"weekly two weeks", twiceAWeek, nil, "2026-10-12", "2026-10-25", 4},
+		{"monthly full month", twiceAMonth, nil, "2026-10-01", "2026-10-31", 2},
+		{"interval", everyThird, nil, "2026-09-01", "2026-09-09", 3},
+		{"daily with pause", dailyRule, []model.HabitPause{pause("2026-10-14", "2026-10-15")}, "2026-10-12", "2026-10-18", 5},
+		{"weekly paused week", twiceAWeek, []model.HabitPause{pause("2026-10-14", "2026-10-14")}, "2026-10-12", "2026-10-25", 2},
+		{"rrule count", rrule("FREQ=DAILY;COUNT=3"), nil, "2026-09-01", "2026-09-30", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := WithPauses(mustSchedule(t, tt.schedule, streakAnchor), tt.pauses)
+
+			if got := ExpectedDays(schedule, day(tt.from), day(tt.to)); got != tt.expected {
+				t.Fatalf("ExpectedDays = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScheduleOfAnchor(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 15, 30, 0, 0, time.UTC)
+	startDay := day("2026-10-02")
+
+	tests := []struct {
+		name     string
+		startDay *time.Time
+		day      string
+		start    string
+	}{
+		{"created day", nil, "2026-10-04", "2026-10-04"},
+		{"explicit start day", &startDay, "2026-10-04", "2026-10-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habit := &model.Habit{CreatedAt: createdAt, Schedule: everyThird}
+			habit.Schedule.StartDay = tt.startDay
+
+			schedule, err := ScheduleOf(habit)
+
+			if err != nil {
+				t.Fatalf("ScheduleOf: %v", err)
+			}
+
+			start, _, ok := schedule.Period(day(tt.day))
+
+			if !ok || !start.Equal(day(tt.start)) {
+				t.Fatalf("Period(%s) start = %s (%v), want %s", tt.day, FormatDay(start), ok, tt.start)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"time"
+	"w2learn/internal/model"
+)
+
+// ApplyStreakCheckIn 在 day 新增打卡后增量更新连续打卡状态，done 为 day 所在周期内已完成的天数
+// 返回 false 表示无法增量计算（如补签历史周期），调用方需要重建
+func ApplyStreakCheckIn(streak *model.HabitStreak, schedule Schedule, day time.Time, done int) bool {
+	start, _, ok := schedule.Period(day)
+
+	if !ok {
+		return true
+	}
+
+	if streak.PeriodStart == nil || !start.Before(*streak.PeriodStart) {
+		streak.PeriodStart = &start
+		streak.PeriodProgress = done
+	}
+
+	if done != schedule.Required() {
+		return true
+	}
+
+	return extendStreak(streak, schedule, start)
+}
+
+// ApplyStreakUndo 在 day 撤销打卡后增量更新连续打卡状态，done 为撤销后 day 所在周期内已完成的天数
+// 只有最近一个已完成周期被撤销且不影响最长记录时才能增量计算，其余情况返回 false
+func ApplyStreakUndo(streak *model.HabitStreak, schedule Schedule, day time.Time, done int) bool {
+	start, _, ok := schedule.Period(day)
+
+	if !ok {
+		return true
+	}
+
+	if streak.PeriodStart != nil && start.Equal(*streak.PeriodStart) {
+		streak.PeriodProgress = done
+	}
+
+	if done != schedule.Required()-1 {
+		return true
+	}
+
+	if streak.LastPeriod == nil || !start.Equal(*streak.LastPeriod) {
+		return false
+	}
+
+	if streak.CurrentStreak <= 1 || streak.CurrentStreak >= streak.LongestStreak {
+		return false
+	}
+
+	prev, prevEnd, ok := PrevPeriod(schedule, start)
+
+	if !ok || IsPaused(schedule, prev, prevEnd) {
+		return false
+	}
+
+	streak.CurrentStreak--
+	streak.LastPeriod = &prev
+
+	return true
+}
+
+// RebuildStreak 根据升序排列的全部已完成日期重新计算连续打卡状态
+func RebuildStreak(streak *model.HabitStreak, schedule Schedule, days []time.Time) {
+	streak.CurrentStreak = 0
+	streak.LongestStreak = 0
+	streak.LastPeriod = nil
+	streak.PeriodStart = nil
+	streak.PeriodProgress = 0
+
+	for _, day := range days {
+		start, _, ok := schedule.Period(day)
+
+		if !ok {
+			continue
+		}
+
+		if streak.PeriodStart == nil || !start.Equal(*streak.PeriodStart) {
+			streak.PeriodStart = &start
+			streak.PeriodProgress = 0
+		}
+
+		streak.PeriodProgress++
+
+		if streak.PeriodProgress == schedule.Required() {
+			extendStreak(streak, schedule, start)
+		}
+	}
+}
+
+// StreakStatus 返回截至 today 仍然有效的连续周期数，以及当前周期剩余天数是否已不足以完成
+func StreakStatus(streak *model.HabitStreak, schedule Schedule, today time.Time) (int, bool) {
+	if streak == nil || streak.LastPeriod == nil {
+		return 0, false
+	}
+
+	_, lastEnd, ok := schedule.Period(*streak.LastPeriod)
+
+	if !ok {
+		return 0, false
+	}
+
+	if !lastEnd.Before(today) {
+		return streak.CurrentStreak, false
+	}
+
+	start, end, ok := nextUnpausedPeriod(schedule, lastEnd, today)
+
+	switch {
+	case !ok || today.Before(start):
+		return streak.CurrentStreak, false
+	case end.Before(today):
+		return 0, false
+	case IsPaused(schedule, start, end):
+		return streak.CurrentStreak, false
+	default:
+		remaining := schedule.Required() - PeriodProgress(streak, start)
+		return streak.CurrentStreak, remaining > 0 && daysBetween(today, end)+1 <= remaining
+	}
+}
+
+// IsDue 判断 today 所在的计划周期是否仍需打卡
+func IsDue(streak *model.HabitStreak, schedule Schedule, today time.Time) bool {
+	start, end, ok := schedule.Period(today)
+
+	if !ok || IsPaused(schedule, start, end) {
+		return false
+	}
+
+	return PeriodProgress(streak, start) < schedule.Required()
+}
+
+// PeriodProgress 返回起始于 start 的周期内已完成的天数
+func PeriodProgress(streak *model.HabitStreak, start time.Time) int {
+	if streak == nil || streak.PeriodStart == nil || !start.Equal(*streak.PeriodStart) {
+		return 0
+	}
+
+	return streak.PeriodProgress
+}
+
+// extendStreak 将起始于 start 的已完成周期接入连续记录
+func extendStreak(streak *model.HabitStreak, schedule Schedule, start time.Time) bool {
+	if streak.LastPeriod == nil {
+		streak.CurrentStreak = 1
+	} else {
+		last := *streak.LastPeriod
+
+		if !start.After(last) {
+			return start.Equal(last)
+		}
+
+		_, lastEnd, _ := schedule.Period(last)
+		next, _, ok := nextUnpausedPeriod(schedule, lastEnd, start)
+
+		if ok && start.Equal(next) {
+			streak.CurrentStreak++
+		} else {
+			streak.CurrentStreak = 1
+		}
+	}
+
+	streak.LastPeriod = &start
+
+	if streak.CurrentStreak > streak.LongestStreak {
+		streak.LongestStreak = streak.CurrentStreak
+	}
+
+	return true
+}
+
+// nextUnpausedPeriod 返回 after 之后第一个未暂停的周期，起始于 until 及之后的周期即使处于暂停期也直接返回
+func nextUnpausedPeriod(schedule Schedule, after time.Time, until time.Time) (time.Time, time.Time, bool) {
+	start, end, ok := NextPeriod(schedule, after)
+
+	for ok && start.Before(until) && IsPaused(schedule, start, end) {
+		start, end, ok = NextPeriod(schedule, end)
+	}
+
+	return start, end, ok
+}
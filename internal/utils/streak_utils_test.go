@@ -0,0 +1,472 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+)
+
+// day 解析 2006-01-02 格式的日期，只用于测试
+func day(s string) time.Time {
+	d, err := ParseDay(s)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func days(s ...string) []time.Time {
+	result := make([]time.Time, 0, len(s))
+	for _, v := range s {
+		result = append(result, day(v))
+	}
+	return result
+}
+
+func mustSchedule(t *testing.T, schedule model.HabitSchedule, anchor string) Schedule {
+	t.Helper()
+
+	s, err := ParseSchedule(&schedule, day(anchor))
+
+	if err != nil {
+		t.Fatalf("ParseSchedule(%+v): %v", schedule, err)
+	}
+
+	return s
+}
+
+func pause(start string, end string) model.HabitPause {
+	p := model.HabitPause{StartDay: day(start)}
+
+	if end != "" {
+		endDay := day(end)
+		p.EndDay = &endDay
+	}
+
+	return p
+}
+
+func dayPtr(s string) *time.Time {
+	d := day(s)
+	return &d
+}
+
+func sameDay(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+// 2026-10-05 为周一
+var (
+	dailyRule    = model.HabitSchedule{Type: def.HabitScheduleDaily}
+	monWedFri    = model.HabitSchedule{Type: def.HabitScheduleWeekdays, Weekdays: model.NewWeekdays(time.Monday, time.Wednesday, time.Friday)}
+	twiceAWeek   = model.HabitSchedule{Type: def.HabitScheduleWeekly, Times: 2}
+	everyThird   = model.HabitSchedule{Type: def.HabitScheduleInterval, Interval: 3}
+	twiceAMonth  = model.HabitSchedule{Type: def.HabitScheduleMonthly, Times: 2}
+	streakAnchor = "2026-09-01"
+)
+
+type streakCase struct {
+	name     string
+	schedule model.HabitSchedule
+	pauses   []model.HabitPause
+	days     []time.Time
+	current  int
+	longest  int
+	last     *time.Time
+}
+
+var streakCases = []streakCase{
+	{
+		name:     "no check-ins",
+		schedule: dailyRule,
+	},
+	{
+		name:     "daily consecutive",
+		schedule: dailyRule,
+		days:     days("2026-10-01", "2026-10-02", "2026-10-03"),
+		current:  3,
+		longest:  3,
+		last:     dayPtr("2026-10-03"),
+	},
+	{
+		name:     "daily gap resets current",
+		schedule: dailyRule,
+		days:     days("2026-10-01", "2026-10-02", "2026-10-04"),
+		current:  1,
+		longest:  2,
+		last:     dayPtr("2026-10-04"),
+	},
+	{
+		name:     "daily pause bridges gap",
+		schedule: dailyRule,
+		pauses:   []model.HabitPause{pause("2026-10-03", "2026-10-04")},
+		days:     days("2026-10-01", "2026-10-02", "2026-10-05"),
+		current:  3,
+		longest:  3,
+		last:     dayPtr("2026-10-05"),
+	},
+	{
+		name:     "daily check-in inside pause still counts",
+		schedule: dailyRule,
+		pauses:   []model.HabitPause{pause("2026-10-02", "2026-10-03")},
+		days:     days("2026-10-01", "2026-10-03", "2026-10-04"),
+		current:  3,
+		longest:  3,
+		last:     dayPtr("2026-10-04"),
+	},
+	{
+		name:     "weekdays skip non-due days",
+		schedule: monWedFri,
+		days:     days("2026-10-05", "2026-10-06", "2026-10-07", "2026-10-09", "2026-10-12"),
+		current:  4,
+		longest:  4,
+		last:     dayPtr("2026-10-12"),
+	},
+	{
+		name:     "weekdays missed due day",
+		schedule: monWedFri,
+		days:     days("2026-10-05", "2026-10-09", "2026-10-12"),
+		current:  2,
+		longest:  2,
+		last:     dayPtr("2026-10-12"),
+	},
+	{
+		name:     "weekly times counts completed weeks",
+		schedule: twiceAWeek,
+		days:     days("2026-10-05", "2026-10-06", "2026-10-13", "2026-10-18", "2026-10-19"),
+		current:  2,
+		longest:  2,
+		last:     dayPtr("2026-10-12"),
+	},
+	{
+		name:     "weekly missed week",
+		schedule: twiceAWeek,
+		days:     days("2026-09-28", "2026-09-29", "2026-10-05", "2026-10-12", "2026-10-14"),
+		current:  1,
+		longest:  1,
+		last:     dayPtr("2026-10-12"),
+	},
+	{
+		name:     "weekly paused week",
+		schedule: twiceAWeek,
+		pauses:   []model.HabitPause{pause("2026-10-07", "2026-10-09")},
+		days:     days("2026-09-28", "2026-09-29", "2026-10-12", "2026-10-14"),
+		current:  2,
+		longest:  2,
+		last:     dayPtr("2026-10-12"),
+	},
+	{
+		name:     "interval periods",
+		schedule: everyThird,
+		days:     days("2026-09-02", "2026-09-06", "2026-09-07", "2026-09-13"),
+		current:  1,
+		longest:  3,
+		last:     dayPtr("2026-09-13"),
+	},
+	{
+		name:     "monthly times",
+		schedule: twiceAMonth,
+		days:     days("2026-09-01", "2026-09-30", "2026-10-15", "2026-10-31", "2026-11-02"),
+		current:  2,
+		longest:  2,
+		last:     dayPtr("2026-10-01"),
+	},
+	{
+		name:     "longest kept after reset",
+		schedule: dailyRule,
+		days:     days("2026-09-01", "2026-09-02", "2026-09-03", "2026-09-04", "2026-09-05", "2026-10-01", "2026-10-02"),
+		current:  2,
+		longest:  5,
+		last:     dayPtr("2026-10-02"),
+	},
+}
+
+func TestRebuildStreak(t *testing.T) {
+	for _, tt := range streakCases {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := WithPauses(mustSchedule(t, tt.schedule, streakAnchor), tt.pauses)
+			streak := &model.HabitStreak{}
+
+			RebuildStreak(streak, schedule, tt.days)
+
+			if streak.CurrentStreak != tt.current || streak.LongestStreak != tt.longest {
+				t.Fatalf("current/longest = %d/%d, want %d/%d", streak.CurrentStreak, streak.LongestStreak, tt.current, tt.longest)
+			}
+
+			if !sameDay(streak.LastPeriod, tt.last) {
+				t.Fatalf("last period = %v, want %v", streak.LastPeriod, tt.last)
+			}
+		})
+	}
+}
+
+// 按时间顺序逐个打卡的增量结果应与全量重建一致
+func TestApplyStreakCheckInMatchesRebuild(t *testing.T) {
+	for _, tt := range streakCases {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := WithPauses(mustSchedule(t, tt.schedule, streakAnchor), tt.pauses)
+			streak := &model.HabitStreak{}
+
+			for i, d := range tt.days {
+				start, _, ok := schedule.Period(d)
+				done := 0
+
+				for _, prev := range tt.days[:i+1] {
+					if s, _, _ := schedule.Period(prev); ok && s.Equal(start) {
+						done++
+					}
+				}
+
+				if !ApplyStreakCheckIn(streak, schedule, d, done) {
+					t.Fatalf("ApplyStreakCheckIn(%s) requested a rebuild", FormatDay(d))
+				}
+			}
+
+			rebuilt := &model.HabitStreak{}
+			RebuildStreak(rebuilt, schedule, tt.days)
+
+			if streak.CurrentStreak != rebuilt.CurrentStreak ||
+				streak.LongestStreak != rebuilt.LongestStreak ||
+				!sameDay(streak.LastPeriod, rebuilt.LastPeriod) ||
+				!sameDay(streak.PeriodStart, rebuilt.PeriodStart) ||
+				streak.PeriodProgress != rebuilt.PeriodProgress {
+				t.Fatalf("incremental %+v, rebuilt %+v", streak, rebuilt)
+			}
+		})
+	}
+}
+
+func TestApplyStreakCheckInBackfill(t *testing.T) {
+	schedule := mustSchedule(t, dailyRule, streakAnchor)
+	streak := &model.HabitStreak{}
+	RebuildStreak(streak, schedule, days("2026-10-01", "2026-10-03"))
+
+	// 补签已过去的周期无法增量计算
+	if ApplyStreakCheckIn(streak, schedule, day("2026-10-02"), 1) {
+		t.Fatal("backfilling a past period did not request a rebuild")
+	}
+}
+
+func TestApplyStreakUndo(t *testing.T) {
+	schedule := mustSchedule(t, dailyRule, streakAnchor)
+
+	tests := []struct {
+		name    string
+		days    []time.Time
+		undo    string
+		ok      bool
+		current int
+		last    *time.Time
+	}{
+		{
+			name:    "undo latest period",
+			days:    days("2026-09-01", "2026-09-02", "2026-09-03", "2026-09-04", "2026-10-01", "2026-10-02", "2026-10-03"),
+			undo:    "2026-10-03",
+			ok:      true,
+			current: 2,
+			last:    dayPtr("2026-10-02"),
+		},
+		{
+			name: "undo period holding the longest streak",
+			days: days("2026-10-01", "2026-10-02", "2026-10-03"),
+			undo: "2026-10-03",
+		},
+		{
+			name: "undo older period",
+			days: days("2026-09-01", "2026-09-02", "2026-09-03", "2026-09-04", "2026-10-01", "2026-10-02", "2026-10-03"),
+			undo: "2026-10-02",
+		},
+		{
+			name: "undo single period streak",
+			days: days("2026-09-01", "2026-09-02", "2026-10-03"),
+			undo: "2026-10-03",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streak := &model.HabitStreak{}
+			RebuildStreak(streak, schedule, tt.days)
+
+			ok := ApplyStreakUndo(streak, schedule, day(tt.undo), 0)
+
+			if ok != tt.ok {
+				t.Fatalf("ApplyStreakUndo = %v, want %v", ok, tt.ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if streak.CurrentStreak != tt.current || !sameDay(streak.LastPeriod, tt.last) {
+				t.Fatalf("current = %d, last = %v, want %d, %v", streak.CurrentStreak, streak.LastPeriod, tt.current, tt.last)
+			}
+		})
+	}
+}
+
+func TestStreakStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.HabitSchedule
+		pauses   []model.HabitPause
+		days     []time.Time
+		today    string
+		current  int
+		atRisk   bool
+	}{
+		{
+			name:     "no streak",
+			schedule: dailyRule,
+			today:    "2026-10-04",
+		},
+		{
+			name:     "completed today",
+			schedule: dailyRule,
+			days:     days("2026-10-01", "2026-10-02", "2026-10-03"),
+			today:    "2026-10-03",
+			current:  3,
+		},
+		{
+			name:     "due today",
+			schedule: dailyRule,
+			days:     days("2026-10-01", "2026-10-02", "2026-10-03"),
+			today:    "2026-10-04",
+			current:  3,
+			atRisk:   true,
+		},
+		{
+			name:     "missed yesterday",
+			schedule: dailyRule,
+			days:     days("2026-10-01", "2026-10-02", "2026-10-03"),
+			today:    "2026-10-05",
+		},
+		{
+			name:     "paused today",
+			schedule: dailyRule,
+			pauses:   []model.HabitPause{pause("2026-10-04", "2026-10-06")},
+			days:     days("2026-10-01", "2026-10-02", "2026-10-03"),
+			today:    "2026-10-05",
+			current:  3,
+		},
+		{
+			name:     "due after pause",
+			schedule: dailyRule,
+			pauses:   []model.HabitPause{pause("2026-10-04", "2026-10-06")},
+			days:     days("2026-10-01", "2026-10-02", "2026-10-03"),
+			today:    "2026-10-07",
+			current:  3,
+			atRisk:   true,
+		},
+		{
+			name:     "missed day after pause",
+			schedule: dailyRule,
+			pauses:   []model.HabitPause{pause("2026-10-04", "2026-10-06")},
+			days:     days("2026-10-01", "2026-10-02", "2026-10-03"),
+			today:    "2026-10-08",
+		},
+		{
+			name:     "non-due day keeps streak",
+			schedule: monWedFri,
+			days:     days("2026-10-05", "2026-10-07", "2026-10-09"),
+			today:    "2026-10-11",
+			current:  3,
+		},
+		{
+			name:     "next due day at risk",
+			schedule: monWedFri,
+			days:     days("2026-10-05", "2026-10-07", "2026-10-09"),
+			today:    "2026-10-12",
+			current:  3,
+			atRisk:   true,
+		},
+		{
+			name:     "weekly enough days left",
+			schedule: twiceAWeek,
+			days:     days("2026-10-05", "2026-10-06"),
+			today:    "2026-10-14",
+			current:  1,
+		},
+		{
+			name:     "weekly two left on saturday",
+			schedule: twiceAWeek,
+			days:     days("2026-10-05", "2026-10-06"),
+			today:    "2026-10-17",
+			current:  1,
+			atRisk:   true,
+		},
+		{
+			name:     "weekly one left on sunday",
+			schedule: twiceAWeek,
+			days:     days("2026-10-05", "2026-10-06", "2026-10-12"),
+			today:    "2026-10-18",
+			current:  1,
+			atRisk:   true,
+		},
+		{
+			name:     "weekly one left with two days",
+			schedule: twiceAWeek,
+			days:     days("2026-10-05", "2026-10-06", "2026-10-12"),
+			today:    "2026-10-17",
+			current:  1,
+		},
+		{
+			name:     "weekly missed whole week",
+			schedule: twiceAWeek,
+			days:     days("2026-10-05", "2026-10-06"),
+			today:    "2026-10-19",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := WithPauses(mustSchedule(t, tt.schedule, streakAnchor), tt.pauses)
+			streak := &model.HabitStreak{}
+			RebuildStreak(streak, schedule, tt.days)
+
+			current, atRisk := StreakStatus(streak, schedule, day(tt.today))
+
+			if current != tt.current || atRisk != tt.atRisk {
+				t.Fatalf("StreakStatus = %d, %v, want %d, %v", current, atRisk, tt.current, tt.atRisk)
+			}
+		})
+	}
+}
+
+func TestIsDue(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.HabitSchedule
+		pauses   []model.HabitPause
+		days     []time.Time
+		today    string
+		due      bool
+	}{
+		{"daily not checked in", dailyRule, nil, nil, "2026-10-05", true},
+		{"daily checked in", dailyRule, nil, days("2026-10-05"), "2026-10-05", false},
+		{"weekdays non-due day", monWedFri, nil, nil, "2026-10-06", false},
+		{"weekly partially done", twiceAWeek, nil, days("2026-10-05"), "2026-10-07", true},
+		{"weekly done", twiceAWeek, nil, days("2026-10-05", "2026-10-06"), "2026-10-07", false},
+		{"paused", dailyRule, []model.HabitPause{pause("2026-10-04", "")}, nil, "2026-10-05", false},
+		{"before interval anchor", everyThird, nil, nil, "2026-08-31", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := WithPauses(mustSchedule(t, tt.schedule, streakAnchor), tt.pauses)
+			streak := &model.HabitStreak{}
+			RebuildStreak(streak, schedule, tt.days)
+
+			if due := IsDue(streak, schedule, day(tt.today)); due != tt.due {
+				t.Fatalf("IsDue = %v, want %v", due, tt.due)
+			}
+		})
+	}
+}
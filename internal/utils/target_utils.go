@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"math"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+)
+
+const targetEpsilon = 1e-9
+
+// IsTargetMet 判断当日累计量是否满足习惯目标，无目标的习惯只要打卡即视为完成
+func IsTargetMet(target model.HabitTarget, amount float64) bool {
+	if target.Value <= 0 {
+		return true
+	}
+
+	switch target.Mode {
+	case def.HabitTargetAtMost:
+		return amount <= target.Value+targetEpsilon
+	case def.HabitTargetExactly:
+		return math.Abs(amount-target.Value) <= targetEpsilon
+	default:
+		return amount >= target.Value-targetEpsilon
+	}
+}
+
+// TargetPercent 返回累计量相对目标值的百分比，保留两位小数
+func TargetPercent(target model.HabitTarget, amount float64, checkedIn bool) float64 {
+	if target.Value <= 0 {
+		if checkedIn {
+			return 100
+		}
+		return 0
+	}
+
+	return math.Round(amount/target.Value*10000) / 100
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"testing"
+	"w2learn/internal/model"
+	"w2learn/pkg/def"
+)
+
+func TestIsTargetMet(t *testing.T) {
+	atLeast := model.HabitTarget{Value: 8, Unit: "cups", Mode: def.HabitTargetAtLeast}
+	atMost := model.HabitTarget{Value: 2, Unit: "coffee", Mode: def.HabitTargetAtMost}
+	exactly := model.HabitTarget{Value: 0.3, Unit: "km", Mode: def.HabitTargetExactly}
+
+	tests := []struct {
+		name   string
+		target model.HabitTarget
+		amount float64
+		met    bool
+	}{
+		{"no target", model.HabitTarget{}, 0, true},
+		{"no target with amount", model.HabitTarget{Mode: def.HabitTargetExactly}, 5, true},
+
+		{"at least below", atLeast, 7.5, false},
+		{"at least equal", atLeast, 8, true},
+		{"at least above", atLeast, 9, true},
+		{"default mode is at least", model.HabitTarget{Value: 8}, 7, false},
+
+		{"at most zero", atMost, 0, true},
+		{"at most equal", atMost, 2, true},
+		{"at most above", atMost, 2.5, false},
+
+		{"exactly below", exactly, 0.2, false},
+		{"exactly float sum", exactly, 0.1 + 0.2, true},
+		{"exactly above", exactly, 0.4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if met := IsTargetMet(tt.target, tt.amount); met != tt.met {
+				t.Fatalf("IsTargetMet(%+v, %v) = %v, want %v", tt.target, tt.amount, met, tt.met)
+			}
+		})
+	}
+}
+
+func TestTargetPercent(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    model.HabitTarget
+		amount    float64
+		checkedIn bool
+		percent   float64
+	}{
+		{"no target not checked in", model.HabitTarget{}, 0, false, 0},
+		{"no target checked in", model.HabitTarget{}, 0, true, 100},
+		{"at least partial", model.HabitTarget{Value: 8, Mode: def.HabitTargetAtLeast}, 3, true, 37.5},
+		{"at least exceeded", model.HabitTarget{Value: 8, Mode: def.HabitTargetAtLeast}, 12, true, 150},
+		{"at most under", model.HabitTarget{Value: 4, Mode: def.HabitTargetAtMost}, 1, true, 25},
+		{"at most over", model.HabitTarget{Value: 4, Mode: def.HabitTargetAtMost}, 5, true, 125},
+		{"exactly rounded", model.HabitTarget{Value: 3, Mode: def.HabitTargetExactly}, 1, true, 33.33},
+		{"exactly met", model.HabitTarget{Value: 0.3, Mode: def.HabitTargetExactly}, 0.1 + 0.2, true, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if percent := TargetPercent(tt.target, tt.amount, tt.checkedIn); percent != tt.percent {
+				t.Fatalf("TargetPercent = %v, want %v", percent, tt.percent)
+			}
+		})
+	}
+}
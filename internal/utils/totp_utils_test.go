@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+	"w2learn/pkg/def"
+)
+
+// rfc6238Secret 为 RFC 6238 附录 B 中 SHA1 使用的 ASCII 密钥 "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// TestTOTPCodeRFC6238 使用 RFC 6238 附录 B 的 SHA1 测试向量
+// 附录中为 8 位验证码，动态截断后取模，因此 6 位验证码为其后 6 位
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix    int64
+		counter int64
+		code    string
+	}{
+		{59, 0x1, "94287082"},
+		{1111111109, 0x23523EC, "07081804"},
+		{1111111111, 0x23523ED, "14050471"},
+		{1234567890, 0x273EF07, "89005924"},
+		{2000000000, 0x3F940AA, "69279037"},
+		{20000000000, 0x27BC86AA, "65353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			at := time.Unix(tt.unix, 0).UTC()
+			want := tt.code[len(tt.code)-def.TOTPDigits:]
+
+			if counter := TOTPCounter(at); counter != tt.counter {
+				t.Fatalf("TOTPCounter(%d) = %#x, want %#x", tt.unix, counter, tt.counter)
+			}
+
+			code, err := TOTPCode(rfc6238Secret, tt.counter)
+
+			if err != nil || code != want {
+				t.Fatalf("TOTPCode = %q, %v, want %q", code, err, want)
+			}
+
+			counter, ok := VerifyTOTP(rfc6238Secret, want, at)
+
+			if !ok || counter != tt.counter {
+				t.Fatalf("VerifyTOTP = %#x, %v, want %#x, true", counter, ok, tt.counter)
+			}
+		})
+	}
+}
+
+func TestTOTPCodeLowercaseSecret(t *testing.T) {
+	upper, _ := TOTPCode(rfc6238Secret, 1)
+	lower, err := TOTPCode("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+
+	if err != nil || lower != upper {
+		t.Fatalf("TOTPCode(lowercase) = %q, %v, want %q", lower, err, upper)
+	}
+
+	_, err = TOTPCode("not base32!", 1)
+
+	if err == nil {
+		t.Fatal("TOTPCode with invalid secret should fail")
+	}
+}
+
+// TestVerifyTOTPSkew 确认偏移窗口内的验证码均返回其生成时的时间步，重放检查依赖该时间步
+func TestVerifyTOTPSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	now := TOTPCounter(at)
+
+	tests := []struct {
+		name    string
+		counter int64
+		ok      bool
+	}{
+		{"previous step", now - 1, true},
+		{"current step", now, true},
+		{"next step", now + 1, true},
+		{"two steps behind", now - 2, false},
+		{"two steps ahead", now + 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := TOTPCode(rfc6238Secret, tt.counter)
+			counter, ok := VerifyTOTP(rfc6238Secret, code, at)
+
+			if ok != tt.ok || (ok && counter != tt.counter) {
+				t.Fatalf("VerifyTOTP = %d, %v, want %d, %v", counter, ok, tt.counter, tt.ok)
+			}
+		})
+	}
+
+	for _, code := range []string{"", "12345", "1234567"} {
+		_, ok := VerifyTOTP(rfc6238Secret, code, at)
+
+		if ok {
+			t.Fatalf("VerifyTOTP(%q) = true, want false", code)
+		}
+	}
+}
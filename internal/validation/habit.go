@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"time"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/internal/utils"
+	"w2learn/pkg/def"
+	"w2learn/pkg/logger"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 习惯相关的自定义校验规则
+const (
+	// RuleHabitNameUnique 要求习惯名称在用户未归档的习惯中唯一，只在 StructCtx 携带 WithHabitScope 时检查
+	RuleHabitNameUnique = "habit_name_unique"
+	// RuleHabitSchedule 要求重复规则中与类型相关的字段取值有效
+	RuleHabitSchedule = "habit_schedule"
+)
+
+// HabitNameLookup 返回用户名下同名且未归档的习惯，不存在时返回 gorm.ErrRecordNotFound
+type HabitNameLookup func(ctx context.Context, userID uint64, name string) (*model.Habit, error)
+
+type habitScopeKey struct{}
+
+// habitScope 为习惯名称唯一性检查的范围，HabitID 为正在修改的习惯，新建时为 0
+type habitScope struct {
+	UserID  uint64
+	HabitID uint64
+}
+
+// WithHabitScope 在 ctx 中记录当前用户与正在修改的习惯，供 StructCtx 检查习惯名称是否唯一
+func WithHabitScope(ctx context.Context, userID uint64, habitID uint64) context.Context {
+	return context.WithValue(ctx, habitScopeKey{}, &habitScope{
+		UserID:  userID,
+		HabitID: habitID,
+	})
+}
+
+func registerHabitRules(v *validator.Validate, lookup HabitNameLookup, enTrans ut.Translator, zhTrans ut.Translator) error {
+	err := v.RegisterValidationCtx(RuleHabitNameUnique, habitNameUnique(lookup))
+
+	if err != nil {
+		return err
+	}
+
+	v.RegisterStructValidation(habitSchedule, dto.HabitScheduleRequest{})
+
+	messages := map[ut.Translator]map[string]string{
+		enTrans: {
+			RuleHabitNameUnique: "{0} is already used by another habit",
+			RuleHabitSchedule:   "{0} is not valid for this schedule type",
+		},
+		zhTrans: {
+			RuleHabitNameUnique: "{0}已被其他习惯使用",
+			RuleHabitSchedule:   "{0}不符合该重复类型的要求",
+		},
+	}
+
+	for trans, m := range messages {
+		for rule, text := range m {
+			err = v.RegisterTranslation(rule, trans, registerText(rule, text), translateText)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// habitNameUnique 检查名称是否被用户的其他习惯使用，查询失败时不放行
+// 检查与写入之间仍可能并发写入同名习惯，最终由 idx_habits_user_name 唯一索引保证
+func habitNameUnique(lookup HabitNameLookup) validator.FuncCtx {
+	return func(ctx context.Context, fl validator.FieldLevel) bool {
+		scope, ok := ctx.Value(habitScopeKey{}).(*habitScope)
+
+		if !ok || lookup == nil {
+			return true
+		}
+
+		habit, err := lookup(ctx, scope.UserID, fl.Field().String())
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true
+		}
+
+		if err != nil {
+			logger.Error("Failed to check habit name", zap.Error(err), zap.Uint64("user_id", scope.UserID))
+			return false
+		}
+
+		return habit.ID == scope.HabitID
+	}
+}
+
+// habitSchedule 按重复类型校验对应字段，出错时报告在与类型相关的字段上
+func habitSchedule(sl validator.StructLevel) {
+	req, ok := sl.Current().Interface().(dto.HabitScheduleRequest)
+
+	if !ok {
+		return
+	}
+
+	schedule := &model.HabitSchedule{
+		Type:     req.Type,
+		Interval: req.Interval,
+		Times:    req.Times,
+		RRule:    req.RRule,
+	}
+
+	for _, d := range req.Weekdays {
+		schedule.Weekdays |= model.NewWeekdays(time.Weekday(d))
+	}
+
+	anchor := time.Now().UTC().Truncate(24 * time.Hour)
+
+	if req.StartDay != "" {
+		day, err := utils.ParseDay(req.StartDay)
+
+		// 日期格式由 datetime 规则报告
+		if err != nil {
+			return
+		}
+
+		anchor = day
+	}
+
+	_, err := utils.ParseSchedule(schedule, anchor)
+
+	if err == nil {
+		return
+	}
+
+	switch req.Type {
+	case def.HabitScheduleWeekdays:
+		sl.ReportError(req.Weekdays, "weekdays", "Weekdays", RuleHabitSchedule, "")
+	case def.HabitScheduleInterval:
+		sl.ReportError(req.Interval, "interval", "Interval", RuleHabitSchedule, "")
+	case def.HabitScheduleWeekly, def.HabitScheduleMonthly:
+		sl.ReportError(req.Times, "times", "Times", RuleHabitSchedule, "")
+	case def.HabitScheduleRRule:
+		sl.ReportError(req.RRule, "rrule", "RRule", RuleHabitSchedule, "")
+	default:
+		sl.ReportError(req.Type, "type", "Type", RuleHabitSchedule, "")
+	}
+}
+
+func registerText(rule string, text string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(rule, text, true)
+	}
+}
+
+func translateText(trans ut.Translator, fe validator.FieldError) string {
+	text, err := trans.T(fe.Tag(), fe.Field())
+
+	if err != nil {
+		return fe.Error()
+	}
+
+	return text
+}
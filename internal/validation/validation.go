@@ -0,0 +1,225 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"w2learn/pkg/response"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// 支持的语言，未匹配时使用英文
+const (
+	LocaleEN = "en"
+	LocaleZH = "zh"
+)
+
+// 自定义翻译的键，与校验规则共用翻译器
+const (
+	translationFailed = "validation_failed"
+	translationType   = "type"
+)
+
+var (
+	validate    *validator.Validate
+	translators = map[string]ut.Translator{}
+)
+
+// Setup 配置 gin 使用的校验器：字段名取 JSON 或查询参数名，注册英文与中文翻译以及习惯相关的自定义规则
+// habitNameLookup 用于检查习惯名称在用户名下是否唯一
+func Setup(habitNameLookup HabitNameLookup) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+
+	if !ok {
+		return errors.New("unsupported validator engine")
+	}
+
+	v.RegisterTagNameFunc(fieldName)
+
+	uni := ut.New(en.New(), en.New(), zh.New())
+
+	enTrans, _ := uni.GetTranslator(LocaleEN)
+	zhTrans, _ := uni.GetTranslator(LocaleZH)
+
+	err := enTranslations.RegisterDefaultTranslations(v, enTrans)
+
+	if err != nil {
+		return err
+	}
+
+	err = zhTranslations.RegisterDefaultTranslations(v, zhTrans)
+
+	if err != nil {
+		return err
+	}
+
+	messages := map[ut.Translator]map[string]string{
+		enTrans: {
+			translationFailed: "validation failed",
+			translationType:   "{0} must be of type {1}",
+		},
+		zhTrans: {
+			translationFailed: "参数校验失败",
+			translationType:   "{0}必须是{1}类型",
+		},
+	}
+
+	for trans, m := range messages {
+		for key, text := range m {
+			err = trans.Add(key, text, false)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = registerHabitRules(v, habitNameLookup, enTrans, zhTrans)
+
+	if err != nil {
+		return err
+	}
+
+	validate = v
+	translators[LocaleEN] = enTrans
+	translators[LocaleZH] = zhTrans
+
+	return nil
+}
+
+// StructCtx 使用 ctx 再次校验已绑定的请求，依赖上下文的规则（如习惯名称唯一）只在这里生效
+func StructCtx(ctx context.Context, obj any) error {
+	if validate == nil {
+		return nil
+	}
+
+	return validate.StructCtx(ctx, obj)
+}
+
+// Translate 将参数绑定或校验错误转换为 VALIDATION_FAILED 应用错误，按 Accept-Language 选择英文或中文
+// 校验器的原始错误信息不返回给客户端
+func Translate(err error, acceptLanguage string) *response.AppError {
+	trans := translator(acceptLanguage)
+
+	var validationErrors validator.ValidationErrors
+
+	if errors.As(err, &validationErrors) {
+		fields := make([]response.FieldError, 0, len(validationErrors))
+
+		for _, fe := range validationErrors {
+			fields = append(fields, response.FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Message: translateField(fe, trans),
+			})
+		}
+
+		return failed(trans, fields, err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return failed(trans, []response.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    translationType,
+			Message: translate(trans, translationType, typeErr.Field, typeErr.Type.String()),
+		}}, err)
+	}
+
+	var numErr *strconv.NumError
+
+	if errors.As(err, &numErr) {
+		return response.FromError(err)
+	}
+
+	if errors.Is(err, io.EOF) {
+		return response.Wrap(response.CodeBadRequest, "request body is empty", err)
+	}
+
+	return response.Wrap(response.CodeBadRequest, "malformed request parameters", err)
+}
+
+func failed(trans ut.Translator, fields []response.FieldError, err error) *response.AppError {
+	appErr := response.Wrap(response.CodeValidationFailed, translate(trans, translationFailed), err)
+	appErr.Fields = fields
+
+	return appErr
+}
+
+func translator(acceptLanguage string) ut.Translator {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+
+		switch {
+		case strings.HasPrefix(tag, LocaleZH):
+			return translators[LocaleZH]
+		case strings.HasPrefix(tag, LocaleEN):
+			return translators[LocaleEN]
+		}
+	}
+
+	return translators[LocaleEN]
+}
+
+// translateField 翻译单个字段错误，未初始化翻译器时退回英文的默认错误信息
+func translateField(fe validator.FieldError, trans ut.Translator) string {
+	if trans == nil {
+		return fe.Error()
+	}
+
+	return fe.Translate(trans)
+}
+
+func translate(trans ut.Translator, key string, params ...string) string {
+	if trans == nil {
+		return key
+	}
+
+	text, err := trans.T(key, params...)
+
+	if err != nil {
+		return key
+	}
+
+	return text
+}
+
+// fieldName 返回字段在请求中的名称，依次取 json 与 form 标签，均未设置时使用结构体字段名
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+
+		if name == "-" {
+			return ""
+		}
+
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldPath 去掉命名空间中的顶层结构体名，如 CreateHabitRequest.schedule.type 转换为 schedule.type
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+
+	if !found {
+		return namespace
+	}
+
+	return path
+}
@@ -0,0 +1,248 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"w2learn/internal/dto"
+	"w2learn/internal/model"
+	"w2learn/pkg/response"
+
+	"gorm.io/gorm"
+)
+
+var setupOnce sync.Once
+
+// errLookupFailed 模拟名称查询失败
+var errLookupFailed = errors.New("connection refused")
+
+// setupValidation 只初始化一次全局校验器，用户 1 名下已有未归档的习惯 Read（ID 为 10）
+func setupValidation(t *testing.T) {
+	t.Helper()
+
+	var err error
+
+	setupOnce.Do(func() {
+		err = Setup(func(ctx context.Context, userID uint64, name string) (*model.Habit, error) {
+			switch {
+			case name == "boom":
+				return nil, errLookupFailed
+			case userID == 1 && name == "Read":
+				return &model.Habit{ID: 10, UserID: 1, Name: name}, nil
+			}
+
+			return nil, gorm.ErrRecordNotFound
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+}
+
+func validHabit() *dto.CreateHabitRequest {
+	return &dto.CreateHabitRequest{
+		Name:     "Read",
+		Info:     "20 pages",
+		Schedule: &dto.HabitScheduleRequest{Type: "daily"},
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	setupValidation(t)
+
+	invalid := validHabit()
+	invalid.Info = ""
+	invalid.Schedule = &dto.HabitScheduleRequest{Type: "daily", Times: 30}
+
+	validationErr := StructCtx(context.Background(), invalid)
+
+	if validationErr == nil {
+		t.Fatal("expected a validation error")
+	}
+
+	typeErr := json.Unmarshal([]byte(`{"name": 1}`), &dto.CreateHabitRequest{})
+
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		code           string
+		message        string
+		fields         []response.FieldError
+	}{
+		{
+			name: "validation en", err: validationErr, acceptLanguage: "en-US,en;q=0.9",
+			code: response.CodeValidationFailed, message: "validation failed",
+			fields: []response.FieldError{
+				{Field: "info", Rule: "required", Message: "info is a required field"},
+				{Field: "schedule.times", Rule: "max", Message: "times must be 28 or less"},
+			},
+		},
+		{
+			name: "validation zh", err: validationErr, acceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8",
+			code: response.CodeValidationFailed, message: "参数校验失败",
+			fields: []response.FieldError{
+				{Field: "info", Rule: "required", Message: "info为必填字段"},
+				{Field: "schedule.times", Rule: "max", Message: "times必须小于或等于28"},
+			},
+		},
+		{
+			name: "unsupported language falls back to en", err: validationErr, acceptLanguage: "fr-FR",
+			code: response.CodeValidationFailed, message: "validation failed",
+			fields: []response.FieldError{
+				{Field: "info", Rule: "required", Message: "info is a required field"},
+				{Field: "schedule.times", Rule: "max", Message: "times must be 28 or less"},
+			},
+		},
+		{
+			name: "first supported language wins", err: validationErr, acceptLanguage: "fr;q=1.0, zh-TW;q=0.8, en;q=0.5",
+			code: response.CodeValidationFailed, message: "参数校验失败",
+			fields: []response.FieldError{
+				{Field: "info", Rule: "required", Message: "info为必填字段"},
+				{Field: "schedule.times", Rule: "max", Message: "times必须小于或等于28"},
+			},
+		},
+		{
+			name: "type en", err: typeErr, acceptLanguage: "",
+			code: response.CodeValidationFailed, message: "validation failed",
+			fields: []response.FieldError{{Field: "name", Rule: "type", Message: "name must be of type string"}},
+		},
+		{
+			name: "type zh", err: typeErr, acceptLanguage: "zh",
+			code: response.CodeValidationFailed, message: "参数校验失败",
+			fields: []response.FieldError{{Field: "name", Rule: "type", Message: "name必须是string类型"}},
+		},
+		{name: "empty body", err: io.EOF, code: response.CodeBadRequest, message: "request body is empty"},
+		{name: "malformed", err: errors.New("invalid character"), code: response.CodeBadRequest, message: "malformed request parameters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr := Translate(tt.err, tt.acceptLanguage)
+
+			if appErr.Code != tt.code || appErr.Message != tt.message {
+				t.Fatalf("Translate = %s %q, want %s %q", appErr.Code, appErr.Message, tt.code, tt.message)
+			}
+
+			if !reflect.DeepEqual(appErr.Fields, tt.fields) {
+				t.Fatalf("Translate fields = %+v, want %+v", appErr.Fields, tt.fields)
+			}
+
+			if !reflect.DeepEqual(errors.Unwrap(appErr), tt.err) {
+				t.Fatal("Translate should wrap the original error")
+			}
+		})
+	}
+}
+
+func TestHabitScheduleRule(t *testing.T) {
+	setupValidation(t)
+
+	tests := []struct {
+		name     string
+		schedule dto.HabitScheduleRequest
+		field    string
+	}{
+		{"daily", dto.HabitScheduleRequest{Type: "daily"}, ""},
+		{"weekdays", dto.HabitScheduleRequest{Type: "weekdays", Weekdays: []int{1, 3, 5}}, ""},
+		{"weekdays empty", dto.HabitScheduleRequest{Type: "weekdays", Weekdays: []int{}}, "schedule.weekdays"},
+		{"interval", dto.HabitScheduleRequest{Type: "interval", Interval: 3}, ""},
+		{"interval missing", dto.HabitScheduleRequest{Type: "interval"}, "schedule.interval"},
+		{"weekly", dto.HabitScheduleRequest{Type: "weekly", Times: 3}, ""},
+		{"weekly too many times", dto.HabitScheduleRequest{Type: "weekly", Times: 8}, "schedule.times"},
+		{"weekly without times", dto.HabitScheduleRequest{Type: "weekly"}, "schedule.times"},
+		{"monthly", dto.HabitScheduleRequest{Type: "monthly", Times: 10}, ""},
+		{"monthly without times", dto.HabitScheduleRequest{Type: "monthly"}, "schedule.times"},
+		{"rrule", dto.HabitScheduleRequest{Type: "rrule", RRule: "FREQ=WEEKLY;BYDAY=MO,TH"}, ""},
+		{"rrule invalid", dto.HabitScheduleRequest{Type: "rrule", RRule: "FREQ=SOMETIMES"}, "schedule.rrule"},
+		{"rrule with start day", dto.HabitScheduleRequest{Type: "rrule", RRule: "FREQ=DAILY;COUNT=10", StartDay: "2026-10-01"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validHabit()
+			req.Name = "Run"
+			req.Schedule = &tt.schedule
+
+			err := StructCtx(context.Background(), req)
+
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("StructCtx = %v, want nil", err)
+				}
+				return
+			}
+
+			// 与类型相关的字段可能同时违反 required_if 等标签规则，这里只检查结构体规则
+			field := tt.field[len("schedule."):]
+			messages := map[string]string{
+				"en": field + " is not valid for this schedule type",
+				"zh": field + "不符合该重复类型的要求",
+			}
+
+			for lang, message := range messages {
+				found := false
+
+				for _, fe := range Translate(err, lang).Fields {
+					if fe.Rule == RuleHabitSchedule {
+						if fe.Field != tt.field || fe.Message != message {
+							t.Fatalf("%s: %s = %+v, want %q on %s", lang, RuleHabitSchedule, fe, message, tt.field)
+						}
+
+						found = true
+					}
+				}
+
+				if !found {
+					t.Fatalf("%s: no %s error in %v", lang, RuleHabitSchedule, err)
+				}
+			}
+		})
+	}
+}
+
+func TestHabitNameUniqueRule(t *testing.T) {
+	setupValidation(t)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		habit   string
+		invalid bool
+	}{
+		{"without scope", context.Background(), "Read", false},
+		{"new name", WithHabitScope(context.Background(), 1, 0), "Run", false},
+		{"taken by another habit", WithHabitScope(context.Background(), 1, 0), "Read", true},
+		{"kept by the habit itself", WithHabitScope(context.Background(), 1, 10), "Read", false},
+		{"renamed to a taken name", WithHabitScope(context.Background(), 1, 11), "Read", true},
+		{"other user", WithHabitScope(context.Background(), 2, 0), "Read", false},
+		{"lookup failed", WithHabitScope(context.Background(), 1, 0), "boom", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validHabit()
+			req.Name = tt.habit
+
+			err := StructCtx(tt.ctx, req)
+
+			if !tt.invalid {
+				if err != nil {
+					t.Fatalf("StructCtx = %v, want nil", err)
+				}
+				return
+			}
+
+			fields := Translate(err, "en").Fields
+
+			if len(fields) != 1 || fields[0].Field != "name" || fields[0].Rule != RuleHabitNameUnique {
+				t.Fatalf("fields = %+v, want %s on name", fields, RuleHabitNameUnique)
+			}
+		})
+	}
+}
@@ -37,7 +37,7 @@ func NewPostgresDB(cfg *PostgresConfig) (*gorm.DB, error) {
 	logger.Info("Prepare DSN Start")
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode, "Asia/Shanghai",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode, "UTC",
 	)
 	logger.Info("Prepare DSN End: ", zap.String("dsn", dsn))
 
@@ -61,7 +61,9 @@ func NewPostgresDB(cfg *PostgresConfig) (*gorm.DB, error) {
 	logger.Info("Connect to PostgreSQL Start")
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger:  logger2.Default.LogMode(logLevel),
-		NowFunc: func() time.Time { return time.Now() },
+		NowFunc: func() time.Time { return time.Now().UTC() },
+		// 将唯一约束等数据库错误转换为 gorm.ErrDuplicatedKey 等通用错误，由 response 统一映射
+		TranslateError: true,
 	})
 
 	if err != nil {
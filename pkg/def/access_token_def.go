@@ -0,0 +1,29 @@
+package def
+
+// Personal Access Token Scope Def
+const (
+	ScopeHabitsRead    = "habits:read"
+	ScopeHabitsWrite   = "habits:write"
+	ScopeCheckinsWrite = "checkins:write"
+)
+
+// Scopes 为个人访问令牌可申请的全部权限范围
+var Scopes = []string{
+	ScopeHabitsRead,
+	ScopeHabitsWrite,
+	ScopeCheckinsWrite,
+}
+
+// Personal Access Token Def
+const (
+	// PersonalAccessTokenPrefix 用于区分个人访问令牌与 JWT
+	PersonalAccessTokenPrefix = "w2l_pat_"
+	PersonalAccessTokenBytes  = 32
+	// PersonalAccessTokenDisplayLen 为列表中展示的令牌前缀长度，包含 PersonalAccessTokenPrefix
+	PersonalAccessTokenDisplayLen = 14
+	PersonalAccessTokenMaxPerUser = 50
+	// PersonalAccessTokenMaxTTLDays 为令牌有效期上限，单位为天
+	PersonalAccessTokenMaxTTLDays = 365
+	// PersonalAccessTokenTouchInterval 为更新最近使用时间的最小间隔，单位为秒
+	PersonalAccessTokenTouchInterval = 60
+)
@@ -0,0 +1,44 @@
+package def
+
+// Audit Action Def
+const (
+	AuditActionRegister               = "auth.register"
+	AuditActionLogin                  = "auth.login"
+	AuditActionLoginOIDC              = "auth.login_oidc"
+	AuditActionTwoFactor              = "auth.2fa"
+	AuditActionLogout                 = "auth.logout"
+	AuditActionLogoutAll              = "auth.logout_all"
+	AuditActionRefreshReuse           = "auth.refresh_reuse"
+	AuditActionPasswordChange         = "password.change"
+	AuditActionPasswordForgot         = "password.forgot"
+	AuditActionPasswordReset          = "password.reset"
+	AuditActionUserCreate             = "user.create"
+	AuditActionUserUpdate             = "user.update"
+	AuditActionUserDelete             = "user.delete"
+	AuditActionUserSuspend            = "user.suspend"
+	AuditActionUserReinstate          = "user.reinstate"
+	AuditActionUserUnlock             = "user.unlock"
+	AuditActionUserRoles              = "user.roles"
+	AuditActionRoleCreate             = "role.create"
+	AuditActionRoleUpdate             = "role.update"
+	AuditActionRoleDelete             = "role.delete"
+	AuditActionTwoFactorEnable        = "2fa.enable"
+	AuditActionTwoFactorDisable       = "2fa.disable"
+	AuditActionTwoFactorRecoveryCodes = "2fa.recovery_codes"
+	AuditActionTokenCreate            = "token.create"
+	AuditActionTokenRevoke            = "token.revoke"
+)
+
+// Audit Result Def
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// Audit Def
+const (
+	// AuditReasonMaxLen 与 AuditActorNameMaxLen 与 audit_logs 的字段长度一致
+	AuditReasonMaxLen    = 255
+	AuditActorNameMaxLen = 64
+	AuditPageSizeMax     = 100
+)
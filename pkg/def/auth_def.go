@@ -0,0 +1,81 @@
+package def
+
+// Session TTL Def，单位为秒
+const (
+	AccessTokenDefaultTTL  = 2 * 60 * 60
+	RefreshTokenDefaultTTL = 30 * 24 * 60 * 60
+)
+
+// Refresh Token Def
+const (
+	RefreshTokenBytes = 32
+	TokenTypeBearer   = "Bearer"
+	// RefreshTokenKeyLayout 保存刷新令牌的信息，参数为令牌的 SHA-256 摘要
+	RefreshTokenKeyLayout = "refresh:token:%s"
+	// RefreshTokenUsedKeyLayout 标记已轮换的刷新令牌，用于检测重放
+	RefreshTokenUsedKeyLayout = "refresh:used:%s"
+	// RefreshFamilyKeyLayout 标记仍有效的令牌族，参数为令牌族 ID
+	RefreshFamilyKeyLayout = "refresh:family:%s"
+)
+
+// Token Revocation Def
+const (
+	// TokenBlacklistKeyLayout 标记已注销的访问令牌，随令牌过期，参数为令牌 ID
+	TokenBlacklistKeyLayout = "token:blacklist:%s"
+	// TokenRevokedBeforeKeyLayout 保存用户的令牌失效水位，早于该 Unix 毫秒时间签发的访问令牌均无效，参数为用户 ID
+	TokenRevokedBeforeKeyLayout = "token:revoked_before:%d"
+)
+
+// Session Def
+const (
+	// SessionDefaultMax 为用户未设置上限时的并发会话数上限
+	SessionDefaultMax = 10
+	// SessionUserAgentMaxLen 与 user_sessions.user_agent 字段长度一致
+	SessionUserAgentMaxLen = 255
+	// SessionSeenKeyLayout 保存会话最近一次访问的 Unix 时间，参数为会话 ID
+	SessionSeenKeyLayout = "session:seen:%s"
+)
+
+// Password Hash Def，Argon2 的 Memory 单位为 KiB
+const (
+	PasswordArgon2Memory      = 64 * 1024
+	PasswordArgon2Iterations  = 3
+	PasswordArgon2Parallelism = 2
+	PasswordSaltLength        = 16
+	PasswordKeyLength         = 32
+)
+
+// Password Reset Def
+const (
+	// PasswordResetTokenTTL 为重置口令令牌的有效期，单位为秒
+	PasswordResetTokenTTL = 30 * 60
+	PasswordResetBytes    = 32
+	// PasswordResetKeyLayout 保存重置令牌对应的用户 ID，参数为令牌的 SHA-256 摘要
+	PasswordResetKeyLayout = "password:reset:%s"
+	// PasswordResetUserKeyLayout 保存用户当前有效的重置令牌摘要，签发新令牌时作废旧令牌
+	PasswordResetUserKeyLayout = "password:reset:user:%d"
+)
+
+// Login Throttle Def，时间单位为秒
+const (
+	// LoginFailureWindow 为统计登录失败次数的滑动窗口
+	LoginFailureWindow = 15 * 60
+	// LoginBackoffThreshold 为同一用户名开始退避的失败次数，之后每次失败等待时间翻倍
+	LoginBackoffThreshold = 3
+	LoginBackoffBase      = 1
+	LoginBackoffMax       = 5 * 60
+	// LoginLockThreshold 为同一用户名被临时锁定的失败次数
+	LoginLockThreshold = 10
+	LoginLockDuration  = 15 * 60
+	// LoginIPBackoffThreshold 为同一 IP 开始退避的失败次数
+	LoginIPBackoffThreshold = 30
+
+	LoginScopeUser = "user"
+	LoginScopeIP   = "ip"
+	// LoginFailureKeyLayout 为保存失败时间的有序集合，参数为范围与用户名或 IP
+	LoginFailureKeyLayout = "login:fail:%s:%s"
+	// LoginBackoffKeyLayout 在退避期间存在，参数同上
+	LoginBackoffKeyLayout = "login:backoff:%s:%s"
+	// LoginLockKeyLayout 在账户锁定期间存在，参数为用户名
+	LoginLockKeyLayout = "login:lock:%s"
+)
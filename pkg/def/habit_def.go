@@ -0,0 +1,57 @@
+package def
+
+// Habit Check-in Def
+const (
+	CheckInDayLayout       = "2006-01-02"
+	CheckInListDefaultDays = 30
+	CheckInListMaxDays     = 366
+)
+
+// Habit Def
+const (
+	// HabitNameMaxLen 与 habits.name 字段长度一致
+	HabitNameMaxLen = 64
+)
+
+// Habit Status Def
+const (
+	HabitStatusActive   = "active"
+	HabitStatusPaused   = "paused"
+	HabitStatusArchived = "archived"
+	// HabitStatusAll 仅用于列表筛选，表示不限状态
+	HabitStatusAll = "all"
+)
+
+// Habit Schedule Type Def
+const (
+	HabitScheduleDaily    = "daily"
+	HabitScheduleWeekdays = "weekdays"
+	HabitScheduleInterval = "interval"
+	HabitScheduleWeekly   = "weekly"
+	HabitScheduleMonthly  = "monthly"
+	HabitScheduleRRule    = "rrule"
+)
+
+// Habit Target Mode Def
+const (
+	HabitTargetAtLeast = "at_least"
+	HabitTargetAtMost  = "at_most"
+	HabitTargetExactly = "exactly"
+)
+
+// Habit Schedule Limit Def
+const (
+	HabitScheduleMaxInterval     = 365
+	HabitScheduleMaxWeeklyTimes  = 7
+	HabitScheduleMaxMonthlyTimes = 28
+	HabitScheduleScanMaxDays     = 1830
+	// HabitRRuleCountScanMaxDays 为换算 RRULE COUNT 最后一次重复日期时的最大扫描天数，超出后视为不限次数
+	HabitRRuleCountScanMaxDays = 100 * 366
+)
+
+// Habit Stats Def
+const (
+	StatsHeatmapDefaultDays = 365
+	StatsHeatmapMaxDays     = 366
+	StatsTrendWeeks         = 8
+)
@@ -0,0 +1,13 @@
+package def
+
+// Mail Driver Def
+const (
+	MailDriverSMTP = "smtp"
+	MailDriverFile = "file"
+	MailDriverLog  = "log"
+)
+
+// Mail Def
+const (
+	MailDefaultFileDir = "logs/mail"
+)
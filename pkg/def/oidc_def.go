@@ -0,0 +1,24 @@
+package def
+
+// OIDC Def
+const (
+	// OIDCStateTTL 为授权请求的有效期，单位为秒
+	OIDCStateTTL      = 10 * 60
+	OIDCStateBytes    = 32
+	OIDCNonceBytes    = 16
+	OIDCVerifierBytes = 32
+	// OIDCStateKeyLayout 保存授权请求的 nonce 与 PKCE code_verifier，参数为 state 的 SHA-256 摘要
+	OIDCStateKeyLayout = "oidc:state:%s"
+	// OIDCHTTPTimeout 为请求身份提供方的超时时间，单位为秒
+	OIDCHTTPTimeout = 10
+	// OIDCKeysRefreshInterval 为遇到未知 kid 时重新拉取 JWKS 的最小间隔，单位为秒
+	OIDCKeysRefreshInterval = 60
+	// OIDCUsernameMaxLen 与 users.username 字段长度一致
+	OIDCUsernameMaxLen = 64
+	// OIDCStateCookieName 保存 state 的摘要，回调时与 state 比对以确认由同一浏览器发起
+	OIDCStateCookieName = "oidc_state"
+	OIDCStateCookiePath = "/auth/oidc"
+)
+
+// OIDCDefaultScopes 为未配置时申请的权限范围
+var OIDCDefaultScopes = []string{"openid", "profile", "email"}
@@ -0,0 +1,27 @@
+package def
+
+// Role Def，内置角色不可删除或改名
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permission Def，PermissionAll 表示拥有全部权限
+const (
+	PermissionAll       = "*"
+	PermissionUserRead  = "user:read"
+	PermissionUserWrite = "user:write"
+	PermissionRoleRead  = "role:read"
+	PermissionRoleWrite = "role:write"
+	PermissionAuditRead = "audit:read"
+)
+
+// Permissions 为可授予自定义角色的全部权限
+var Permissions = []string{
+	PermissionAll,
+	PermissionUserRead,
+	PermissionUserWrite,
+	PermissionRoleRead,
+	PermissionRoleWrite,
+	PermissionAuditRead,
+}
@@ -0,0 +1,26 @@
+package def
+
+// Reminder Channel Def
+const (
+	ReminderChannelInbox   = "inbox"
+	ReminderChannelEmail   = "email"
+	ReminderChannelWebhook = "webhook"
+)
+
+// Reminder Limit Def
+const (
+	ReminderTimeLayout           = "15:04"
+	ReminderMaxTimes             = 10
+	ReminderDefaultSnoozeMinutes = 10
+	ReminderMaxSnoozeMinutes     = 720
+	// ReminderMaxCatchUpMinutes 为调度器停顿后最多补发的分钟数
+	ReminderMaxCatchUpMinutes = 10
+)
+
+// Reminder Scheduler Def
+const (
+	ReminderDefaultInterval = 30
+	ReminderDefaultLockTTL  = 600
+	// ReminderLockKeyLayout 为每条提醒每分钟的发送锁，参数为提醒 ID 与 Unix 分钟数
+	ReminderLockKeyLayout = "reminder:lock:%d:%d"
+)
@@ -0,0 +1,24 @@
+package def
+
+// TOTP Def，遵循 RFC 6238 的常用参数
+const (
+	TOTPSecretBytes = 20
+	TOTPDigits      = 6
+	TOTPPeriod      = 30
+	// TOTPSkew 为校验时允许前后偏移的时间步数
+	TOTPSkew = 1
+)
+
+// Two Factor Def
+const (
+	TwoFactorDefaultIssuer = "w2learn"
+	// TwoFactorChallengeTTL 为登录二次验证挑战的有效期，单位为秒
+	TwoFactorChallengeTTL         = 5 * 60
+	TwoFactorChallengeMaxAttempts = 5
+	TwoFactorRecoveryCodeCount    = 10
+	TwoFactorRecoveryCodeBytes    = 5
+	// TwoFactorChallengeKeyLayout 保存二次验证挑战，参数为挑战令牌的 SHA-256 摘要
+	TwoFactorChallengeKeyLayout = "2fa:challenge:%s"
+	// TwoFactorAttemptsKeyLayout 记录挑战的验证失败次数
+	TwoFactorAttemptsKeyLayout = "2fa:attempts:%s"
+)
@@ -0,0 +1,18 @@
+package def
+
+// User Status Def，历史数据中的 1 即为 UserStatusActive
+const (
+	UserStatusActive    int8 = 1
+	UserStatusPending   int8 = 2
+	UserStatusSuspended int8 = 3
+	UserStatusDeleted   int8 = 4
+)
+
+// Email Verification Def
+const (
+	// EmailVerifyTokenTTL 为邮箱验证令牌的有效期，单位为秒
+	EmailVerifyTokenTTL   = 24 * 60 * 60
+	EmailVerifyTokenBytes = 32
+	// EmailVerifyKeyLayout 保存验证令牌对应的用户与邮箱，参数为令牌的 SHA-256 摘要
+	EmailVerifyKeyLayout = "email:verify:%s"
+)
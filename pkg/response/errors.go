@@ -0,0 +1,151 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"w2learn/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 错误码，客户端根据错误码而不是错误信息判断错误类型
+const (
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeTooManyRequests  = "TOO_MANY_REQUESTS"
+	CodeInternal         = "INTERNAL"
+	CodeUnavailable      = "UNAVAILABLE"
+)
+
+var codeStatus = map[string]int{
+	CodeBadRequest:       http.StatusBadRequest,
+	CodeValidationFailed: http.StatusBadRequest,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeForbidden:        http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeConflict:         http.StatusConflict,
+	CodeTooManyRequests:  http.StatusTooManyRequests,
+	CodeInternal:         http.StatusInternalServerError,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+}
+
+var (
+	ErrUnauthorized = Unauthorized("unauthorized")
+	ErrForbidden    = Forbidden("permission denied")
+	ErrInternal     = NewError(CodeInternal, "internal server error")
+)
+
+// AppError 为带有错误码的应用错误，Message 可以直接返回给客户端，Err 为不对外暴露的原始错误
+// Fields 为按字段列出的校验错误，只在 VALIDATION_FAILED 时使用
+type AppError struct {
+	Code    string
+	Status  int
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError 描述单个字段的校验错误，Field 为 JSON 字段路径，Rule 为未通过的校验规则
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// NewError 创建错误码为 code 的应用错误，HTTP 状态码由错误码决定
+func NewError(code string, message string) *AppError {
+	return Wrap(code, message, nil)
+}
+
+// Wrap 使用错误码与对外的错误信息包装原始错误
+func Wrap(code string, message string, err error) *AppError {
+	status, ok := codeStatus[code]
+
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	return &AppError{
+		Code:    code,
+		Status:  status,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func BadRequest(message string) *AppError {
+	return NewError(CodeBadRequest, message)
+}
+
+func Validation(message string) *AppError {
+	return NewError(CodeValidationFailed, message)
+}
+
+func Unauthorized(message string) *AppError {
+	return NewError(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *AppError {
+	return NewError(CodeForbidden, message)
+}
+
+func NotFound(message string) *AppError {
+	return NewError(CodeNotFound, message)
+}
+
+func Conflict(message string) *AppError {
+	return NewError(CodeConflict, message)
+}
+
+func Unavailable(message string) *AppError {
+	return NewError(CodeUnavailable, message)
+}
+
+// FromError 将任意错误转换为应用错误，未知错误统一视为内部错误，原始信息只记录日志不返回给客户端
+func FromError(err error) *AppError {
+	var appErr *AppError
+
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var numErr *strconv.NumError
+
+	if errors.As(err, &numErr) {
+		return Wrap(CodeValidationFailed, "invalid number: "+strconv.Quote(numErr.Num), err)
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return Wrap(CodeNotFound, "record not found", err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return Wrap(CodeConflict, "record already exists", err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return Wrap(CodeConflict, "record is referenced by other records", err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(CodeUnavailable, "request timed out", err)
+	}
+
+	logger.Error("Unhandled error", zap.Error(err))
+
+	return Wrap(CodeInternal, ErrInternal.Message, err)
+}
@@ -6,10 +6,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Response 中 ErrorCode 只在错误响应中出现，取值见 Code* 常量，Errors 为按字段列出的校验错误
 type Response struct {
-	Code int         `json:"code"`
-	Msg  string      `json:"msg"`
-	Data interface{} `json:"data"`
+	Code      int          `json:"code"`
+	Msg       string       `json:"msg"`
+	ErrorCode string       `json:"error_code,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Data      interface{}  `json:"data"`
 }
 
 const (
@@ -30,10 +33,25 @@ func Success(c *gin.Context, data interface{}) {
 	})
 }
 
+// Error 返回错误响应，HTTP 状态码与错误码由 data 决定，data 为字符串时视为请求参数错误
+// 未知错误只返回通用的错误信息，避免泄露数据库等内部错误
 func Error(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, Response{
-		Code: ErrorCodeDefault,
-		Msg:  ErrorMsgDefault,
-		Data: data,
+	var appErr *AppError
+
+	switch v := data.(type) {
+	case error:
+		appErr = FromError(v)
+	case string:
+		appErr = BadRequest(v)
+	default:
+		appErr = ErrInternal
+	}
+
+	c.JSON(appErr.Status, Response{
+		Code:      ErrorCodeDefault,
+		Msg:       ErrorMsgDefault,
+		ErrorCode: appErr.Code,
+		Errors:    appErr.Fields,
+		Data:      appErr.Message,
 	})
 }